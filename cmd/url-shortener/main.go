@@ -1,21 +1,17 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"log/slog"
-	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
-	ssogrpc "github.com/Braendie/url-shortener/internal/clients/sso/grpc"
+	"github.com/Braendie/url-shortener/internal/app"
 	"github.com/Braendie/url-shortener/internal/config"
-	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/delete"
-	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/redirect"
-	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/save"
-	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth/jwt"
 	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogpretty"
 	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
-	"github.com/Braendie/url-shortener/internal/storage/sqlite"
-	"github.com/go-chi/chi/v5"
-	"github.com/go-chi/chi/v5/middleware"
 )
 
 const (
@@ -29,55 +25,82 @@ func main() {
 
 	log := setupLogger(cfg.Env)
 
+	if args := flag.Args(); len(args) > 0 {
+		if command, ok := commands[args[0]]; ok {
+			if err := runCommand(log, cfg, command, args[1:]); err != nil {
+				log.Error("command failed", slog.String("command", args[0]), sl.Err(err))
+				os.Exit(1)
+			}
+
+			return
+		}
+	}
+
 	log.Info("starting url-shortener", slog.String("env", cfg.Env))
 	log.Debug("debug messages are enabled")
 
-	ssoClient, err := ssogrpc.New(
-		log,
-		cfg.Clients.SSO.Address,
-		cfg.Clients.SSO.Timeout,
-		cfg.Clients.SSO.RetriesCount,
-	)
+	application, err := app.New(log, cfg)
 	if err != nil {
-		panic(err)
+		log.Error("failed to initialize app", sl.Err(err))
+		os.Exit(1)
 	}
 
-	storage, err := sqlite.New(cfg.StoragePath)
-	if err != nil {
-		log.Error("failed to initialize storage", sl.Err(err))
+	if err := application.Start(); err != nil {
+		log.Error("failed to start app", sl.Err(err))
 		os.Exit(1)
 	}
 
-	router := chi.NewRouter()
-	router.Use(middleware.RequestID)
-	router.Use(middleware.Logger)
-	router.Use(middleware.Recoverer)
-	router.Use(middleware.URLFormat)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	router.Route("/url", func(r chi.Router) {
-		r.Use(jwt.New(cfg, log, ssoClient))
+	exitCode := 0
 
-		r.Post("/", save.New(log, storage, cfg.AliasLength))
-		r.Delete("/{alias}", delete.New(log, storage))
-	})
+	select {
+	case <-ctx.Done():
+		log.Info("received shutdown signal")
+	case err := <-application.Err():
+		log.Error("server stopped unexpectedly", sl.Err(err))
+		exitCode = 1
+	}
 
-	router.Get("/{alias}", redirect.New(log, storage))
+	shutdownCtx, cancel := context.WithTimeout(
+		context.Background(),
+		cfg.HTTPServer.ShutdownDelay+cfg.HTTPServer.ShutdownTimeout,
+	)
+	defer cancel()
 
-	log.Info("starting server", slog.String("address", cfg.Address))
+	if err := application.Shutdown(shutdownCtx); err != nil {
+		log.Error("failed to shut down gracefully", sl.Err(err))
+		exitCode = 1
+	}
 
-	srv := &http.Server{
-		Addr:         cfg.Address,
-		Handler:      router,
-		ReadTimeout:  cfg.HTTPServer.Timeout,
-		WriteTimeout: cfg.HTTPServer.Timeout,
-		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
+	if exitCode != 0 {
+		os.Exit(exitCode)
 	}
 
-	if err := srv.ListenAndServe(); err != nil {
-		log.Error("failed to start server")
+	log.Info("server stopped")
+}
+
+// commands are the subcommands that work on the storage instead of serving.
+var commands = map[string]func(log *slog.Logger, cfg *config.Config, storage app.Storage, args []string) error{
+	"migrate": runMigrate,
+	"export":  runExport,
+	"import":  runImport,
+}
+
+func runCommand(
+	log *slog.Logger,
+	cfg *config.Config,
+	command func(log *slog.Logger, cfg *config.Config, storage app.Storage, args []string) error,
+	args []string,
+) error {
+	storage, err := app.NewStorage(cfg)
+	if err != nil {
+		return err
 	}
+	defer storage.Close()
 
-	log.Error("server stopped")
+	return command(log, cfg, storage, args)
 }
 
 func setupLogger(env string) *slog.Logger {
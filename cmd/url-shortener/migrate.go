@@ -0,0 +1,92 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/app"
+	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/Braendie/url-shortener/internal/storage/migrator"
+)
+
+const migrateUsage = "usage: url-shortener -config=<path> migrate up|down|status|to <version>"
+
+// runMigrate implements the migrate subcommand.
+func runMigrate(log *slog.Logger, _ *config.Config, storage app.Storage, args []string) error {
+	const op = "main.runMigrate"
+
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	m, err := storage.Migrator()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch args[0] {
+	case "up":
+		err = m.Up()
+	case "down":
+		err = m.Down()
+	case "to":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+
+		version, parseErr := strconv.ParseInt(args[1], 10, 64)
+		if parseErr != nil {
+			return fmt.Errorf("%s: invalid version %q", op, args[1])
+		}
+
+		err = m.To(version)
+	case "status":
+		return printMigrationStatus(m)
+	default:
+		return errors.New(migrateUsage)
+	}
+
+	if errors.Is(err, migrator.ErrNoChange) {
+		log.Info("schema is up to date")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	version, err := m.Version()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("migrations applied", slog.Int64("version", version))
+
+	return nil
+}
+
+func printMigrationStatus(m *migrator.Migrator) error {
+	const op = "main.printMigrationStatus"
+
+	statuses, err := m.Status()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+
+	for _, st := range statuses {
+		appliedAt := "pending"
+		if st.Applied {
+			appliedAt = st.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\n", st.Version, st.Name, appliedAt)
+	}
+
+	return w.Flush()
+}
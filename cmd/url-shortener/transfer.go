@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Braendie/url-shortener/internal/app"
+	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/transfer"
+	"github.com/Braendie/url-shortener/internal/storage"
+)
+
+// runExport implements the export subcommand.
+func runExport(log *slog.Logger, _ *config.Config, s app.Storage, args []string) error {
+	const op = "main.runExport"
+
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	rawFormat := fs.String("format", string(transfer.FormatJSON), "output format: json, csv or ndjson")
+	ownerID := fs.Int64("owner", 0, "export only the links of this owner, 0 for all")
+	domain := fs.String("domain", "", "export the links of this custom domain instead of the default one")
+	output := fs.String("o", "-", "output file, - for stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	format, err := transfer.ParseFormat(*rawFormat)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "-" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		defer f.Close()
+
+		out = f
+	}
+
+	enc := transfer.NewEncoder(out, format)
+
+	var count int64
+
+	err = s.ExportURLs(context.Background(), strings.ToLower(*domain), *ownerID, func(u storage.URL) error {
+		count++
+		return enc.Encode(transfer.FromURL(u))
+	})
+	if err == nil {
+		err = enc.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// The log shares stdout with the export, so it is only written for files.
+	if *output != "-" {
+		log.Info("urls exported", slog.Int64("count", count), slog.String("file", *output))
+	}
+
+	return nil
+}
+
+// runImport implements the import subcommand.
+func runImport(log *slog.Logger, cfg *config.Config, s app.Storage, args []string) error {
+	const op = "main.runImport"
+
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	rawFormat := fs.String("format", "", "input format: json, csv or ndjson; taken from the file extension by default")
+	onConflict := fs.String("on-conflict", string(storage.ConflictSkip), "what to do with existing aliases: skip, overwrite or fail")
+	domain := fs.String("domain", "", "import into this custom domain instead of the default one")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	policy := storage.ConflictPolicy(*onConflict)
+	switch policy {
+	case storage.ConflictSkip, storage.ConflictOverwrite, storage.ConflictFail:
+	default:
+		return fmt.Errorf("%s: invalid -on-conflict %q", op, *onConflict)
+	}
+
+	var in io.Reader = os.Stdin
+	input := "-"
+	if fs.NArg() > 0 {
+		input = fs.Arg(0)
+	}
+	if input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		defer f.Close()
+
+		in = f
+	}
+
+	if *rawFormat == "" {
+		*rawFormat = string(transfer.FormatJSON)
+		if ext := strings.TrimPrefix(filepath.Ext(input), "."); ext != "" {
+			*rawFormat = ext
+		}
+	}
+
+	format, err := transfer.ParseFormat(*rawFormat)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	dec := transfer.NewDecoder(in, format)
+	rules := app.NewAliasRules(cfg.Alias)
+
+	// Invalid records are logged one by one, and then fail the import.
+	var records, invalid int
+
+	res, err := s.ImportURLs(context.Background(), func() (storage.URL, error) {
+		for {
+			rec, err := dec.Decode()
+			if errors.Is(err, io.EOF) && invalid > 0 {
+				return storage.URL{}, fmt.Errorf("%d invalid records", invalid)
+			}
+			if err != nil {
+				return storage.URL{}, err
+			}
+
+			records++
+
+			rec, err = rec.Prepare(rules)
+			if err != nil {
+				invalid++
+				log.Error("invalid record", slog.Int("index", records-1), sl.Err(err))
+				continue
+			}
+			if invalid > 0 {
+				continue
+			}
+
+			u := rec.ToURL()
+			u.Domain = strings.ToLower(*domain)
+
+			return u, nil
+		}
+	}, policy)
+	if errors.Is(err, storage.ErrURLExists) {
+		return fmt.Errorf("%s: nothing imported: %w", op, err)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("urls imported",
+		slog.Int64("created", res.Created),
+		slog.Int64("overwritten", res.Overwritten),
+		slog.Int64("skipped", res.Skipped),
+	)
+
+	return nil
+}
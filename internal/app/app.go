@@ -0,0 +1,644 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	ssogrpc "github.com/Braendie/url-shortener/internal/clients/sso/grpc"
+	"github.com/Braendie/url-shortener/internal/config"
+	domaindelete "github.com/Braendie/url-shortener/internal/http-server/handlers/domain/delete"
+	domainlist "github.com/Braendie/url-shortener/internal/http-server/handlers/domain/list"
+	domainsave "github.com/Braendie/url-shortener/internal/http-server/handlers/domain/save"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/health"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/batch"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/delete"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/export"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/history"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/importer"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/info"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/list"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/preview"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/qr"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/redirect"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/save"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/stats"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/update"
+	mwalias "github.com/Braendie/url-shortener/internal/http-server/middleware/alias"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth/jwt"
+	mwdomain "github.com/Braendie/url-shortener/internal/http-server/middleware/domain"
+	mwidempotency "github.com/Braendie/url-shortener/internal/http-server/middleware/idempotency"
+	mwmetrics "github.com/Braendie/url-shortener/internal/http-server/middleware/metrics"
+	mwratelimit "github.com/Braendie/url-shortener/internal/http-server/middleware/ratelimit"
+	mwtracing "github.com/Braendie/url-shortener/internal/http-server/middleware/tracing"
+	"github.com/Braendie/url-shortener/internal/lib/alias"
+	"github.com/Braendie/url-shortener/internal/lib/domains"
+	"github.com/Braendie/url-shortener/internal/lib/idempotency"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/metrics"
+	"github.com/Braendie/url-shortener/internal/lib/password"
+	"github.com/Braendie/url-shortener/internal/lib/ratelimit"
+	"github.com/Braendie/url-shortener/internal/lib/shorturl"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/Braendie/url-shortener/internal/storage/cache"
+	"github.com/Braendie/url-shortener/internal/storage/clicks"
+	"github.com/Braendie/url-shortener/internal/storage/janitor"
+	"github.com/Braendie/url-shortener/internal/storage/migrator"
+	"github.com/Braendie/url-shortener/internal/storage/postgres"
+	"github.com/Braendie/url-shortener/internal/storage/sqlite"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Storage is the set of storage operations the service depends on.
+type Storage interface {
+	save.URLSaver
+	batch.URLBatchSaver
+	redirect.URLGetter
+	redirect.ClickLimiter
+	delete.URLDeleter
+	delete.URLInfoGetter
+	janitor.ExpiredURLDeleter
+	clicks.ClicksSaver
+	stats.URLStatsGetter
+	list.URLLister
+	update.URLUpdater
+	history.URLHistoryGetter
+	info.ClickCounter
+	export.URLExporter
+	importer.URLImporter
+	alias.Sequence
+	domains.Storage
+	Migrator() (*migrator.Migrator, error)
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+// NewStorage opens the storage selected by the config.
+func NewStorage(cfg *config.Config) (Storage, error) {
+	switch cfg.StorageDriver {
+	case config.StorageDriverPostgres:
+		return postgres.New(cfg.Postgres.DSN)
+	default:
+		return sqlite.New(cfg.StoragePath)
+	}
+}
+
+// App owns the components of the service and controls their lifecycle.
+type App struct {
+	log *slog.Logger
+	cfg *config.Config
+
+	storage      Storage
+	ssoClient    *ssogrpc.Client
+	janitor      *janitor.Janitor
+	clicksWriter *clicks.Writer
+	probe        *health.Probe
+	aliases      *alias.Allocator
+	aliasRules   *alias.Rules
+	shortLinks   *shorturl.Builder
+	domains      *domains.Registry
+
+	rateLimitStore   mwratelimit.Store
+	idempotencyStore mwidempotency.Store
+
+	shutdownTracing func(context.Context) error
+	metrics         *metrics.Metrics
+	server          *http.Server
+	adminServer     *http.Server
+
+	listener      net.Listener
+	adminListener net.Listener
+	serveErr      chan error
+	shutdownOnce  sync.Once
+	shutdownErr   error
+}
+
+// New opens the storage, applies migrations if enabled and wires up the HTTP server.
+// Nothing is started until Start is called.
+func New(log *slog.Logger, cfg *config.Config) (*App, error) {
+	const op = "app.New"
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	storage, err := NewStorage(cfg)
+	if err != nil {
+		_ = shutdownTracing(context.Background())
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if cfg.AutoMigrate {
+		if err := migrateUp(log, storage); err != nil {
+			_ = storage.Close()
+			_ = shutdownTracing(context.Background())
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	m := metrics.New(cfg.Metrics.MaxAliases)
+	storage = instrumentedStorage{Storage: storage, metrics: m}
+	storage = tracedStorage{Storage: storage, tracer: tracing.Tracer(), driver: cfg.StorageDriver}
+
+	aliasRules := NewAliasRules(cfg.Alias)
+
+	aliasGenerator, err := alias.NewGenerator(cfg.Alias, cfg.AliasLength, storage)
+	if err != nil {
+		_ = storage.Close()
+		_ = shutdownTracing(context.Background())
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	shortLinks, err := shorturl.New(cfg.BaseURL, cfg.TrustedProxies)
+	if err != nil {
+		_ = storage.Close()
+		_ = shutdownTracing(context.Background())
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	ssoClient, err := ssogrpc.New(
+		log,
+		cfg.Clients.SSO.Address,
+		cfg.Clients.SSO.Timeout,
+		cfg.Clients.SSO.RetriesCount,
+		m,
+	)
+	if err != nil {
+		_ = storage.Close()
+		_ = shutdownTracing(context.Background())
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	a := &App{
+		log:        log,
+		cfg:        cfg,
+		storage:    storage,
+		ssoClient:  ssoClient,
+		metrics:    m,
+		aliases:    alias.NewAllocator(aliasGenerator, aliasRules, cfg.Alias.MaxAttempts, cfg.Alias.EscalateAfter),
+		aliasRules: aliasRules,
+		shortLinks: shortLinks,
+		domains:    domains.NewRegistry(storage, shortLinks.DefaultHost(), cfg.Domains.RefreshInterval),
+
+		rateLimitStore:   ratelimit.NewMemoryStore(),
+		idempotencyStore: idempotency.NewMemoryStore(cfg.Idempotency.TTL),
+
+		shutdownTracing: shutdownTracing,
+		clicksWriter: clicks.New(
+			log,
+			storage,
+			cfg.AppSecret,
+			cfg.Clicks.BufferSize,
+			cfg.Clicks.BatchSize,
+			cfg.Clicks.FlushInterval,
+		),
+		serveErr: make(chan error, 2),
+	}
+
+	a.probe = health.New(log, cfg.HTTPServer.ReadinessTimeout)
+	a.probe.Add("storage", health.CheckerFunc(storage.Ping))
+	a.probe.Add("sso", health.CheckerFunc(ssoClient.Check))
+
+	if cfg.Janitor.Interval > 0 {
+		a.janitor = janitor.New(log, storage, cfg.Janitor.Interval)
+	}
+
+	a.server = &http.Server{
+		Addr:         cfg.Address,
+		Handler:      a.router(),
+		ReadTimeout:  cfg.HTTPServer.Timeout,
+		WriteTimeout: cfg.HTTPServer.Timeout,
+		IdleTimeout:  cfg.HTTPServer.IdleTimeout,
+	}
+
+	if cfg.HTTPServer.AdminAddress != "" {
+		a.adminServer = &http.Server{
+			Addr:         cfg.HTTPServer.AdminAddress,
+			Handler:      a.adminRouter(),
+			ReadTimeout:  cfg.HTTPServer.Timeout,
+			WriteTimeout: cfg.HTTPServer.Timeout,
+			IdleTimeout:  cfg.HTTPServer.IdleTimeout,
+		}
+	}
+
+	return a, nil
+}
+
+// ReservedPaths are the first path segments of the routes of the service,
+// which no alias may take so that no link shadows an endpoint.
+var ReservedPaths = []string{"url", "domains", "healthz", "readyz", "metrics"}
+
+// NewAliasRules returns the rules of custom aliases as the service enforces
+// them, with ReservedPaths reserved.
+func NewAliasRules(cfg config.Alias) *alias.Rules {
+	rules := alias.NewRules(cfg)
+	rules.Reserve(ReservedPaths...)
+
+	return rules
+}
+
+func (a *App) router() http.Handler {
+	// Redirects, creations, updates, deletes and imports go through the cache
+	// so that it is invalidated whenever a link changes.
+	var urlCache cache.URLStorage = a.storage
+	var urlGetter redirect.URLGetter = a.storage
+	var urlImporter importer.URLImporter = a.storage
+	if a.cfg.Cache.Size > 0 {
+		c := cache.New(a.storage, a.cfg.Cache.Size, a.cfg.Cache.TTL, a.cfg.Cache.NegativeTTL)
+		urlCache, urlGetter = c, c
+		urlImporter = invalidatingImporter{URLImporter: a.storage, cache: c}
+
+		a.metrics.RegisterCache(func() (uint64, uint64, int) {
+			st := c.Stats()
+			return st.Hits, st.Misses, st.Size
+		})
+	}
+
+	router := chi.NewRouter()
+	router.Use(middleware.RequestID)
+	router.Use(mwtracing.New(tracing.Tracer()))
+	router.Use(mwmetrics.New(a.metrics))
+	router.Use(middleware.Logger)
+	router.Use(middleware.Recoverer)
+	router.Use(middleware.URLFormat)
+
+	router.Get("/healthz", a.probe.Live())
+	router.Get("/readyz", a.probe.Ready())
+
+	// normalizeAlias resolves the {alias} parameter the way aliases are stored.
+	normalizeAlias := mwalias.New(a.aliasRules.Normalize)
+	// resolveDomain serves the links of the domain the request is sent to, and
+	// manageDomain picks the domain managed through the API.
+	resolveDomain, manageDomain := a.resolveDomain()
+	idempotent := a.idempotent()
+
+	router.Route("/url", func(r chi.Router) {
+		r.Use(manageDomain)
+		r.Use(jwt.New(a.cfg, a.log, a.ssoClient))
+
+		r.With(a.rateLimit("list")).Get("/", list.New(a.log, a.storage, a.shortLinks))
+		r.With(a.rateLimit("save"), idempotent).
+			Post("/", save.New(a.log, urlCache, a.aliases, a.aliasRules, a.shortLinks, a.cfg.Dedup))
+		r.With(a.rateLimit("batch"), idempotent).
+			Post("/batch", batch.New(
+				a.log, urlCache, a.aliases, a.aliasRules, a.cfg.BatchMaxItems, a.cfg.BatchMaxPasswords, a.cfg.Dedup,
+			))
+		r.With(a.rateLimit("export")).Get("/export", export.New(a.log, a.storage, a.cfg.HTTPServer.Timeout))
+		r.With(a.rateLimit("import")).Post("/import", importer.New(
+			a.log, urlImporter, a.aliasRules, a.cfg.ImportMaxSize, a.cfg.HTTPServer.Timeout,
+		))
+		r.With(a.rateLimit("info"), normalizeAlias).
+			Get("/{alias}", info.New(a.log, a.storage, a.storage, a.shortLinks))
+		r.With(a.rateLimit("update"), normalizeAlias).Patch("/{alias}", update.New(a.log, urlCache, a.storage))
+		r.With(a.rateLimit("delete"), normalizeAlias).Delete("/{alias}", delete.New(a.log, urlCache, a.storage))
+		r.With(a.rateLimit("history"), normalizeAlias).
+			Get("/{alias}/history", history.New(a.log, a.storage, a.storage))
+		r.With(a.rateLimit("stats"), normalizeAlias).Get("/{alias}/stats", stats.New(a.log, a.storage, a.storage))
+		r.With(a.rateLimit("qr"), normalizeAlias).Get("/{alias}/qr", qr.New(a.log, a.storage, a.shortLinks, a.cfg.QR))
+	})
+
+	if a.cfg.Domains.Enabled {
+		router.Route("/domains", func(r chi.Router) {
+			r.Use(jwt.New(a.cfg, a.log, a.ssoClient))
+			r.Use(a.rateLimit("domains"))
+
+			r.Get("/", domainlist.New(a.log, a.domains))
+			r.Put("/{domain}", domainsave.New(a.log, a.domains, a.shortLinks.DefaultHost()))
+			r.Delete("/{domain}", domaindelete.New(a.log, a.domains))
+		})
+	}
+
+	redirectHandler := redirect.New(
+		a.log,
+		urlGetter,
+		urlCache,
+		redirectRecorder{next: a.clicksWriter, metrics: a.metrics},
+		password.NewGuard(a.cfg.AppSecret, a.cfg.Protection, a.shortLinks.Scheme),
+		a.shortLinks.ClientIP,
+		a.cfg.Redirect,
+	)
+	router.With(a.rateLimit("redirect"), resolveDomain, normalizeAlias).Get("/{alias}", redirectHandler)
+	router.With(a.rateLimit("redirect"), resolveDomain, normalizeAlias).Head("/{alias}", redirectHandler)
+	// Unlocks password-protected links.
+	router.With(a.rateLimit("redirect"), resolveDomain, normalizeAlias).Post("/{alias}", redirectHandler)
+	router.With(a.rateLimit("redirect"), resolveDomain, normalizeAlias).Get("/{alias}+", preview.New(a.log, urlGetter))
+
+	mustReserveRoutes(router)
+
+	return router
+}
+
+// mustReserveRoutes panics if the first path segment of a route is not in
+// ReservedPaths.
+func mustReserveRoutes(routes chi.Routes) {
+	_ = chi.Walk(routes, func(_ string, route string, _ http.Handler, _ ...func(http.Handler) http.Handler) error {
+		segment, _, _ := strings.Cut(strings.TrimPrefix(route, "/"), "/")
+		if segment != "" && !strings.ContainsAny(segment, "{*") && !slices.Contains(ReservedPaths, segment) {
+			panic(fmt.Sprintf("route %s is not in ReservedPaths", route))
+		}
+
+		return nil
+	})
+}
+
+// rateLimit returns the limiter of the named route. Redirects are limited per
+// client address and everything else per user.
+func (a *App) rateLimit(route string) func(http.Handler) http.Handler {
+	cfg := a.cfg.RateLimit
+
+	rule, key := cfg.Management, mwratelimit.ByUser(a.shortLinks.ClientIP)
+	if route == "redirect" {
+		rule, key = cfg.Redirect, mwratelimit.ByIP(a.shortLinks.ClientIP)
+	}
+	if r, ok := cfg.Routes[route]; ok {
+		rule = r
+	}
+
+	if !cfg.Enabled || rule.RPS <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	limit := ratelimit.Limit{Rate: rule.RPS, Burst: max(rule.Burst, 1)}
+
+	return mwratelimit.New(a.log, a.rateLimitStore, route, limit, key)
+}
+
+// resolveDomain returns the middlewares that put the domain of the request
+// into its context. Without custom domains every request is for the default
+// domain. With them, links are only served on the default host and on
+// registered domains, while the /url API answers on any host, including
+// internal ones: requests for a registered domain manage its links and every
+// other host manages the default domain.
+func (a *App) resolveDomain() (serve, manage func(http.Handler) http.Handler) {
+	if !a.cfg.Domains.Enabled {
+		noop := func(next http.Handler) http.Handler { return next }
+		return noop, noop
+	}
+
+	return mwdomain.New(a.log, a.domains, a.shortLinks.Host),
+		mwdomain.New(a.log, defaultOnUnknownHost{a.domains}, a.shortLinks.Host)
+}
+
+// idempotent returns the middleware replaying responses to requests retried
+// with the same Idempotency-Key header.
+func (a *App) idempotent() func(http.Handler) http.Handler {
+	if a.cfg.Idempotency.TTL <= 0 {
+		return func(next http.Handler) http.Handler { return next }
+	}
+
+	return mwidempotency.New(
+		a.log,
+		a.idempotencyStore,
+		a.cfg.Idempotency.MaxRequestSize,
+		a.cfg.Idempotency.MaxResponseSize,
+	)
+}
+
+func (a *App) adminRouter() http.Handler {
+	router := chi.NewRouter()
+	router.Use(middleware.Recoverer)
+
+	router.Handle("/metrics", a.metrics.Handler())
+
+	mustReserveRoutes(router)
+
+	return router
+}
+
+// defaultOnUnknownHost resolves hosts that are not registered to the default
+// domain.
+type defaultOnUnknownHost struct {
+	resolver mwdomain.Resolver
+}
+
+func (d defaultOnUnknownHost) Resolve(ctx context.Context, host string) (storage.Domain, error) {
+	dom, err := d.resolver.Resolve(ctx, host)
+	if errors.Is(err, domains.ErrUnknownHost) {
+		return storage.Domain{}, nil
+	}
+
+	return dom, err
+}
+
+// redirectRecorder counts redirects per alias before recording the click.
+// Aliases of custom domains are counted as domain/alias.
+type redirectRecorder struct {
+	next    redirect.ClickRecorder
+	metrics *metrics.Metrics
+}
+
+func (r redirectRecorder) RecordClick(click storage.Click) {
+	name := click.Alias
+	if click.Domain != "" {
+		name = click.Domain + "/" + click.Alias
+	}
+
+	r.metrics.ObserveRedirect(name)
+	r.next.RecordClick(click)
+}
+
+// invalidatingImporter drops the imported aliases from the cache once the
+// import transaction is over, so that overwritten links and aliases that were
+// negatively cached are read again.
+type invalidatingImporter struct {
+	importer.URLImporter
+	cache *cache.Cache
+}
+
+func (i invalidatingImporter) ImportURLs(
+	ctx context.Context,
+	next func() (storage.URL, error),
+	policy storage.ConflictPolicy,
+) (storage.ImportResult, error) {
+	var imported []storage.URL
+
+	res, err := i.URLImporter.ImportURLs(ctx, func() (storage.URL, error) {
+		u, err := next()
+		if err == nil {
+			imported = append(imported, storage.URL{Domain: u.Domain, Alias: u.Alias})
+		}
+
+		return u, err
+	}, policy)
+
+	for _, u := range imported {
+		i.cache.Invalidate(u.Domain, u.Alias)
+	}
+
+	return res, err
+}
+
+// Start starts the background workers, binds the HTTP and admin listeners and
+// serves requests in separate goroutines. Errors that stop serving are reported by Err.
+func (a *App) Start() error {
+	const op = "app.Start"
+
+	ln, err := net.Listen("tcp", a.server.Addr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if a.adminServer != nil {
+		adminLn, err := net.Listen("tcp", a.adminServer.Addr)
+		if err != nil {
+			_ = ln.Close()
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		a.adminListener = adminLn
+	}
+	a.listener = ln
+
+	if a.janitor != nil {
+		a.janitor.Start()
+	}
+	a.clicksWriter.Start()
+
+	var wg sync.WaitGroup
+
+	a.log.Info("starting server", slog.String("address", ln.Addr().String()))
+	wg.Add(1)
+	go a.serve(&wg, a.server, ln)
+
+	if a.adminListener != nil {
+		a.log.Info("starting admin server", slog.String("address", a.adminListener.Addr().String()))
+		wg.Add(1)
+		go a.serve(&wg, a.adminServer, a.adminListener)
+	}
+
+	go func() {
+		wg.Wait()
+		close(a.serveErr)
+	}()
+
+	return nil
+}
+
+func (a *App) serve(wg *sync.WaitGroup, srv *http.Server, ln net.Listener) {
+	const op = "app.serve"
+
+	defer wg.Done()
+
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		a.serveErr <- fmt.Errorf("%s: %s: %w", op, ln.Addr(), err)
+	}
+}
+
+// Addr returns the address the server listens on. It is only valid after Start.
+func (a *App) Addr() string {
+	return a.listener.Addr().String()
+}
+
+// AdminAddr returns the address the admin server listens on. It is only valid
+// after Start and empty if the admin listener is disabled.
+func (a *App) AdminAddr() string {
+	if a.adminListener == nil {
+		return ""
+	}
+
+	return a.adminListener.Addr().String()
+}
+
+// Err returns a channel that receives an error if a server stops serving
+// unexpectedly. The channel is closed once all servers have stopped.
+func (a *App) Err() <-chan error {
+	return a.serveErr
+}
+
+// Shutdown fails readiness and waits for the configured delay, then stops
+// accepting new requests and waits for in-flight ones until ctx is done.
+// Finally it stops the background workers and closes storage and the SSO
+// client. It is safe to call more than once.
+func (a *App) Shutdown(ctx context.Context) error {
+	a.shutdownOnce.Do(func() {
+		a.shutdownErr = a.shutdown(ctx)
+	})
+
+	return a.shutdownErr
+}
+
+func (a *App) shutdown(ctx context.Context) error {
+	const op = "app.Shutdown"
+
+	a.log.Info("shutting down")
+
+	var errs []error
+
+	a.probe.SetShuttingDown()
+
+	if a.listener != nil && a.cfg.HTTPServer.ShutdownDelay > 0 {
+		select {
+		case <-time.After(a.cfg.HTTPServer.ShutdownDelay):
+		case <-ctx.Done():
+		}
+	}
+
+	if err := a.server.Shutdown(ctx); err != nil {
+		a.log.Error("failed to drain http server", sl.Err(err))
+		errs = append(errs, fmt.Errorf("%s: http server: %w", op, err))
+	}
+
+	// The admin server stays up while requests drain, so that metrics of the
+	// shutdown itself can still be scraped.
+	if a.adminServer != nil {
+		if err := a.adminServer.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("%s: admin server: %w", op, err))
+		}
+	}
+
+	if a.listener != nil {
+		if a.janitor != nil {
+			a.janitor.Stop()
+		}
+		// In-flight redirects are done, so every recorded click is flushed.
+		a.clicksWriter.Stop()
+	}
+
+	if err := a.storage.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("%s: storage: %w", op, err))
+	}
+
+	if err := a.ssoClient.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("%s: sso client: %w", op, err))
+	}
+
+	if err := a.shutdownTracing(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("%s: tracing: %w", op, err))
+	}
+
+	a.log.Info("shutdown complete")
+
+	return errors.Join(errs...)
+}
+
+// migrateUp applies pending migrations on startup.
+func migrateUp(log *slog.Logger, storage Storage) error {
+	const op = "app.migrateUp"
+
+	m, err := storage.Migrator()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = m.Up()
+	if errors.Is(err, migrator.ErrNoChange) {
+		log.Debug("schema is up to date")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("migrations applied")
+
+	return nil
+}
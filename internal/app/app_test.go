@@ -0,0 +1,152 @@
+package app_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/app"
+	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/health"
+	"github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testConfig(t *testing.T) *config.Config {
+	t.Helper()
+
+	return &config.Config{
+		Env:           "local",
+		StorageDriver: config.StorageDriverSQLite,
+		StoragePath:   t.TempDir(),
+		AutoMigrate:   true,
+		Clicks: config.Clicks{
+			BufferSize:    10,
+			BatchSize:     10,
+			FlushInterval: time.Second,
+		},
+		Metrics: config.Metrics{
+			MaxAliases: 10,
+		},
+		Cache: config.Cache{
+			Size:        10,
+			TTL:         time.Minute,
+			NegativeTTL: time.Second,
+		},
+		HTTPServer: config.HTTPServer{
+			Address:          "127.0.0.1:0",
+			AdminAddress:     "127.0.0.1:0",
+			Timeout:          time.Second,
+			IdleTimeout:      time.Second,
+			ShutdownTimeout:  time.Second,
+			ReadinessTimeout: time.Second,
+			AliasLength:      6,
+		},
+		Clients: config.ClientConfig{
+			SSO: config.Client{
+				Address: "127.0.0.1:1",
+				Timeout: time.Second,
+			},
+		},
+		AppSecret: "secret",
+	}
+}
+
+func TestApp_StartShutdown(t *testing.T) {
+	a, err := app.New(slogdiscard.NewDiscardLogger(), testConfig(t))
+	require.NoError(t, err)
+
+	require.NoError(t, a.Start())
+
+	resp, err := http.Get("http://" + a.Addr() + "/unknown")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Get("http://" + a.Addr() + "/healthz")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// Nothing listens on the SSO address, so the instance is not ready.
+	resp, err = http.Get("http://" + a.Addr() + "/readyz")
+	require.NoError(t, err)
+
+	var ready health.Response
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&ready))
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.Equal(t, response.StatusOK, ready.Checks["storage"].Status)
+	assert.Equal(t, response.StatusError, ready.Checks["sso"].Status)
+
+	resp, err = http.Get("http://" + a.AdminAddr() + "/metrics")
+	require.NoError(t, err)
+	metrics, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(metrics), `url_shortener_http_requests_total{code="200",method="GET",route="/healthz"} 1`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, a.Shutdown(ctx))
+	// Shutdown must be safe to call more than once.
+	require.NoError(t, a.Shutdown(ctx))
+
+	_, ok := <-a.Err()
+	assert.False(t, ok, "server is expected to stop without an error")
+
+	_, err = http.Get("http://" + a.Addr() + "/unknown")
+	assert.Error(t, err)
+}
+
+func TestApp_Domains(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.BaseURL = "https://sho.rt"
+	cfg.Domains = config.Domains{Enabled: true, RefreshInterval: time.Hour}
+
+	a, err := app.New(slogdiscard.NewDiscardLogger(), cfg)
+	require.NoError(t, err)
+	require.NoError(t, a.Start())
+	t.Cleanup(func() { _ = a.Shutdown(context.Background()) })
+
+	get := func(host, path string) int {
+		req, err := http.NewRequest(http.MethodGet, "http://"+a.Addr()+path, nil)
+		require.NoError(t, err)
+		req.Host = host
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, http.StatusNotFound, get("sho.rt", "/missing"))
+	assert.Equal(t, http.StatusMisdirectedRequest, get("evil.com", "/missing"))
+	// The API stays reachable on hosts that serve no links.
+	assert.Equal(t, http.StatusUnauthorized, get("url-shortener.internal", "/url"))
+}
+
+func TestApp_ShutdownWithoutStart(t *testing.T) {
+	a, err := app.New(slogdiscard.NewDiscardLogger(), testConfig(t))
+	require.NoError(t, err)
+
+	require.NoError(t, a.Shutdown(context.Background()))
+}
+
+func TestNewAliasRules(t *testing.T) {
+	rules := app.NewAliasRules(config.Alias{Reserved: []string{"admin"}})
+
+	for _, word := range []string{"url", "healthz", "readyz", "domains", "metrics", "admin"} {
+		assert.True(t, rules.IsReserved(word), word)
+	}
+	assert.False(t, rules.IsReserved("google"))
+}
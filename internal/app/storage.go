@@ -0,0 +1,268 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/lib/metrics"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentedStorage records latency and errors of the storage operations
+// on the request path. Not found, expired, exhausted and duplicate links, as
+// well as unknown domains and domains in use, are expected outcomes and are
+// not counted as errors.
+type instrumentedStorage struct {
+	Storage
+	metrics *metrics.Metrics
+}
+
+func (s instrumentedStorage) SaveURL(ctx context.Context, u storage.URL) (int64, error) {
+	start := time.Now()
+	id, err := s.Storage.SaveURL(ctx, u)
+	s.metrics.ObserveStorage("SaveURL", start, unexpected(err))
+
+	return id, err
+}
+
+func (s instrumentedStorage) GetURL(ctx context.Context, domain, alias string) (storage.URL, error) {
+	start := time.Now()
+	u, err := s.Storage.GetURL(ctx, domain, alias)
+	s.metrics.ObserveStorage("GetURL", start, unexpected(err))
+
+	return u, err
+}
+
+func (s instrumentedStorage) GetURLInfo(ctx context.Context, domain, alias string) (storage.URL, error) {
+	start := time.Now()
+	u, err := s.Storage.GetURLInfo(ctx, domain, alias)
+	s.metrics.ObserveStorage("GetURLInfo", start, unexpected(err))
+
+	return u, err
+}
+
+func (s instrumentedStorage) UseClick(ctx context.Context, domain, alias string) error {
+	start := time.Now()
+	err := s.Storage.UseClick(ctx, domain, alias)
+	s.metrics.ObserveStorage("UseClick", start, unexpected(err))
+
+	return err
+}
+
+func (s instrumentedStorage) DeleteURL(ctx context.Context, domain, alias string) error {
+	start := time.Now()
+	err := s.Storage.DeleteURL(ctx, domain, alias)
+	s.metrics.ObserveStorage("DeleteURL", start, unexpected(err))
+
+	return err
+}
+
+func unexpected(err error) error {
+	if errors.Is(err, storage.ErrURLNotFound) ||
+		errors.Is(err, storage.ErrURLExpired) ||
+		errors.Is(err, storage.ErrURLExhausted) ||
+		errors.Is(err, storage.ErrURLExists) ||
+		errors.Is(err, storage.ErrDomainNotFound) ||
+		errors.Is(err, storage.ErrDomainInUse) {
+		return nil
+	}
+
+	return err
+}
+
+// tracedStorage wraps every storage call made on behalf of a request or a
+// background worker in a client span.
+type tracedStorage struct {
+	Storage
+	tracer trace.Tracer
+	driver string
+}
+
+func (s tracedStorage) start(ctx context.Context, operation string) (context.Context, trace.Span) {
+	return s.tracer.Start(ctx, "storage."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", s.driver),
+			attribute.String("db.operation", operation),
+		),
+	)
+}
+
+func end(span trace.Span, err error) {
+	if err := unexpected(err); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func (s tracedStorage) SaveURL(ctx context.Context, u storage.URL) (int64, error) {
+	ctx, span := s.start(ctx, "SaveURL")
+	id, err := s.Storage.SaveURL(ctx, u)
+	end(span, err)
+
+	return id, err
+}
+
+func (s tracedStorage) SaveURLs(ctx context.Context, urls []storage.URL) ([]storage.SaveResult, error) {
+	ctx, span := s.start(ctx, "SaveURLs")
+	span.SetAttributes(attribute.Int("urls.count", len(urls)))
+	results, err := s.Storage.SaveURLs(ctx, urls)
+	end(span, err)
+
+	return results, err
+}
+
+func (s tracedStorage) GetURL(ctx context.Context, domain, alias string) (storage.URL, error) {
+	ctx, span := s.start(ctx, "GetURL")
+	u, err := s.Storage.GetURL(ctx, domain, alias)
+	end(span, err)
+
+	return u, err
+}
+
+func (s tracedStorage) GetURLInfo(ctx context.Context, domain, alias string) (storage.URL, error) {
+	ctx, span := s.start(ctx, "GetURLInfo")
+	u, err := s.Storage.GetURLInfo(ctx, domain, alias)
+	end(span, err)
+
+	return u, err
+}
+
+func (s tracedStorage) UseClick(ctx context.Context, domain, alias string) error {
+	ctx, span := s.start(ctx, "UseClick")
+	err := s.Storage.UseClick(ctx, domain, alias)
+	end(span, err)
+
+	return err
+}
+
+func (s tracedStorage) UpdateURL(ctx context.Context, domain, alias string, upd storage.URLUpdate) (storage.URL, error) {
+	ctx, span := s.start(ctx, "UpdateURL")
+	u, err := s.Storage.UpdateURL(ctx, domain, alias, upd)
+	end(span, err)
+
+	return u, err
+}
+
+func (s tracedStorage) URLHistory(ctx context.Context, domain, alias string) ([]storage.Revision, error) {
+	ctx, span := s.start(ctx, "URLHistory")
+	revisions, err := s.Storage.URLHistory(ctx, domain, alias)
+	end(span, err)
+
+	return revisions, err
+}
+
+func (s tracedStorage) ListURLs(ctx context.Context, q storage.ListQuery) ([]storage.URL, error) {
+	ctx, span := s.start(ctx, "ListURLs")
+	urls, err := s.Storage.ListURLs(ctx, q)
+	end(span, err)
+
+	return urls, err
+}
+
+func (s tracedStorage) DeleteURL(ctx context.Context, domain, alias string) error {
+	ctx, span := s.start(ctx, "DeleteURL")
+	err := s.Storage.DeleteURL(ctx, domain, alias)
+	end(span, err)
+
+	return err
+}
+
+func (s tracedStorage) DeleteExpiredURLs(ctx context.Context, before time.Time) (int64, error) {
+	ctx, span := s.start(ctx, "DeleteExpiredURLs")
+	n, err := s.Storage.DeleteExpiredURLs(ctx, before)
+	end(span, err)
+
+	return n, err
+}
+
+func (s tracedStorage) SaveClicks(ctx context.Context, clicks []storage.Click) error {
+	ctx, span := s.start(ctx, "SaveClicks")
+	span.SetAttributes(attribute.Int("clicks.count", len(clicks)))
+	err := s.Storage.SaveClicks(ctx, clicks)
+	end(span, err)
+
+	return err
+}
+
+func (s tracedStorage) ClickStats(ctx context.Context, domain, alias string, q storage.StatsQuery) (storage.ClickStats, error) {
+	ctx, span := s.start(ctx, "ClickStats")
+	stats, err := s.Storage.ClickStats(ctx, domain, alias, q)
+	end(span, err)
+
+	return stats, err
+}
+
+func (s tracedStorage) CountClicks(ctx context.Context, domain, alias string) (int64, error) {
+	ctx, span := s.start(ctx, "CountClicks")
+	n, err := s.Storage.CountClicks(ctx, domain, alias)
+	end(span, err)
+
+	return n, err
+}
+
+func (s tracedStorage) ExportURLs(ctx context.Context, domain string, ownerID int64, fn func(storage.URL) error) error {
+	ctx, span := s.start(ctx, "ExportURLs")
+	err := s.Storage.ExportURLs(ctx, domain, ownerID, fn)
+	end(span, err)
+
+	return err
+}
+
+func (s tracedStorage) ImportURLs(
+	ctx context.Context,
+	next func() (storage.URL, error),
+	policy storage.ConflictPolicy,
+) (storage.ImportResult, error) {
+	ctx, span := s.start(ctx, "ImportURLs")
+	span.SetAttributes(attribute.String("import.on_conflict", string(policy)))
+	res, err := s.Storage.ImportURLs(ctx, next, policy)
+	end(span, err)
+
+	return res, err
+}
+
+func (s tracedStorage) NextAliasSeq(ctx context.Context) (int64, error) {
+	ctx, span := s.start(ctx, "NextAliasSeq")
+	n, err := s.Storage.NextAliasSeq(ctx)
+	end(span, err)
+
+	return n, err
+}
+
+func (s tracedStorage) FindGeneratedURL(ctx context.Context, domain, url string, ownerID int64) (storage.URL, error) {
+	ctx, span := s.start(ctx, "FindGeneratedURL")
+	u, err := s.Storage.FindGeneratedURL(ctx, domain, url, ownerID)
+	end(span, err)
+
+	return u, err
+}
+
+func (s tracedStorage) SaveDomain(ctx context.Context, d storage.Domain) error {
+	ctx, span := s.start(ctx, "SaveDomain")
+	err := s.Storage.SaveDomain(ctx, d)
+	end(span, err)
+
+	return err
+}
+
+func (s tracedStorage) ListDomains(ctx context.Context) ([]storage.Domain, error) {
+	ctx, span := s.start(ctx, "ListDomains")
+	domains, err := s.Storage.ListDomains(ctx)
+	end(span, err)
+
+	return domains, err
+}
+
+func (s tracedStorage) DeleteDomain(ctx context.Context, name string) error {
+	ctx, span := s.start(ctx, "DeleteDomain")
+	err := s.Storage.DeleteDomain(ctx, name)
+	end(span, err)
+
+	return err
+}
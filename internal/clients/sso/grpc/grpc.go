@@ -9,14 +9,24 @@ import (
 	ssov1 "github.com/Braendie/protos/gen/go/sso"
 	grpclog "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
 	grpcretry "github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/retry"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
 )
 
+// Observer receives metrics of SSO calls.
+type Observer interface {
+	ObserveSSOCall(method string, code codes.Code, d time.Duration)
+	ObserveSSORetry()
+}
+
 type Client struct {
-	api ssov1.AuthClient
-	log *slog.Logger
+	api  ssov1.AuthClient
+	conn *grpc.ClientConn
+	log  *slog.Logger
 }
 
 func New(
@@ -24,6 +34,7 @@ func New(
 	addr string,
 	timeout time.Duration,
 	retriesCount int,
+	observer Observer,
 ) (*Client, error) {
 	const op = "grpc.New"
 
@@ -31,14 +42,21 @@ func New(
 		grpcretry.WithCodes(codes.NotFound, codes.Aborted, codes.DeadlineExceeded),
 		grpcretry.WithMax(uint(retriesCount)),
 		grpcretry.WithPerRetryTimeout(timeout),
+		grpcretry.WithOnRetryCallback(func(context.Context, uint, error) {
+			observer.ObserveSSORetry()
+		}),
 	}
 
 	logOpts := []grpclog.Option{
 		grpclog.WithLogOnEvents(grpclog.PayloadReceived, grpclog.PayloadSent),
 	}
 
+	// The stats handler creates client spans and propagates the trace context
+	// into the outgoing metadata.
 	cc, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(otelgrpc.NewClientHandler()),
 		grpc.WithChainUnaryInterceptor(
+			metricsInterceptor(observer),
 			grpclog.UnaryClientInterceptor(InterceptorLogger(log), logOpts...),
 			grpcretry.UnaryClientInterceptor(retryOpts...),
 		))
@@ -49,10 +67,40 @@ func New(
 	log.Info("New grpc client", slog.String("address", addr))
 
 	return &Client{
-		api: ssov1.NewAuthClient(cc),
+		api:  ssov1.NewAuthClient(cc),
+		conn: cc,
+		log:  log,
 	}, nil
 }
 
+// Check reports whether the connection to SSO is usable. An idle connection
+// is asked to connect, and the check waits until it is ready, fails or ctx is done.
+func (c *Client) Check(ctx context.Context) error {
+	const op = "grpc.Check"
+
+	c.conn.Connect()
+
+	for {
+		state := c.conn.GetState()
+
+		switch state {
+		case connectivity.Ready:
+			return nil
+		case connectivity.TransientFailure, connectivity.Shutdown:
+			return fmt.Errorf("%s: connection is %s", op, state)
+		}
+
+		if !c.conn.WaitForStateChange(ctx, state) {
+			return fmt.Errorf("%s: connection is %s: %w", op, state, ctx.Err())
+		}
+	}
+}
+
+// Close closes the underlying connection.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
 func (c *Client) IsAdmin(ctx context.Context, userID int64) (bool, error) {
 	const op = "grpc.IsAdmin"
 
@@ -96,6 +144,24 @@ func (c *Client) IsAdmin(ctx context.Context, userID int64) (bool, error) {
 // 	return resp.Token, nil
 // }
 
+// metricsInterceptor measures every call including its retries.
+func metricsInterceptor(observer Observer) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply any,
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		observer.ObserveSSOCall(method, status.Code(err), time.Since(start))
+
+		return err
+	}
+}
+
 // InterceptorLogger adapts slog logger to interceptor logger.
 // This code is simple enough to be copied and not imported.
 func InterceptorLogger(l *slog.Logger) grpclog.Logger {
@@ -3,31 +3,221 @@ package config
 import (
 	"flag"
 	"log"
+	"net/http"
 	"os"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
+const (
+	StorageDriverSQLite   = "sqlite"
+	StorageDriverPostgres = "postgres"
+)
+
+const (
+	DedupOff    = "off"
+	DedupOwner  = "owner"
+	DedupGlobal = "global"
+)
+
 type Config struct {
-	Env         string `yaml:"env" env-default:"local"`
-	StoragePath string `yaml:"storage_path" env-required:"true"`
+	Env           string    `yaml:"env" env-default:"local"`
+	StorageDriver string    `yaml:"storage_driver" env-default:"sqlite"` // sqlite, postgres
+	StoragePath   string    `yaml:"storage_path"`
+	Postgres      Postgres  `yaml:"postgres"`
+	AutoMigrate   bool      `yaml:"auto_migrate" env:"AUTO_MIGRATE" env-default:"true"`
+	Janitor       Janitor   `yaml:"janitor"`
+	Clicks        Clicks    `yaml:"clicks"`
+	Cache         Cache     `yaml:"cache"`
+	Metrics       Metrics   `yaml:"metrics"`
+	Tracing       Tracing   `yaml:"tracing"`
+	RateLimit     RateLimit `yaml:"rate_limit"`
+	Alias         Alias     `yaml:"alias"`
+	// Dedup makes POST /url and POST /url/batch return the existing generated
+	// alias of a URL instead of creating another one: off, owner (among the caller's links)
+	// or global (among all links).
+	Dedup       string      `yaml:"dedup" env:"DEDUP" env-default:"off"`
+	Idempotency Idempotency `yaml:"idempotency"`
+	Redirect    Redirect    `yaml:"redirect"`
+	Protection  Protection  `yaml:"protection"`
+	QR          QR          `yaml:"qr"`
+	Domains     Domains     `yaml:"domains"`
 	HTTPServer  `yaml:"http_server"`
 	Clients     ClientConfig `yaml:"clients"`
 	AppSecret   string       `yaml:"app_secret" env-required:"true" env:"APP_SECRET"`
 }
 
+type Postgres struct {
+	DSN string `yaml:"dsn" env:"POSTGRES_DSN"`
+}
+
+type Janitor struct {
+	// Interval between purges of expired links. Zero disables the janitor.
+	Interval time.Duration `yaml:"interval" env-default:"1h"`
+}
+
+type Clicks struct {
+	BufferSize    int           `yaml:"buffer_size" env-default:"10000"`
+	BatchSize     int           `yaml:"batch_size" env-default:"500"`
+	FlushInterval time.Duration `yaml:"flush_interval" env-default:"1s"`
+}
+
+type Cache struct {
+	// Size is the maximum number of cached aliases. Zero disables the cache.
+	Size        int           `yaml:"size" env-default:"10000"`
+	TTL         time.Duration `yaml:"ttl" env-default:"5m"`
+	NegativeTTL time.Duration `yaml:"negative_ttl" env-default:"10s"`
+}
+
+type Metrics struct {
+	// MaxAliases caps the number of aliases with their own redirects series.
+	MaxAliases int `yaml:"max_aliases" env-default:"1000"`
+}
+
+type Tracing struct {
+	// Exporter is one of none, otlp, stdout or file.
+	Exporter string `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"`
+	// Endpoint is the host:port of the OTLP gRPC collector.
+	Endpoint    string  `yaml:"endpoint" env:"TRACING_ENDPOINT"`
+	Insecure    bool    `yaml:"insecure"`
+	FilePath    string  `yaml:"file_path" env-default:"traces.jsonl"`
+	SampleRatio float64 `yaml:"sample_ratio" env-default:"1"`
+	ServiceName string  `yaml:"service_name" env-default:"url-shortener"`
+}
+
+type RateLimit struct {
+	Enabled bool `yaml:"enabled" env-default:"true"`
+	// Redirect limits GET /{alias} per client address.
+	Redirect RateLimitRule `yaml:"redirect"`
+	// Management limits every /url route per user.
+	Management RateLimitRule `yaml:"management"`
+	// Routes overrides the limit of single routes by name: redirect, list,
+	// save, batch, export, import, info, update, delete, history, stats, qr,
+	// domains.
+	Routes map[string]RateLimitRule `yaml:"routes"`
+}
+
+// RateLimitRule is a token bucket refilled at RPS tokens per second with
+// room for Burst tokens. Zero RPS disables the limit.
+type RateLimitRule struct {
+	RPS   float64 `yaml:"rps"`
+	Burst int     `yaml:"burst"`
+}
+
+// Alias configures the aliases generated for links saved without one, whose
+// base length is HTTPServer.AliasLength, and the rules for custom aliases.
+type Alias struct {
+	// Strategy is one of random, sequential, hashids or words.
+	Strategy string `yaml:"strategy" env:"ALIAS_STRATEGY" env-default:"random"`
+	// Alphabet of random, sequential and hashids aliases. Empty means base62.
+	// Only ASCII letters, digits, '-' and '_' are allowed, as in custom aliases.
+	Alphabet string `yaml:"alphabet"`
+	// Salt shuffles the alphabet of hashids aliases.
+	Salt string `yaml:"salt" env:"ALIAS_SALT"`
+	// Words is the number of words in word aliases, joined by Separator,
+	// which is limited to the same characters as Alphabet.
+	Words     int    `yaml:"words" env-default:"3"`
+	Separator string `yaml:"separator" env-default:"-"`
+	// MaxAttempts bounds how many generated aliases are tried when they are taken.
+	MaxAttempts int `yaml:"max_attempts" env-default:"5"`
+	// EscalateAfter is the number of collisions after which generated aliases
+	// grow by one character or word. Zero keeps the length fixed.
+	EscalateAfter int `yaml:"escalate_after" env-default:"2"`
+	// MinLength and MaxLength bound the aliases users choose.
+	MinLength int `yaml:"min_length" env-default:"3"`
+	MaxLength int `yaml:"max_length" env-default:"64"`
+	// Reserved aliases cannot be taken, in addition to every path segment of
+	// the service's own routes.
+	Reserved []string `yaml:"reserved"`
+	// CaseInsensitive stores and resolves aliases in lower case. Aliases saved
+	// with upper-case letters before it was enabled can no longer be resolved.
+	CaseInsensitive bool `yaml:"case_insensitive"`
+}
+
+type Redirect struct {
+	// Code is the status of redirects through links saved without one:
+	// 301, 302, 307 or 308.
+	Code int `yaml:"code" env:"REDIRECT_CODE" env-default:"302"`
+	// MaxAge is how long clients may cache permanent (301 and 308) redirects.
+	MaxAge time.Duration `yaml:"max_age" env-default:"24h"`
+}
+
+type Protection struct {
+	// UnlockTTL is how long a client may follow a password-protected link
+	// after entering its password.
+	UnlockTTL time.Duration `yaml:"unlock_ttl" env-default:"1h"`
+	// Attempts is the number of password attempts allowed per link in a burst.
+	Attempts int `yaml:"attempts" env-default:"5"`
+	// AttemptsRefill is how often another attempt is allowed.
+	AttemptsRefill time.Duration `yaml:"attempts_refill" env-default:"1m"`
+}
+
+type QR struct {
+	// Size is the width of QR code images in pixels when the request does
+	// not choose one, MaxSize the largest width a request may choose.
+	Size    int `yaml:"size" env-default:"256"`
+	MaxSize int `yaml:"max_size" env-default:"2048"`
+}
+
+type Domains struct {
+	// Enabled serves the links of registered custom domains on their hosts.
+	// It requires BaseURL, whose host serves the default domain; redirects
+	// for any other host are refused.
+	Enabled bool `yaml:"enabled" env:"DOMAINS_ENABLED"`
+	// RefreshInterval is how often registered domains are reloaded, so that
+	// domains changed on another instance are picked up.
+	RefreshInterval time.Duration `yaml:"refresh_interval" env-default:"30s"`
+}
+
+type Idempotency struct {
+	// TTL is how long responses to requests with an Idempotency-Key header are
+	// kept for retries. Zero disables the header.
+	TTL time.Duration `yaml:"ttl" env-default:"24h"`
+	// MaxRequestSize caps the bodies, in bytes, of requests with the header.
+	// Larger requests are rejected.
+	MaxRequestSize int64 `yaml:"max_request_size" env-default:"4194304"`
+	// MaxResponseSize caps the responses, in bytes, kept for retries. Larger
+	// responses are not stored, so their requests are not deduplicated.
+	MaxResponseSize int64 `yaml:"max_response_size" env-default:"1048576"`
+}
+
 type HTTPServer struct {
-	Address     string        `yaml:"address" env-default:"localhost:8080"`
-	Timeout     time.Duration `yaml:"timeout" env-default:"4s"`
-	IdleTimeout time.Duration `yaml:"idle_timeout" env-default:"60s"`
-	AliasLength int           `yaml:"alias_length"`
-	User        string        `yaml:"user" env-required:"true"`
-	Password    string        `yaml:"password" env-required:"true" env:"HTTP_SERVER_PASSWORD"`
+	Address string `yaml:"address" env-default:"localhost:8080"`
+	// BaseURL is the public origin of short links, such as https://sho.rt,
+	// when it differs from what clients send in the Host header. Custom
+	// domains require it.
+	BaseURL string `yaml:"base_url" env:"HTTP_SERVER_BASE_URL"`
+	// TrustedProxies are the addresses or CIDR ranges whose X-Forwarded-Host
+	// and X-Forwarded-Proto headers are honored when BaseURL is empty, and
+	// whose X-Forwarded-For header gives the client address rate limits apply to.
+	TrustedProxies []string `yaml:"trusted_proxies"`
+	// AdminAddress serves /metrics. Empty disables the admin listener.
+	AdminAddress string        `yaml:"admin_address" env-default:"localhost:8081"`
+	Timeout      time.Duration `yaml:"timeout" env-default:"4s"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout" env-default:"60s"`
+	// ShutdownTimeout bounds how long in-flight requests are drained on shutdown.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env-default:"10s"`
+	// ShutdownDelay is how long /readyz reports failure before the server stops
+	// accepting requests. It should cover the orchestrator's readiness period.
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" env-default:"0s"`
+	// ReadinessTimeout bounds the dependency checks of /readyz.
+	ReadinessTimeout time.Duration `yaml:"readiness_timeout" env-default:"2s"`
+	AliasLength      int           `yaml:"alias_length"`
+	// BatchMaxItems caps the number of links in one POST /url/batch request.
+	BatchMaxItems int `yaml:"batch_max_items" env-default:"10000"`
+	// BatchMaxPasswords caps the password-protected links in one batch, each
+	// of which costs a bcrypt hash.
+	BatchMaxPasswords int `yaml:"batch_max_passwords" env-default:"10"`
+	// ImportMaxSize caps the body of POST /url/import in bytes. The whole
+	// import runs in one transaction.
+	ImportMaxSize int64  `yaml:"import_max_size" env-default:"67108864"`
+	User          string `yaml:"user" env-required:"true"`
+	Password      string `yaml:"password" env-required:"true" env:"HTTP_SERVER_PASSWORD"`
 }
 
 type Client struct {
-	Address      string        `yaml: "address"`
+	Address      string        `yaml:"address"`
 	Timeout      time.Duration `yaml:"timeout"`
 	RetriesCount int           `yaml:"retries_count"`
 	Insecure     bool          `yaml:"insecure"`
@@ -55,5 +245,42 @@ func MustLoad() *Config {
 		log.Fatalf("cannot read config: %s", err)
 	}
 
+	switch cfg.StorageDriver {
+	case StorageDriverSQLite:
+		if cfg.StoragePath == "" {
+			log.Fatal("storage_path is required for the sqlite storage driver")
+		}
+	case StorageDriverPostgres:
+		if cfg.Postgres.DSN == "" {
+			log.Fatal("postgres.dsn is required for the postgres storage driver")
+		}
+	default:
+		log.Fatalf("unknown storage driver: %s", cfg.StorageDriver)
+	}
+
+	if cfg.Domains.Enabled && cfg.BaseURL == "" {
+		log.Fatal("http_server.base_url is required for custom domains")
+	}
+
+	switch cfg.Dedup {
+	case DedupOff, DedupOwner, DedupGlobal:
+	default:
+		log.Fatalf("unknown dedup mode: %s", cfg.Dedup)
+	}
+
+	switch cfg.Redirect.Code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		log.Fatalf("unsupported redirect code: %d", cfg.Redirect.Code)
+	}
+
+	if cfg.QR.Size <= 0 || cfg.QR.Size > cfg.QR.MaxSize {
+		log.Fatalf("qr.size must be between 1 and qr.max_size (%d)", cfg.QR.MaxSize)
+	}
+
+	if cfg.Idempotency.TTL > 0 && (cfg.Idempotency.MaxRequestSize <= 0 || cfg.Idempotency.MaxResponseSize <= 0) {
+		log.Fatal("idempotency.max_request_size and idempotency.max_response_size must be positive")
+	}
+
 	return &cfg
 }
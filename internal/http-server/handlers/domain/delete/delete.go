@@ -0,0 +1,91 @@
+package delete
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=DomainDeleter
+type DomainDeleter interface {
+	DeleteDomain(ctx context.Context, name string) error
+}
+
+// New unregisters a domain. Only admins may manage domains, and only domains
+// without links can be deleted.
+func New(log *slog.Logger, domainDeleter DomainDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.domain.delete.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			log.Info("unauthorized request: no user in context")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("unauthorized"))
+
+			return
+		}
+
+		if !user.IsAdmin {
+			log.Info("domain delete forbidden", slog.Int64("uid", user.ID))
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.Error("forbidden"))
+
+			return
+		}
+
+		name := domainParam(r)
+
+		err := domainDeleter.DeleteDomain(r.Context(), name)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrDomainNotFound):
+				log.Info("domain not found", slog.String("domain", name))
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("not found"))
+			case errors.Is(err, storage.ErrDomainInUse):
+				log.Info("domain in use", slog.String("domain", name))
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error("domain has links"))
+			default:
+				log.Error("failed to delete domain", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("internal error"))
+			}
+
+			return
+		}
+
+		log.Info("domain deleted", slog.String("domain", name))
+		render.NoContent(w, r)
+	}
+}
+
+// domainParam returns the lower-cased domain URL parameter. The URLFormat
+// middleware takes the last label of the domain for a format extension, so it
+// is joined back.
+func domainParam(r *http.Request) string {
+	name := chi.URLParam(r, "domain")
+	if format, _ := r.Context().Value(middleware.URLFormatCtxKey).(string); format != "" {
+		name += "." + format
+	}
+
+	return strings.ToLower(name)
+}
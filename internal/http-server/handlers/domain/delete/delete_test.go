@@ -0,0 +1,83 @@
+package delete_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/domain/delete"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/domain/delete/mocks"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDeleteHandler(t *testing.T) {
+	admin := auth.User{ID: 1, IsAdmin: true}
+
+	testCases := []struct {
+		name      string
+		user      auth.User
+		mockError error
+		code      int
+	}{
+		{
+			name: "deleted",
+			user: admin,
+			code: http.StatusNoContent,
+		},
+		{
+			name: "not an admin",
+			user: auth.User{ID: 2},
+			code: http.StatusForbidden,
+		},
+		{
+			name:      "not found",
+			user:      admin,
+			mockError: storage.ErrDomainNotFound,
+			code:      http.StatusNotFound,
+		},
+		{
+			name:      "in use",
+			user:      admin,
+			mockError: storage.ErrDomainInUse,
+			code:      http.StatusConflict,
+		},
+		{
+			name:      "storage error",
+			user:      admin,
+			mockError: errors.New("some error"),
+			code:      http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			domainDeleterMock := mocks.NewDomainDeleter(t)
+			if tc.user.IsAdmin {
+				domainDeleterMock.On("DeleteDomain", mock.Anything, "go.example.org").Return(tc.mockError).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Use(middleware.URLFormat)
+			r.Delete("/domains/{domain}", delete.New(slogdiscard.NewDiscardLogger(), domainDeleterMock))
+
+			req := httptest.NewRequest(http.MethodDelete, "/domains/go.example.org", nil)
+			req = req.WithContext(auth.WithUser(req.Context(), tc.user))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.code, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,46 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// DomainDeleter is an autogenerated mock type for the DomainDeleter type
+type DomainDeleter struct {
+	mock.Mock
+}
+
+// DeleteDomain provides a mock function with given fields: ctx, name
+func (_m *DomainDeleter) DeleteDomain(ctx context.Context, name string) error {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteDomain")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewDomainDeleter creates a new instance of DomainDeleter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewDomainDeleter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *DomainDeleter {
+	mock := &DomainDeleter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
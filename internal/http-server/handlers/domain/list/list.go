@@ -0,0 +1,89 @@
+package list
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Item struct {
+	Domain       string    `json:"domain"`
+	AliasLength  int       `json:"alias_length,omitempty"`
+	RedirectCode int       `json:"redirect_code,omitempty"`
+	FallbackURL  string    `json:"fallback_url,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type Response struct {
+	resp.Response
+	Items []Item `json:"items"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=DomainLister
+type DomainLister interface {
+	ListDomains(ctx context.Context) ([]storage.Domain, error)
+}
+
+// New lists the registered domains. Only admins may manage domains.
+func New(log *slog.Logger, domainLister DomainLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.domain.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			log.Info("unauthorized request: no user in context")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("unauthorized"))
+
+			return
+		}
+
+		if !user.IsAdmin {
+			log.Info("domain list forbidden", slog.Int64("uid", user.ID))
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.Error("forbidden"))
+
+			return
+		}
+
+		domains, err := domainLister.ListDomains(r.Context())
+		if err != nil {
+			log.Error("failed to list domains", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		items := make([]Item, 0, len(domains))
+		for _, d := range domains {
+			items = append(items, Item{
+				Domain:       d.Name,
+				AliasLength:  d.AliasLength,
+				RedirectCode: d.RedirectCode,
+				FallbackURL:  d.FallbackURL,
+				CreatedAt:    d.CreatedAt,
+			})
+		}
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Items:    items,
+		})
+	}
+}
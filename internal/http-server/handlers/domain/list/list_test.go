@@ -0,0 +1,82 @@
+package list_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/domain/list"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/domain/list/mocks"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListHandler(t *testing.T) {
+	admin := auth.User{ID: 1, IsAdmin: true}
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name      string
+		user      auth.User
+		mockError error
+		respError string
+		code      int
+	}{
+		{
+			name: "admin",
+			user: admin,
+			code: http.StatusOK,
+		},
+		{
+			name:      "not an admin",
+			user:      auth.User{ID: 2},
+			respError: "forbidden",
+			code:      http.StatusForbidden,
+		},
+		{
+			name:      "storage error",
+			user:      admin,
+			mockError: errors.New("some error"),
+			respError: "internal error",
+			code:      http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			domainListerMock := mocks.NewDomainLister(t)
+			if tc.user.IsAdmin {
+				domainListerMock.On("ListDomains", mock.Anything).
+					Return([]storage.Domain{{Name: "go.example.org", AliasLength: 4, CreatedAt: createdAt}}, tc.mockError).
+					Once()
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/domains", nil)
+			req = req.WithContext(auth.WithUser(req.Context(), tc.user))
+
+			rr := httptest.NewRecorder()
+			list.New(slogdiscard.NewDiscardLogger(), domainListerMock).ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.code, rr.Code)
+
+			var resp list.Response
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, tc.respError, resp.Error)
+
+			if tc.respError == "" {
+				assert.Equal(t, []list.Item{{Domain: "go.example.org", AliasLength: 4, CreatedAt: createdAt}}, resp.Items)
+			}
+		})
+	}
+}
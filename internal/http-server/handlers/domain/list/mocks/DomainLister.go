@@ -0,0 +1,60 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "github.com/Braendie/url-shortener/internal/storage"
+)
+
+// DomainLister is an autogenerated mock type for the DomainLister type
+type DomainLister struct {
+	mock.Mock
+}
+
+// ListDomains provides a mock function with given fields: ctx
+func (_m *DomainLister) ListDomains(ctx context.Context) ([]storage.Domain, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListDomains")
+	}
+
+	var r0 []storage.Domain
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]storage.Domain, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []storage.Domain); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Domain)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewDomainLister creates a new instance of DomainLister. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewDomainLister(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *DomainLister {
+	mock := &DomainLister{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,48 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "github.com/Braendie/url-shortener/internal/storage"
+)
+
+// DomainSaver is an autogenerated mock type for the DomainSaver type
+type DomainSaver struct {
+	mock.Mock
+}
+
+// SaveDomain provides a mock function with given fields: ctx, d
+func (_m *DomainSaver) SaveDomain(ctx context.Context, d storage.Domain) error {
+	ret := _m.Called(ctx, d)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveDomain")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.Domain) error); ok {
+		r0 = rf(ctx, d)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewDomainSaver creates a new instance of DomainSaver. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewDomainSaver(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *DomainSaver {
+	mock := &DomainSaver{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,137 @@
+package save
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+// Request holds the defaults of the domain, zero values fall back to the
+// server ones.
+type Request struct {
+	AliasLength  int    `json:"alias_length,omitempty" validate:"min=0,max=64"`
+	RedirectCode int    `json:"redirect_code,omitempty" validate:"omitempty,oneof=301 302 307 308"`
+	FallbackURL  string `json:"fallback_url,omitempty" validate:"omitempty,url"`
+}
+
+type Response struct {
+	resp.Response
+	Domain string `json:"domain"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=DomainSaver
+type DomainSaver interface {
+	SaveDomain(ctx context.Context, d storage.Domain) error
+}
+
+// New registers the domain of the URL or replaces its defaults. Only admins
+// may manage domains. defaultHost, the host of the configured base URL,
+// serves the default domain and cannot be registered.
+func New(log *slog.Logger, domainSaver DomainSaver, defaultHost string) http.HandlerFunc {
+	validate := validator.New()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.domain.save.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			log.Info("unauthorized request: no user in context")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("unauthorized"))
+
+			return
+		}
+
+		if !user.IsAdmin {
+			log.Info("domain save forbidden", slog.Int64("uid", user.ID))
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.Error("forbidden"))
+
+			return
+		}
+
+		name := domainParam(r)
+		if err := validate.Var(name, "required,fqdn"); err != nil || name == defaultHost {
+			log.Info("invalid domain", slog.String("domain", name))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid domain"))
+
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err := validate.Struct(req); err != nil {
+			var validateErr validator.ValidationErrors
+			errors.As(err, &validateErr)
+
+			log.Info("invalid request", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		err = domainSaver.SaveDomain(r.Context(), storage.Domain{
+			Name:         name,
+			AliasLength:  req.AliasLength,
+			RedirectCode: req.RedirectCode,
+			FallbackURL:  req.FallbackURL,
+		})
+		if err != nil {
+			log.Error("failed to save domain", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		log.Info("domain saved", slog.String("domain", name))
+
+		render.JSON(w, r, Response{
+			Response: resp.OK(),
+			Domain:   name,
+		})
+	}
+}
+
+// domainParam returns the lower-cased domain URL parameter. The URLFormat
+// middleware takes the last label of the domain for a format extension, so it
+// is joined back.
+func domainParam(r *http.Request) string {
+	name := chi.URLParam(r, "domain")
+	if format, _ := r.Context().Value(middleware.URLFormatCtxKey).(string); format != "" {
+		name += "." + format
+	}
+
+	return strings.ToLower(name)
+}
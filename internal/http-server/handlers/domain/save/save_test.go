@@ -0,0 +1,141 @@
+package save_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/domain/save"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/domain/save/mocks"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveHandler(t *testing.T) {
+	admin := auth.User{ID: 1, IsAdmin: true}
+
+	testCases := []struct {
+		name      string
+		domain    string
+		body      string
+		user      auth.User
+		want      *storage.Domain
+		mockError error
+		respError string
+		code      int
+	}{
+		{
+			name:   "registered",
+			domain: "Go.Example.org",
+			body:   `{"alias_length": 4, "redirect_code": 301, "fallback_url": "https://example.org"}`,
+			user:   admin,
+			want: &storage.Domain{
+				Name:         "go.example.org",
+				AliasLength:  4,
+				RedirectCode: http.StatusMovedPermanently,
+				FallbackURL:  "https://example.org",
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:   "defaults",
+			domain: "go.example.org",
+			body:   `{}`,
+			user:   admin,
+			want:   &storage.Domain{Name: "go.example.org"},
+			code:   http.StatusOK,
+		},
+		{
+			name:      "not an admin",
+			domain:    "go.example.org",
+			body:      `{}`,
+			user:      auth.User{ID: 2},
+			respError: "forbidden",
+			code:      http.StatusForbidden,
+		},
+		{
+			name:      "invalid domain",
+			domain:    "localhost",
+			body:      `{}`,
+			user:      admin,
+			respError: "invalid domain",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "default host",
+			domain:    "sho.rt",
+			body:      `{}`,
+			user:      admin,
+			respError: "invalid domain",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "invalid redirect code",
+			domain:    "go.example.org",
+			body:      `{"redirect_code": 200}`,
+			user:      admin,
+			respError: "field RedirectCode must be one of 301 302 307 308",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "invalid fallback url",
+			domain:    "go.example.org",
+			body:      `{"fallback_url": "example"}`,
+			user:      admin,
+			respError: "field FallbackURL is not a valid URL",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "storage error",
+			domain:    "go.example.org",
+			body:      `{}`,
+			user:      admin,
+			want:      &storage.Domain{Name: "go.example.org"},
+			mockError: errors.New("some error"),
+			respError: "internal error",
+			code:      http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			domainSaverMock := mocks.NewDomainSaver(t)
+			if tc.want != nil {
+				domainSaverMock.On("SaveDomain", mock.Anything, *tc.want).Return(tc.mockError).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Use(middleware.URLFormat)
+			r.Put("/domains/{domain}", save.New(slogdiscard.NewDiscardLogger(), domainSaverMock, "sho.rt"))
+
+			req := httptest.NewRequest(http.MethodPut, "/domains/"+tc.domain, strings.NewReader(tc.body))
+			req = req.WithContext(auth.WithUser(req.Context(), tc.user))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.code, rr.Code)
+
+			var resp save.Response
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, tc.respError, resp.Error)
+
+			if tc.respError == "" {
+				assert.Equal(t, tc.want.Name, resp.Domain)
+			}
+		})
+	}
+}
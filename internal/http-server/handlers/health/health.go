@@ -0,0 +1,117 @@
+package health
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/go-chi/render"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=Checker
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to the Checker interface.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error {
+	return f(ctx)
+}
+
+type CheckResult struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+type namedChecker struct {
+	name    string
+	checker Checker
+}
+
+// Probe serves liveness and readiness endpoints.
+type Probe struct {
+	log          *slog.Logger
+	timeout      time.Duration
+	checkers     []namedChecker
+	shuttingDown atomic.Bool
+}
+
+// New creates a probe. timeout bounds every readiness check.
+func New(log *slog.Logger, timeout time.Duration) *Probe {
+	return &Probe{
+		log:     log,
+		timeout: timeout,
+	}
+}
+
+// Add registers a dependency checked by the readiness endpoint.
+func (p *Probe) Add(name string, checker Checker) {
+	p.checkers = append(p.checkers, namedChecker{name: name, checker: checker})
+}
+
+// SetShuttingDown makes the readiness endpoint fail, so that the instance
+// is taken out of rotation before it stops serving.
+func (p *Probe) SetShuttingDown() {
+	p.shuttingDown.Store(true)
+}
+
+// Live reports that the process is up.
+func (p *Probe) Live() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, resp.OK())
+	}
+}
+
+// Ready runs every registered check and reports 503 if any of them fails.
+func (p *Probe) Ready() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.health.Ready"
+
+		log := p.log.With(slog.String("op", op))
+
+		if p.shuttingDown.Load() {
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, Response{Response: resp.Error("shutting down")})
+
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), p.timeout)
+		defer cancel()
+
+		res := Response{
+			Response: resp.OK(),
+			Checks:   make(map[string]CheckResult, len(p.checkers)),
+		}
+
+		for _, c := range p.checkers {
+			if err := c.checker.Check(ctx); err != nil {
+				log.Warn("readiness check failed", slog.String("check", c.name), sl.Err(err))
+
+				res.Response = resp.Error("not ready")
+				res.Checks[c.name] = CheckResult{Status: resp.StatusError, Error: err.Error()}
+
+				continue
+			}
+
+			res.Checks[c.name] = CheckResult{Status: resp.StatusOK}
+		}
+
+		if res.Status != resp.StatusOK {
+			render.Status(r, http.StatusServiceUnavailable)
+		}
+
+		render.JSON(w, r, res)
+	}
+}
@@ -0,0 +1,109 @@
+package health_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/health"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/health/mocks"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProbe_Live(t *testing.T) {
+	p := health.New(slogdiscard.NewDiscardLogger(), time.Second)
+
+	rr := httptest.NewRecorder()
+	p.Live().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestProbe_Ready(t *testing.T) {
+	testCases := []struct {
+		name         string
+		storageError error
+		ssoError     error
+		shuttingDown bool
+		code         int
+		respError    string
+		checks       map[string]health.CheckResult
+	}{
+		{
+			name: "ready",
+			code: http.StatusOK,
+			checks: map[string]health.CheckResult{
+				"storage": {Status: resp.StatusOK},
+				"sso":     {Status: resp.StatusOK},
+			},
+		},
+		{
+			name:      "sso unavailable",
+			ssoError:  errors.New("connection is TRANSIENT_FAILURE"),
+			code:      http.StatusServiceUnavailable,
+			respError: "not ready",
+			checks: map[string]health.CheckResult{
+				"storage": {Status: resp.StatusOK},
+				"sso":     {Status: resp.StatusError, Error: "connection is TRANSIENT_FAILURE"},
+			},
+		},
+		{
+			name:         "storage unavailable",
+			storageError: errors.New("database is closed"),
+			code:         http.StatusServiceUnavailable,
+			respError:    "not ready",
+			checks: map[string]health.CheckResult{
+				"storage": {Status: resp.StatusError, Error: "database is closed"},
+				"sso":     {Status: resp.StatusOK},
+			},
+		},
+		{
+			name:         "shutting down",
+			shuttingDown: true,
+			code:         http.StatusServiceUnavailable,
+			respError:    "shutting down",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			storageMock := mocks.NewChecker(t)
+			ssoMock := mocks.NewChecker(t)
+
+			if !tc.shuttingDown {
+				storageMock.On("Check", mock.Anything).Return(tc.storageError).Once()
+				ssoMock.On("Check", mock.Anything).Return(tc.ssoError).Once()
+			}
+
+			p := health.New(slogdiscard.NewDiscardLogger(), time.Second)
+			p.Add("storage", storageMock)
+			p.Add("sso", ssoMock)
+
+			if tc.shuttingDown {
+				p.SetShuttingDown()
+			}
+
+			rr := httptest.NewRecorder()
+			p.Ready().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			require.Equal(t, tc.code, rr.Code)
+
+			var body health.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+			assert.Equal(t, tc.respError, body.Error)
+			assert.Equal(t, tc.checks, body.Checks)
+		})
+	}
+}
@@ -0,0 +1,385 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/save"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/domain"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/expiry"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/password"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+const contentTypeNDJSON = "application/x-ndjson"
+
+var errTooManyItems = errors.New("too many items")
+
+type Result struct {
+	Index     int        `json:"index"`
+	Status    string     `json:"status"`
+	Error     string     `json:"error,omitempty"`
+	Alias     string     `json:"alias,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Created int      `json:"created"`
+	Failed  int      `json:"failed"`
+	Results []Result `json:"results"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=URLBatchSaver
+type URLBatchSaver interface {
+	SaveURLs(ctx context.Context, urls []storage.URL) ([]storage.SaveResult, error)
+	FindGeneratedURL(ctx context.Context, domain, url string, ownerID int64) (storage.URL, error)
+}
+
+// AliasGenerator produces aliases for items saved without one, see alias.Allocator.
+type AliasGenerator interface {
+	Alias(ctx context.Context, length int, attempt int) (string, error)
+	MaxAttempts() int
+}
+
+// New creates links in bulk on the domain of the request. The body is either a JSON array of save.Request
+// or, with the application/x-ndjson content type, one request per line.
+// Every item is validated like POST /url and gets its own result; invalid
+// items and taken aliases do not fail the rest of the batch. Items whose
+// generated alias is taken are saved again with a new one. A batch with more
+// than maxPasswords password-protected items is rejected before any password
+// is hashed. Items are deduplicated according to dedup like POST /url does,
+// and items of the batch that would be deduplicated against each other share
+// the alias of the first one.
+func New(
+	log *slog.Logger,
+	batchSaver URLBatchSaver,
+	aliases AliasGenerator,
+	rules save.AliasRules,
+	maxItems int,
+	maxPasswords int,
+	dedup string,
+) http.HandlerFunc {
+	validate := rules.Validator()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.batch.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			log.Info("unauthorized request: no user in context")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("unauthorized"))
+
+			return
+		}
+
+		reqs, err := decode(r, maxItems)
+		if err != nil {
+			log.Info("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			if errors.Is(err, errTooManyItems) {
+				render.JSON(w, r, resp.Error(fmt.Sprintf("batch must not contain more than %d items", maxItems)))
+			} else {
+				render.JSON(w, r, resp.Error("failed to decode request"))
+			}
+
+			return
+		}
+
+		if len(reqs) == 0 {
+			log.Info("empty batch")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("batch is empty"))
+
+			return
+		}
+
+		passwords := 0
+		for _, req := range reqs {
+			if req.Password != "" {
+				passwords++
+			}
+		}
+		if passwords > maxPasswords {
+			log.Info("too many password-protected items", slog.Int("passwords", passwords))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(
+				fmt.Sprintf("batch must not contain more than %d password-protected items", maxPasswords),
+			))
+
+			return
+		}
+
+		now := time.Now()
+		dom := domain.FromContext(r.Context())
+
+		ownerID := user.ID
+		if dedup == config.DedupGlobal {
+			ownerID = 0
+		}
+
+		results := make([]Result, len(reqs))
+		urls := make([]storage.URL, 0, len(reqs))
+		// positions maps urls back to their results.
+		positions := make([]int, 0, len(reqs))
+		// firstOf maps the results of items deduplicated within the batch to
+		// the result of the first item with the same URL.
+		firstOf := make(map[int]int)
+		firstByURL := make(map[string]int)
+
+		for i, req := range reqs {
+			results[i].Index = i
+			req.Alias = rules.Normalize(req.Alias)
+
+			u, err := prepare(validate, req, user.ID, now)
+			if err != nil {
+				results[i].Status = resp.StatusError
+				results[i].Error = err.Error()
+
+				continue
+			}
+			u.Domain = dom.Name
+
+			if dedup != config.DedupOff && save.Reusable(u) {
+				if first, ok := firstByURL[u.URL]; ok {
+					firstOf[i] = first
+
+					continue
+				}
+
+				existing, err := batchSaver.FindGeneratedURL(r.Context(), u.Domain, u.URL, ownerID)
+				switch {
+				case err == nil:
+					results[i].Status = resp.StatusOK
+					results[i].Alias = existing.Alias
+
+					continue
+				case !errors.Is(err, storage.ErrURLNotFound):
+					log.Error("failed to find existing url", sl.Err(err))
+					render.Status(r, http.StatusInternalServerError)
+					render.JSON(w, r, resp.Error("failed to add urls"))
+
+					return
+				}
+
+				firstByURL[u.URL] = i
+			}
+
+			urls = append(urls, u)
+			positions = append(positions, i)
+		}
+
+		if err := saveAll(r.Context(), log, batchSaver, aliases, dom.AliasLength, urls, positions, results); err != nil {
+			log.Error("failed to add urls", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("failed to add urls"))
+
+			return
+		}
+
+		for i, first := range firstOf {
+			results[i] = results[first]
+			results[i].Index = i
+		}
+
+		response := Response{Response: resp.OK(), Results: results}
+		for _, res := range results {
+			if res.Status == resp.StatusOK {
+				response.Created++
+			} else {
+				response.Failed++
+			}
+		}
+
+		log.Info("batch processed", slog.Int("created", response.Created), slog.Int("failed", response.Failed))
+
+		render.JSON(w, r, response)
+	}
+}
+
+// saveAll saves urls in rounds and fills in the results at their positions.
+// Each round retries the items whose generated alias was taken. Generated
+// aliases have the given length, zero meaning the configured one.
+func saveAll(
+	ctx context.Context,
+	log *slog.Logger,
+	batchSaver URLBatchSaver,
+	aliases AliasGenerator,
+	length int,
+	urls []storage.URL,
+	positions []int,
+	results []Result,
+) error {
+	// generated marks the urls whose alias may be replaced on a collision.
+	generated := make([]bool, len(urls))
+	pending := make([]int, len(urls))
+	for j, u := range urls {
+		generated[j] = u.Alias == ""
+		urls[j].Generated = generated[j]
+		pending[j] = j
+	}
+
+	for attempt := 0; len(pending) > 0; attempt++ {
+		round := make([]storage.URL, len(pending))
+		for k, j := range pending {
+			if generated[j] {
+				alias, err := aliases.Alias(ctx, length, attempt)
+				if err != nil {
+					return err
+				}
+				urls[j].Alias = alias
+			}
+			round[k] = urls[j]
+		}
+
+		saved, err := batchSaver.SaveURLs(ctx, round)
+		if err != nil {
+			return err
+		}
+
+		var retry []int
+
+		for k, res := range saved {
+			j := pending[k]
+			result := &results[positions[j]]
+
+			switch {
+			case errors.Is(res.Err, storage.ErrURLExists) && generated[j] && attempt+1 < aliases.MaxAttempts():
+				retry = append(retry, j)
+			case errors.Is(res.Err, storage.ErrURLExists) && generated[j]:
+				result.Status = resp.StatusError
+				result.Error = "failed to generate alias"
+			case errors.Is(res.Err, storage.ErrURLExists):
+				result.Status = resp.StatusError
+				result.Error = "url already exists"
+			case res.Err != nil:
+				log.Error("failed to add url", slog.Int("index", positions[j]), sl.Err(res.Err))
+				result.Status = resp.StatusError
+				result.Error = "failed to add url"
+			default:
+				result.Status = resp.StatusOK
+				result.Alias = urls[j].Alias
+				result.ExpiresAt = urls[j].ExpiresAt
+			}
+		}
+
+		pending = retry
+	}
+
+	return nil
+}
+
+// prepare validates a single item the same way POST /url does. The alias of
+// items without one is left empty for saveAll to generate.
+func prepare(validate *validator.Validate, req save.Request, ownerID int64, now time.Time) (storage.URL, error) {
+	if err := validate.Struct(req); err != nil {
+		var validateErr validator.ValidationErrors
+		if errors.As(err, &validateErr) {
+			return storage.URL{}, errors.New(resp.ValidationError(validateErr).Error)
+		}
+
+		return storage.URL{}, err
+	}
+
+	expiresAt, err := expiry.Resolve(req.TTL, req.ExpiresAt, now)
+	if err != nil {
+		return storage.URL{}, err
+	}
+
+	u := storage.URL{
+		URL:          req.URL,
+		Alias:        req.Alias,
+		OwnerID:      ownerID,
+		ExpiresAt:    expiresAt,
+		RedirectCode: req.RedirectCode,
+		ForwardQuery: req.ForwardQuery,
+		MaxClicks:    req.MaxClicks,
+	}
+
+	if req.Password != "" {
+		if u.PasswordHash, err = password.Hash(req.Password); err != nil {
+			return storage.URL{}, errors.New("failed to hash password")
+		}
+	}
+
+	return u, nil
+}
+
+// decode reads the items one by one, so that an oversized batch is rejected
+// without reading it whole.
+func decode(r *http.Request, maxItems int) ([]save.Request, error) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	dec := json.NewDecoder(r.Body)
+
+	var reqs []save.Request
+
+	if mediaType == contentTypeNDJSON {
+		for {
+			var req save.Request
+
+			err := dec.Decode(&req)
+			if errors.Is(err, io.EOF) {
+				return reqs, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if len(reqs) == maxItems {
+				return nil, errTooManyItems
+			}
+
+			reqs = append(reqs, req)
+		}
+	}
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return nil, errors.New("expected a JSON array")
+	}
+
+	for dec.More() {
+		if len(reqs) == maxItems {
+			return nil, errTooManyItems
+		}
+
+		var req save.Request
+		if err := dec.Decode(&req); err != nil {
+			return nil, err
+		}
+
+		reqs = append(reqs, req)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+
+	return reqs, nil
+}
@@ -0,0 +1,329 @@
+package batch_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/batch"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/batch/mocks"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/lib/alias"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/lib/random"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const mixedBatch = `[
+	{"url": "https://google.com", "alias": "google"},
+	{"url": "not a url", "alias": "invalid"},
+	{"url": "https://ya.ru", "alias": "taken"},
+	{"url": "https://ya.ru", "ttl": "1h", "expires_at": "2999-01-01T00:00:00Z"},
+	{"url": "https://go.dev"},
+	{"url": "https://go.dev", "alias": "url"}
+]`
+
+func newAllocator() *alias.Allocator {
+	return alias.NewAllocator(alias.Random{Alphabet: random.Base62, Length: 6}, nil, 3, 2)
+}
+
+func newRules() *alias.Rules {
+	rules := alias.NewRules(config.Alias{MinLength: 3, MaxLength: 16})
+	rules.Reserve("url")
+
+	return rules
+}
+
+func TestBatchHandler(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		maxItems    int
+		saveAliases int
+		saveResults []storage.SaveResult
+		mockError   error
+		code        int
+		respError   string
+		results     []batch.Result
+	}{
+		{
+			name:        "mixed array",
+			body:        mixedBatch,
+			saveAliases: 3,
+			saveResults: []storage.SaveResult{{ID: 1}, {Err: storage.ErrURLExists}, {ID: 2}},
+			code:        http.StatusOK,
+			results: []batch.Result{
+				{Index: 0, Status: resp.StatusOK, Alias: "google"},
+				{Index: 1, Status: resp.StatusError, Error: "field URL is not a valid URL"},
+				{Index: 2, Status: resp.StatusError, Error: "url already exists"},
+				{Index: 3, Status: resp.StatusError, Error: "field TTL cannot be used together with ExpiresAt"},
+				{Index: 4, Status: resp.StatusOK},
+				{Index: 5, Status: resp.StatusError, Error: "field Alias is reserved"},
+			},
+		},
+		{
+			name:        "ndjson",
+			contentType: "application/x-ndjson",
+			body:        "{\"url\": \"https://google.com\", \"alias\": \"google\"}\n{\"url\": \"\", \"alias\": \"empty\"}\n",
+			saveAliases: 1,
+			saveResults: []storage.SaveResult{{ID: 1}},
+			code:        http.StatusOK,
+			results: []batch.Result{
+				{Index: 0, Status: resp.StatusOK, Alias: "google"},
+				{Index: 1, Status: resp.StatusError, Error: "field URL is a required field"},
+			},
+		},
+		{
+			name:     "all invalid",
+			body:     `[{"url": "not a url"}]`,
+			code:     http.StatusOK,
+			results:  []batch.Result{{Index: 0, Status: resp.StatusError, Error: "field URL is not a valid URL"}},
+			maxItems: 1,
+		},
+		{
+			name:      "too many items",
+			body:      mixedBatch,
+			maxItems:  2,
+			code:      http.StatusBadRequest,
+			respError: "batch must not contain more than 2 items",
+		},
+		{
+			name:        "too many ndjson items",
+			contentType: "application/x-ndjson",
+			body:        "{\"url\": \"https://a.com\"}\n{\"url\": \"https://b.com\"}\n",
+			maxItems:    1,
+			code:        http.StatusBadRequest,
+			respError:   "batch must not contain more than 1 items",
+		},
+		{
+			name:        "password within the limit",
+			body:        `[{"url": "https://a.com", "password": "secret"}, {"url": "https://b.com"}]`,
+			saveAliases: 2,
+			saveResults: []storage.SaveResult{{ID: 1}, {ID: 2}},
+			code:        http.StatusOK,
+			results: []batch.Result{
+				{Index: 0, Status: resp.StatusOK},
+				{Index: 1, Status: resp.StatusOK},
+			},
+		},
+		{
+			name:      "too many passwords",
+			body:      `[{"url": "https://a.com", "password": "secret"}, {"url": "https://b.com", "password": "secret"}]`,
+			code:      http.StatusBadRequest,
+			respError: "batch must not contain more than 1 password-protected items",
+		},
+		{
+			name:      "not an array",
+			body:      `{"url": "https://google.com"}`,
+			code:      http.StatusBadRequest,
+			respError: "failed to decode request",
+		},
+		{
+			name:      "empty",
+			body:      `[]`,
+			code:      http.StatusBadRequest,
+			respError: "batch is empty",
+		},
+		{
+			name:        "storage error",
+			body:        `[{"url": "https://google.com"}]`,
+			saveAliases: 1,
+			mockError:   errors.New("some error"),
+			code:        http.StatusInternalServerError,
+			respError:   "failed to add urls",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			batchSaverMock := mocks.NewURLBatchSaver(t)
+
+			if tc.saveAliases > 0 {
+				batchSaverMock.On("SaveURLs", mock.Anything, mock.MatchedBy(func(urls []storage.URL) bool {
+					for _, u := range urls {
+						if u.OwnerID != 42 || u.Alias == "" {
+							return false
+						}
+					}
+					return len(urls) == tc.saveAliases
+				})).Return(tc.saveResults, tc.mockError).Once()
+			}
+
+			maxItems := tc.maxItems
+			if maxItems == 0 {
+				maxItems = 100
+			}
+
+			handler := batch.New(slogdiscard.NewDiscardLogger(), batchSaverMock, newAllocator(), newRules(), maxItems, 1, config.DedupOff)
+
+			req, err := http.NewRequest(http.MethodPost, "/url/batch", strings.NewReader(tc.body))
+			require.NoError(t, err)
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			req = req.WithContext(auth.WithUser(req.Context(), auth.User{ID: 42}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.code, rr.Code)
+
+			var body batch.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+			require.Equal(t, tc.respError, body.Error)
+			if tc.respError != "" {
+				return
+			}
+
+			require.Len(t, body.Results, len(tc.results))
+			for i, want := range tc.results {
+				got := body.Results[i]
+				assert.Equal(t, want.Index, got.Index, fmt.Sprintf("item %d", i))
+				assert.Equal(t, want.Status, got.Status, fmt.Sprintf("item %d", i))
+				assert.Equal(t, want.Error, got.Error, fmt.Sprintf("item %d", i))
+				if want.Alias != "" {
+					assert.Equal(t, want.Alias, got.Alias)
+				}
+				if got.Status == resp.StatusOK {
+					assert.NotEmpty(t, got.Alias)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchHandler_AliasCollision(t *testing.T) {
+	batchSaverMock := mocks.NewURLBatchSaver(t)
+
+	input := `[{"url": "https://a.com"}, {"url": "https://b.com", "alias": "taken"}, {"url": "https://c.com"}]`
+
+	// Generated aliases collide in the first round, the custom alias is taken.
+	// The first item gets a new alias in the second round; the third one
+	// collides until it runs out of attempts.
+	batchSaverMock.On("SaveURLs", mock.Anything, mock.MatchedBy(func(urls []storage.URL) bool {
+		return len(urls) == 3 && urls[0].Generated && !urls[1].Generated && urls[2].Generated
+	})).Return([]storage.SaveResult{
+		{Err: storage.ErrURLExists},
+		{Err: storage.ErrURLExists},
+		{Err: storage.ErrURLExists},
+	}, nil).Once()
+	batchSaverMock.On("SaveURLs", mock.Anything, mock.MatchedBy(func(urls []storage.URL) bool {
+		return len(urls) == 2 && urls[0].URL == "https://a.com" && urls[1].URL == "https://c.com"
+	})).Return([]storage.SaveResult{{ID: 1}, {Err: storage.ErrURLExists}}, nil).Once()
+	batchSaverMock.On("SaveURLs", mock.Anything, mock.MatchedBy(func(urls []storage.URL) bool {
+		return len(urls) == 1 && urls[0].URL == "https://c.com" && len(urls[0].Alias) == 7
+	})).Return([]storage.SaveResult{{Err: storage.ErrURLExists}}, nil).Once()
+
+	handler := batch.New(slogdiscard.NewDiscardLogger(), batchSaverMock, newAllocator(), newRules(), 10, 1, config.DedupOff)
+
+	req, err := http.NewRequest(http.MethodPost, "/url/batch", strings.NewReader(input))
+	require.NoError(t, err)
+	req = req.WithContext(auth.WithUser(req.Context(), auth.User{ID: 42}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var body batch.Response
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+	require.Len(t, body.Results, 3)
+	assert.Equal(t, resp.StatusOK, body.Results[0].Status)
+	assert.Len(t, body.Results[0].Alias, 6)
+	assert.Equal(t, "url already exists", body.Results[1].Error)
+	assert.Equal(t, "failed to generate alias", body.Results[2].Error)
+	assert.Equal(t, 1, body.Created)
+	assert.Equal(t, 2, body.Failed)
+}
+
+func TestBatchHandler_Dedup(t *testing.T) {
+	input := `[
+		{"url": "https://google.com"},
+		{"url": "https://go.dev"},
+		{"url": "https://go.dev"},
+		{"url": "https://go.dev", "alias": "godev"},
+		{"url": "https://google.com", "ttl": "1h"}
+	]`
+
+	cases := []struct {
+		name    string
+		dedup   string
+		ownerID int64
+	}{
+		{name: "owner", dedup: config.DedupOwner, ownerID: 42},
+		{name: "global", dedup: config.DedupGlobal, ownerID: 0},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			batchSaverMock := mocks.NewURLBatchSaver(t)
+			batchSaverMock.On("FindGeneratedURL", mock.Anything, "", "https://google.com", tc.ownerID).
+				Return(storage.URL{Alias: "goog"}, nil).Once()
+			batchSaverMock.On("FindGeneratedURL", mock.Anything, "", "https://go.dev", tc.ownerID).
+				Return(storage.URL{}, storage.ErrURLNotFound).Once()
+			// The repeated go.dev item is not saved again, nor are the items
+			// with an alias or an expiration looked up.
+			batchSaverMock.On("SaveURLs", mock.Anything, mock.MatchedBy(func(urls []storage.URL) bool {
+				return len(urls) == 3 && urls[0].URL == "https://go.dev" && urls[1].Alias == "godev" &&
+					urls[2].ExpiresAt != nil
+			})).Return([]storage.SaveResult{{ID: 1}, {ID: 2}, {ID: 3}}, nil).Once()
+
+			handler := batch.New(slogdiscard.NewDiscardLogger(), batchSaverMock, newAllocator(), newRules(), 10, 1, tc.dedup)
+
+			req, err := http.NewRequest(http.MethodPost, "/url/batch", strings.NewReader(input))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), auth.User{ID: 42}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, http.StatusOK, rr.Code)
+
+			var body batch.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+
+			require.Len(t, body.Results, 5)
+			for i, res := range body.Results {
+				assert.Equal(t, i, res.Index)
+				assert.Equal(t, resp.StatusOK, res.Status)
+			}
+			assert.Equal(t, "goog", body.Results[0].Alias)
+			assert.NotEmpty(t, body.Results[1].Alias)
+			assert.Equal(t, body.Results[1].Alias, body.Results[2].Alias)
+			assert.Equal(t, "godev", body.Results[3].Alias)
+			assert.NotEqual(t, "goog", body.Results[4].Alias)
+		})
+	}
+}
+
+func TestBatchHandler_Unauthorized(t *testing.T) {
+	handler := batch.New(slogdiscard.NewDiscardLogger(), mocks.NewURLBatchSaver(t), newAllocator(), newRules(), 10, 1, config.DedupOff)
+
+	req, err := http.NewRequest(http.MethodPost, "/url/batch", strings.NewReader(`[]`))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
@@ -0,0 +1,87 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	storage "github.com/Braendie/url-shortener/internal/storage"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// URLBatchSaver is an autogenerated mock type for the URLBatchSaver type
+type URLBatchSaver struct {
+	mock.Mock
+}
+
+// FindGeneratedURL provides a mock function with given fields: ctx, domain, url, ownerID
+func (_m *URLBatchSaver) FindGeneratedURL(ctx context.Context, domain string, url string, ownerID int64) (storage.URL, error) {
+	ret := _m.Called(ctx, domain, url, ownerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindGeneratedURL")
+	}
+
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64) (storage.URL, error)); ok {
+		return rf(ctx, domain, url, ownerID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64) storage.URL); ok {
+		r0 = rf(ctx, domain, url, ownerID)
+	} else {
+		r0 = ret.Get(0).(storage.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int64) error); ok {
+		r1 = rf(ctx, domain, url, ownerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveURLs provides a mock function with given fields: ctx, urls
+func (_m *URLBatchSaver) SaveURLs(ctx context.Context, urls []storage.URL) ([]storage.SaveResult, error) {
+	ret := _m.Called(ctx, urls)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveURLs")
+	}
+
+	var r0 []storage.SaveResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []storage.URL) ([]storage.SaveResult, error)); ok {
+		return rf(ctx, urls)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []storage.URL) []storage.SaveResult); ok {
+		r0 = rf(ctx, urls)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.SaveResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []storage.URL) error); ok {
+		r1 = rf(ctx, urls)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewURLBatchSaver creates a new instance of URLBatchSaver. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewURLBatchSaver(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *URLBatchSaver {
+	mock := &URLBatchSaver{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -1,12 +1,16 @@
 package delete
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"net/http"
 
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/domain"
 	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
 	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
 	"github.com/Braendie/url-shortener/internal/storage"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -15,18 +19,26 @@ import (
 
 //go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=URLDeleter
 type URLDeleter interface {
-	DeleteURL(alias string) error
+	DeleteURL(ctx context.Context, domain, alias string) error
 }
 
-func New(log *slog.Logger, urlDeleter URLDeleter) http.HandlerFunc {
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=URLInfoGetter
+type URLInfoGetter interface {
+	GetURLInfo(ctx context.Context, domain, alias string) (storage.URL, error)
+}
+
+// New deletes an alias. Only the owner of the link or an admin may delete it.
+func New(log *slog.Logger, urlDeleter URLDeleter, urlInfoGetter URLInfoGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.delete.New"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
 		)
 
+		dom := domain.FromContext(r.Context())
 		alias := chi.URLParam(r, "alias")
 		if alias == "" {
 			log.Info("alias is empty")
@@ -35,7 +47,36 @@ func New(log *slog.Logger, urlDeleter URLDeleter) http.HandlerFunc {
 			return
 		}
 
-		err := urlDeleter.DeleteURL(alias)
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			log.Info("unauthorized request: no user in context")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("unauthorized"))
+			return
+		}
+
+		info, err := urlInfoGetter.GetURLInfo(r.Context(), dom.Name, alias)
+		if err != nil {
+			if errors.Is(err, storage.ErrURLNotFound) {
+				log.Info("not found")
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("not found"))
+			} else {
+				log.Error("failed to get url info", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("internal error"))
+			}
+			return
+		}
+
+		if !user.CanManage(info.OwnerID) {
+			log.Info("forbidden: not an owner", slog.Int64("uid", user.ID), slog.Int64("owner_id", info.OwnerID))
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.Error("forbidden"))
+			return
+		}
+
+		err = urlDeleter.DeleteURL(r.Context(), dom.Name, alias)
 		if err != nil {
 			if errors.Is(err, storage.ErrURLNotFound) {
 				log.Info("not found")
@@ -9,6 +9,7 @@ import (
 
 	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/delete"
 	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/delete/mocks"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
 	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
 	"github.com/Braendie/url-shortener/internal/storage"
 	"github.com/go-chi/chi/v5"
@@ -18,34 +19,60 @@ import (
 )
 
 func TestDeleteHandler(t *testing.T) {
+	owner := auth.User{ID: 1}
+
 	testCases := []struct {
 		name      string
 		alias     string
+		user      auth.User
+		ownerID   int64
 		respError string
+		infoError error
 		mockError error
 		code      int
 	}{
 		{
-			name:  "valid",
-			alias: "test_alias",
-			code:  http.StatusNoContent,
+			name:    "valid",
+			alias:   "test_alias",
+			user:    owner,
+			ownerID: owner.ID,
+			code:    http.StatusNoContent,
+		},
+		{
+			name:    "admin deletes someone else's link",
+			alias:   "test_alias",
+			user:    auth.User{ID: 2, IsAdmin: true},
+			ownerID: owner.ID,
+			code:    http.StatusNoContent,
+		},
+		{
+			name:      "not an owner",
+			alias:     "test_alias",
+			user:      auth.User{ID: 2},
+			ownerID:   owner.ID,
+			respError: "forbidden",
+			code:      http.StatusForbidden,
 		},
 		{
 			name:      "empty alias",
 			alias:     "",
+			user:      owner,
 			respError: "invalid request",
 			code:      http.StatusNotFound,
 		},
 		{
 			name:      "not found",
 			alias:     "test_alias",
+			user:      owner,
 			respError: "not found",
-			mockError: storage.ErrURLNotFound,
+			infoError: storage.ErrURLNotFound,
 			code:      http.StatusNotFound,
 		},
 		{
 			name:      "storage error",
 			alias:     "test_alias",
+			user:      owner,
+			ownerID:   owner.ID,
 			respError: "internal error",
 			mockError: errors.New("some error"),
 			code:      http.StatusInternalServerError,
@@ -59,18 +86,27 @@ func TestDeleteHandler(t *testing.T) {
 			t.Parallel()
 
 			urlDeleterMock := mocks.NewURLDeleter(t)
+			urlInfoGetterMock := mocks.NewURLInfoGetter(t)
 
 			if tc.alias != "" {
-				urlDeleterMock.On("DeleteURL", mock.AnythingOfType("string")).
+				urlInfoGetterMock.On("GetURLInfo", mock.Anything, "", tc.alias).
+					Return(storage.URL{Alias: tc.alias, OwnerID: tc.ownerID}, tc.infoError).
+					Once()
+			}
+
+			if tc.alias != "" && tc.infoError == nil && tc.code != http.StatusForbidden {
+				urlDeleterMock.On("DeleteURL", mock.Anything, "", mock.AnythingOfType("string")).
 					Return(tc.mockError).
 					Once()
 			}
-			handler := delete.New(slogdiscard.NewDiscardLogger(), urlDeleterMock)
+			handler := delete.New(slogdiscard.NewDiscardLogger(), urlDeleterMock, urlInfoGetterMock)
 			r := chi.NewRouter()
 			r.Delete("/{alias}", handler)
 
 			req, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/%s", tc.alias), nil)
 			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), tc.user))
+
 			rr := httptest.NewRecorder()
 			r.ServeHTTP(rr, req)
 
@@ -0,0 +1,127 @@
+package export
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/domain"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
+	"github.com/Braendie/url-shortener/internal/lib/transfer"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=URLExporter
+type URLExporter interface {
+	ExportURLs(ctx context.Context, domain string, ownerID int64, fn func(storage.URL) error) error
+}
+
+// New streams links of the domain of the request as a file in the format
+// given by the format query parameter (json, csv or ndjson; json by
+// default). Admins export all links, other users only their own and without
+// password hashes. The write deadline is moved writeTimeout ahead with every
+// write, so that exports that take longer than the server's write timeout
+// are not cut off while the client keeps reading.
+func New(log *slog.Logger, exporter URLExporter, writeTimeout time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.export.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			log.Info("unauthorized request: no user in context")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("unauthorized"))
+
+			return
+		}
+
+		format := transfer.FormatJSON
+		if raw := r.URL.Query().Get("format"); raw != "" {
+			f, err := transfer.ParseFormat(raw)
+			if err != nil {
+				log.Info("invalid export format", sl.Err(err))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid format"))
+
+				return
+			}
+			format = f
+		}
+
+		ownerID := user.ID
+		if user.IsAdmin {
+			ownerID = 0
+		}
+
+		// Headers can only be changed until the first record is written.
+		out := &lazyWriter{w: w, rc: http.NewResponseController(w), format: format, timeout: writeTimeout}
+		enc := transfer.NewEncoder(out, format)
+
+		var count int64
+
+		err := exporter.ExportURLs(r.Context(), domain.FromContext(r.Context()).Name, ownerID, func(u storage.URL) error {
+			count++
+
+			rec := transfer.FromURL(u)
+			if !user.IsAdmin {
+				rec.PasswordHash = ""
+			}
+
+			return enc.Encode(rec)
+		})
+		if err == nil {
+			err = enc.Close()
+		}
+		if err != nil {
+			log.Error("failed to export urls", sl.Err(err))
+
+			if !out.started {
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to export urls"))
+			}
+
+			return
+		}
+
+		log.Info("urls exported", slog.Int64("count", count), slog.String("format", string(format)))
+	}
+}
+
+// lazyWriter writes the response headers of the export on the first write
+// and extends the write deadline by timeout on every write.
+type lazyWriter struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	format  transfer.Format
+	timeout time.Duration
+	started bool
+}
+
+func (lw *lazyWriter) Write(p []byte) (int, error) {
+	if lw.timeout > 0 {
+		// Not every ResponseWriter supports deadlines, such as in tests.
+		_ = lw.rc.SetWriteDeadline(time.Now().Add(lw.timeout))
+	}
+
+	if !lw.started {
+		lw.started = true
+		lw.w.Header().Set("Content-Type", lw.format.ContentType())
+		lw.w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="urls.%s"`, lw.format))
+		lw.w.WriteHeader(http.StatusOK)
+	}
+
+	return lw.w.Write(p)
+}
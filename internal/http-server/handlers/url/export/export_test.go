@@ -0,0 +1,167 @@
+package export_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/export"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/export/mocks"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportHandler(t *testing.T) {
+	createdAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	urls := []storage.URL{
+		{Alias: "google", URL: "https://google.com", OwnerID: 42, CreatedAt: createdAt},
+		{Alias: "go", URL: "https://go.dev", OwnerID: 42, CreatedAt: createdAt, PasswordHash: "$2a$10$hash"},
+	}
+
+	cases := []struct {
+		name        string
+		query       string
+		user        auth.User
+		ownerID     int64
+		mockError   error
+		code        int
+		contentType string
+		body        string
+	}{
+		{
+			name:        "json",
+			user:        auth.User{ID: 42},
+			ownerID:     42,
+			code:        http.StatusOK,
+			contentType: "application/json",
+			// Password hashes are only exported for admins.
+			body: "[\n" +
+				`{"alias":"google","url":"https://google.com","owner_id":42,"created_at":"2024-05-01T12:00:00Z"},` + "\n" +
+				`{"alias":"go","url":"https://go.dev","owner_id":42,"created_at":"2024-05-01T12:00:00Z"}` + "\n]\n",
+		},
+		{
+			name:        "csv as admin",
+			query:       "?format=csv",
+			user:        auth.User{ID: 1, IsAdmin: true},
+			ownerID:     0,
+			code:        http.StatusOK,
+			contentType: "text/csv",
+			body: "alias,url,owner_id,created_at,expires_at,redirect_code,forward_query,password_hash,max_clicks,used_clicks,generated\n" +
+				"google,https://google.com,42,2024-05-01T12:00:00Z,,0,false,,0,0,false\n" +
+				"go,https://go.dev,42,2024-05-01T12:00:00Z,,0,false,$2a$10$hash,0,0,false\n",
+		},
+		{
+			name:  "unknown format",
+			query: "?format=xml",
+			user:  auth.User{ID: 42},
+			code:  http.StatusBadRequest,
+			body:  `{"status":"Error","error":"invalid format"}` + "\n",
+		},
+		{
+			name:      "storage error",
+			user:      auth.User{ID: 42},
+			ownerID:   42,
+			mockError: errors.New("unexpected error"),
+			code:      http.StatusInternalServerError,
+			body:      `{"status":"Error","error":"failed to export urls"}` + "\n",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			exporterMock := mocks.NewURLExporter(t)
+
+			if tc.code != http.StatusBadRequest {
+				exporterMock.On("ExportURLs", mock.Anything, "", tc.ownerID, mock.Anything).
+					Return(func(_ context.Context, _ string, _ int64, fn func(storage.URL) error) error {
+						if tc.mockError != nil {
+							return tc.mockError
+						}
+						for _, u := range urls {
+							if err := fn(u); err != nil {
+								return err
+							}
+						}
+						return nil
+					}).
+					Once()
+			}
+
+			handler := export.New(slogdiscard.NewDiscardLogger(), exporterMock, time.Second)
+
+			req, err := http.NewRequest(http.MethodGet, "/url/export"+tc.query, nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), tc.user))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.code, rr.Code)
+			assert.Equal(t, tc.body, rr.Body.String())
+			if tc.contentType != "" {
+				assert.Equal(t, tc.contentType, rr.Header().Get("Content-Type"))
+				assert.Contains(t, rr.Header().Get("Content-Disposition"), "attachment")
+			}
+		})
+	}
+}
+
+func TestExportHandler_WriteTimeout(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	exporterMock := mocks.NewURLExporter(t)
+	exporterMock.On("ExportURLs", mock.Anything, "", int64(0), mock.Anything).
+		Return(func(_ context.Context, _ string, _ int64, fn func(storage.URL) error) error {
+			for i := 0; i < 5; i++ {
+				time.Sleep(timeout / 2)
+				if err := fn(storage.URL{Alias: fmt.Sprintf("a%d", i), URL: "https://go.dev"}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).
+		Once()
+
+	handler := export.New(slogdiscard.NewDiscardLogger(), exporterMock, timeout)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), auth.User{ID: 1, IsAdmin: true})))
+	}))
+	srv.Config.WriteTimeout = timeout
+	srv.Start()
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/url/export?format=ndjson")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, 5, strings.Count(string(body), "\n"))
+}
+
+func TestExportHandler_Unauthorized(t *testing.T) {
+	handler := export.New(slogdiscard.NewDiscardLogger(), mocks.NewURLExporter(t), time.Second)
+
+	req, err := http.NewRequest(http.MethodGet, "/url/export", nil)
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
@@ -0,0 +1,48 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "github.com/Braendie/url-shortener/internal/storage"
+)
+
+// URLExporter is an autogenerated mock type for the URLExporter type
+type URLExporter struct {
+	mock.Mock
+}
+
+// ExportURLs provides a mock function with given fields: ctx, domain, ownerID, fn
+func (_m *URLExporter) ExportURLs(ctx context.Context, domain string, ownerID int64, fn func(storage.URL) error) error {
+	ret := _m.Called(ctx, domain, ownerID, fn)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ExportURLs")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64, func(storage.URL) error) error); ok {
+		r0 = rf(ctx, domain, ownerID, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewURLExporter creates a new instance of URLExporter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewURLExporter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *URLExporter {
+	mock := &URLExporter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
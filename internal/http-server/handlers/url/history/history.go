@@ -0,0 +1,127 @@
+package history
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/domain"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Revision struct {
+	URL       string     `json:"url"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	ChangedAt time.Time  `json:"changed_at"`
+	ChangedBy int64      `json:"changed_by"`
+}
+
+type Response struct {
+	resp.Response
+	Alias     string     `json:"alias"`
+	Revisions []Revision `json:"revisions"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=URLHistoryGetter
+type URLHistoryGetter interface {
+	URLHistory(ctx context.Context, domain, alias string) ([]storage.Revision, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=URLInfoGetter
+type URLInfoGetter interface {
+	GetURLInfo(ctx context.Context, domain, alias string) (storage.URL, error)
+}
+
+// New returns previous targets of an alias, newest first.
+// Only the owner of the link or an admin may see it.
+func New(log *slog.Logger, historyGetter URLHistoryGetter, urlInfoGetter URLInfoGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.history.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		dom := domain.FromContext(r.Context())
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			log.Info("unauthorized request: no user in context")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("unauthorized"))
+
+			return
+		}
+
+		info, err := urlInfoGetter.GetURLInfo(r.Context(), dom.Name, alias)
+		if err != nil {
+			responseStorageError(w, r, log, err)
+
+			return
+		}
+
+		if !user.CanManage(info.OwnerID) {
+			log.Info("forbidden: not an owner", slog.Int64("uid", user.ID), slog.Int64("owner_id", info.OwnerID))
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.Error("forbidden"))
+
+			return
+		}
+
+		revisions, err := historyGetter.URLHistory(r.Context(), dom.Name, alias)
+		if err != nil {
+			responseStorageError(w, r, log, err)
+
+			return
+		}
+
+		items := make([]Revision, 0, len(revisions))
+		for _, rev := range revisions {
+			items = append(items, Revision{
+				URL:       rev.URL,
+				ExpiresAt: rev.ExpiresAt,
+				ChangedAt: rev.ChangedAt,
+				ChangedBy: rev.ChangedBy,
+			})
+		}
+
+		render.JSON(w, r, Response{
+			Response:  resp.OK(),
+			Alias:     alias,
+			Revisions: items,
+		})
+	}
+}
+
+func responseStorageError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	if errors.Is(err, storage.ErrURLNotFound) {
+		log.Info("url not found")
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, resp.Error("not found"))
+
+		return
+	}
+
+	log.Error("failed to get url history", sl.Err(err))
+	render.Status(r, http.StatusInternalServerError)
+	render.JSON(w, r, resp.Error("internal error"))
+}
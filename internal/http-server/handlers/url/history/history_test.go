@@ -0,0 +1,113 @@
+package history_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/history"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/history/mocks"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistoryHandler(t *testing.T) {
+	owner := auth.User{ID: 1}
+
+	revisions := []storage.Revision{
+		{URL: "https://second.example.com", ChangedAt: time.Now(), ChangedBy: 1},
+		{URL: "https://first.example.com", ChangedAt: time.Now().Add(-time.Hour), ChangedBy: 1},
+	}
+
+	testCases := []struct {
+		name         string
+		user         auth.User
+		infoError    error
+		historyError error
+		respError    string
+		code         int
+	}{
+		{
+			name: "owner",
+			user: owner,
+			code: http.StatusOK,
+		},
+		{
+			name: "admin",
+			user: auth.User{ID: 2, IsAdmin: true},
+			code: http.StatusOK,
+		},
+		{
+			name:      "not an owner",
+			user:      auth.User{ID: 2},
+			respError: "forbidden",
+			code:      http.StatusForbidden,
+		},
+		{
+			name:      "not found",
+			user:      owner,
+			infoError: storage.ErrURLNotFound,
+			respError: "not found",
+			code:      http.StatusNotFound,
+		},
+		{
+			name:         "storage error",
+			user:         owner,
+			historyError: errors.New("some error"),
+			respError:    "internal error",
+			code:         http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			historyGetterMock := mocks.NewURLHistoryGetter(t)
+			urlInfoGetterMock := mocks.NewURLInfoGetter(t)
+
+			urlInfoGetterMock.On("GetURLInfo", mock.Anything, "", "test_alias").
+				Return(storage.URL{Alias: "test_alias", OwnerID: owner.ID}, tc.infoError).
+				Once()
+
+			if tc.infoError == nil && tc.code != http.StatusForbidden {
+				historyGetterMock.On("URLHistory", mock.Anything, "", "test_alias").
+					Return(revisions, tc.historyError).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}/history", history.New(slogdiscard.NewDiscardLogger(), historyGetterMock, urlInfoGetterMock))
+
+			req, err := http.NewRequest(http.MethodGet, "/test_alias/history", nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), tc.user))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.code, rr.Code)
+
+			var resp history.Response
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+
+			if tc.respError != "" {
+				assert.Equal(t, tc.respError, resp.Error)
+				return
+			}
+
+			require.Len(t, resp.Revisions, 2)
+			assert.Equal(t, revisions[0].URL, resp.Revisions[0].URL)
+		})
+	}
+}
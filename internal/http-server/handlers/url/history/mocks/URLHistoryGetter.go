@@ -0,0 +1,60 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "github.com/Braendie/url-shortener/internal/storage"
+)
+
+// URLHistoryGetter is an autogenerated mock type for the URLHistoryGetter type
+type URLHistoryGetter struct {
+	mock.Mock
+}
+
+// URLHistory provides a mock function with given fields: ctx, domain, alias
+func (_m *URLHistoryGetter) URLHistory(ctx context.Context, domain string, alias string) ([]storage.Revision, error) {
+	ret := _m.Called(ctx, domain, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for URLHistory")
+	}
+
+	var r0 []storage.Revision
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) ([]storage.Revision, error)); ok {
+		return rf(ctx, domain, alias)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) []storage.Revision); ok {
+		r0 = rf(ctx, domain, alias)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Revision)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, domain, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewURLHistoryGetter creates a new instance of URLHistoryGetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewURLHistoryGetter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *URLHistoryGetter {
+	mock := &URLHistoryGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
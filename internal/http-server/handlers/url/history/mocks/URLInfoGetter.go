@@ -0,0 +1,58 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "github.com/Braendie/url-shortener/internal/storage"
+)
+
+// URLInfoGetter is an autogenerated mock type for the URLInfoGetter type
+type URLInfoGetter struct {
+	mock.Mock
+}
+
+// GetURLInfo provides a mock function with given fields: ctx, domain, alias
+func (_m *URLInfoGetter) GetURLInfo(ctx context.Context, domain string, alias string) (storage.URL, error) {
+	ret := _m.Called(ctx, domain, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetURLInfo")
+	}
+
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (storage.URL, error)); ok {
+		return rf(ctx, domain, alias)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) storage.URL); ok {
+		r0 = rf(ctx, domain, alias)
+	} else {
+		r0 = ret.Get(0).(storage.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, domain, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewURLInfoGetter creates a new instance of URLInfoGetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewURLInfoGetter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *URLInfoGetter {
+	mock := &URLInfoGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,246 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/domain"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
+	"github.com/Braendie/url-shortener/internal/lib/transfer"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// maxRecordErrors bounds the invalid records reported by an import; reading
+// stops at the next invalid one.
+const maxRecordErrors = 100
+
+var errInvalidRecords = errors.New("invalid records")
+
+// RecordError explains why a record, counted from zero, cannot be imported.
+type RecordError struct {
+	Index int    `json:"index"`
+	Error string `json:"error"`
+}
+
+type Response struct {
+	resp.Response
+	storage.ImportResult
+	Errors []RecordError `json:"errors,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=URLImporter
+type URLImporter interface {
+	ImportURLs(
+		ctx context.Context,
+		next func() (storage.URL, error),
+		policy storage.ConflictPolicy,
+	) (storage.ImportResult, error)
+}
+
+// New imports links exported by the export handler into the domain of the
+// request. Only admins may import.
+// The format is taken from the format query parameter or the Content-Type
+// header, existing aliases are handled according to on_conflict (skip,
+// overwrite or fail; skip by default). Aliases are normalized and held to the
+// rules of custom aliases. The import is all or nothing: if any record is
+// invalid, nothing is imported and every invalid record is reported.
+//
+// Bodies larger than maxSize bytes are rejected. The read and write deadlines
+// are extended by timeout on every read, so that imports that take longer than
+// the server's timeouts are not cut off while the client keeps sending.
+func New(
+	log *slog.Logger,
+	importer URLImporter,
+	rules transfer.AliasRules,
+	maxSize int64,
+	timeout time.Duration,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.importer.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			log.Info("unauthorized request: no user in context")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("unauthorized"))
+
+			return
+		}
+
+		if !user.IsAdmin {
+			log.Info("import forbidden", slog.Int64("uid", user.ID))
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.Error("forbidden"))
+
+			return
+		}
+
+		format, err := requestFormat(r)
+		if err != nil {
+			log.Info("invalid import format", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid format"))
+
+			return
+		}
+
+		policy := storage.ConflictSkip
+		if raw := r.URL.Query().Get("on_conflict"); raw != "" {
+			policy = storage.ConflictPolicy(raw)
+		}
+
+		switch policy {
+		case storage.ConflictSkip, storage.ConflictOverwrite, storage.ConflictFail:
+		default:
+			log.Info("invalid conflict policy", slog.String("on_conflict", string(policy)))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("on_conflict must be one of skip, overwrite, fail"))
+
+			return
+		}
+
+		body := &deadlineReader{
+			r:       http.MaxBytesReader(w, r.Body, maxSize),
+			rc:      http.NewResponseController(w),
+			timeout: timeout,
+		}
+		dec := transfer.NewDecoder(body, format)
+		dom := domain.FromContext(r.Context())
+
+		// inputErr tells a bad request body apart from a storage failure.
+		var inputErr error
+		var recordErrs []RecordError
+		var records int
+
+		// Invalid records are collected instead of failing the import right
+		// away, the ones after them are only checked.
+		next := func() (storage.URL, error) {
+			for {
+				rec, err := dec.Decode()
+				if errors.Is(err, io.EOF) && len(recordErrs) > 0 {
+					return storage.URL{}, errInvalidRecords
+				}
+				if errors.Is(err, io.EOF) {
+					return storage.URL{}, err
+				}
+				if err != nil {
+					inputErr = err
+					return storage.URL{}, err
+				}
+
+				index := records
+				records++
+
+				rec, err = rec.Prepare(rules)
+				if err != nil {
+					recordErrs = append(recordErrs, RecordError{Index: index, Error: err.Error()})
+					if len(recordErrs) == maxRecordErrors {
+						return storage.URL{}, errInvalidRecords
+					}
+					continue
+				}
+				if len(recordErrs) > 0 {
+					continue
+				}
+
+				u := rec.ToURL()
+				u.Domain = dom.Name
+
+				return u, nil
+			}
+		}
+
+		res, err := importer.ImportURLs(r.Context(), next, policy)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+
+			switch {
+			case errors.Is(err, storage.ErrURLExists):
+				log.Info("import conflict", sl.Err(err))
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error("url already exists"))
+			case errors.Is(err, errInvalidRecords):
+				log.Info("invalid import records", slog.Int("count", len(recordErrs)))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, Response{
+					Response: resp.Error("invalid records"),
+					Errors:   recordErrs,
+				})
+			case inputErr != nil && errors.As(err, &tooLarge):
+				log.Info("import is too large", slog.Int64("limit", tooLarge.Limit))
+				render.Status(r, http.StatusRequestEntityTooLarge)
+				render.JSON(w, r, resp.Error("request is too large"))
+			case inputErr != nil && errors.Is(err, inputErr):
+				log.Info("invalid import data", sl.Err(err))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("failed to decode request"))
+			default:
+				log.Error("failed to import urls", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to import urls"))
+			}
+
+			return
+		}
+
+		log.Info("urls imported",
+			slog.Int64("created", res.Created),
+			slog.Int64("overwritten", res.Overwritten),
+			slog.Int64("skipped", res.Skipped),
+		)
+
+		render.JSON(w, r, Response{
+			Response:     resp.OK(),
+			ImportResult: res,
+		})
+	}
+}
+
+// deadlineReader extends the read and write deadlines of the request by
+// timeout on every read.
+type deadlineReader struct {
+	r       io.Reader
+	rc      *http.ResponseController
+	timeout time.Duration
+}
+
+func (dr *deadlineReader) Read(p []byte) (int, error) {
+	if dr.timeout > 0 {
+		// Not every ResponseWriter supports deadlines, such as in tests.
+		deadline := time.Now().Add(dr.timeout)
+		_ = dr.rc.SetReadDeadline(deadline)
+		_ = dr.rc.SetWriteDeadline(deadline)
+	}
+
+	return dr.r.Read(p)
+}
+
+func requestFormat(r *http.Request) (transfer.Format, error) {
+	if raw := r.URL.Query().Get("format"); raw != "" {
+		return transfer.ParseFormat(raw)
+	}
+
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err == nil {
+		if f, ok := transfer.FormatFromContentType(mediaType); ok {
+			return f, nil
+		}
+	}
+
+	return transfer.FormatJSON, nil
+}
@@ -0,0 +1,273 @@
+package importer_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/importer"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/importer/mocks"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/lib/alias"
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const csvBody = "alias,url,owner_id,created_at,expires_at\n" +
+	"google,https://google.com,42,2024-05-01T12:00:00Z,\n" +
+	"go,https://go.dev,42,,2999-01-01T00:00:00Z\n"
+
+// importAll reads every record like the storage does and fails on the
+// first error, or with conflictAlias once it is read.
+func importAll(conflictAlias string) func(context.Context, func() (storage.URL, error), storage.ConflictPolicy) (storage.ImportResult, error) {
+	return func(_ context.Context, next func() (storage.URL, error), _ storage.ConflictPolicy) (storage.ImportResult, error) {
+		var res storage.ImportResult
+
+		for {
+			u, err := next()
+			if errors.Is(err, io.EOF) {
+				return res, nil
+			}
+			if err != nil {
+				return storage.ImportResult{}, fmt.Errorf("import: %w", err)
+			}
+			if u.Alias == conflictAlias {
+				return storage.ImportResult{}, fmt.Errorf("import: %w", storage.ErrURLExists)
+			}
+			res.Created++
+		}
+	}
+}
+
+func TestImportHandler(t *testing.T) {
+	cases := []struct {
+		name          string
+		query         string
+		contentType   string
+		body          string
+		user          auth.User
+		policy        storage.ConflictPolicy
+		conflictAlias string
+		maxSize       int64
+		mockError     error
+		code          int
+		respError     string
+		recordErrors  []importer.RecordError
+		created       int64
+	}{
+		{
+			name:    "json",
+			body:    `[{"alias": "google", "url": "https://google.com", "owner_id": 42}]`,
+			policy:  storage.ConflictSkip,
+			code:    http.StatusOK,
+			created: 1,
+		},
+		{
+			name:        "csv by content type",
+			query:       "?on_conflict=overwrite",
+			contentType: "text/csv; charset=utf-8",
+			body:        csvBody,
+			policy:      storage.ConflictOverwrite,
+			code:        http.StatusOK,
+			created:     2,
+		},
+		{
+			name:    "ndjson by query",
+			query:   "?format=ndjson",
+			body:    "{\"alias\": \"a\", \"url\": \"https://a.com\"}\n{\"alias\": \"b\", \"url\": \"https://b.com\"}\n",
+			policy:  storage.ConflictSkip,
+			code:    http.StatusOK,
+			created: 2,
+		},
+		{
+			name:          "conflict",
+			query:         "?format=csv&on_conflict=fail",
+			body:          csvBody,
+			policy:        storage.ConflictFail,
+			conflictAlias: "go",
+			code:          http.StatusConflict,
+			respError:     "url already exists",
+		},
+		{
+			name:      "invalid record",
+			body:      `[{"alias": "google", "url": "google.com"}]`,
+			policy:    storage.ConflictSkip,
+			code:      http.StatusBadRequest,
+			respError: "invalid records",
+			recordErrors: []importer.RecordError{
+				{Index: 0, Error: `invalid record: alias "google": url is not valid`},
+			},
+		},
+		{
+			name:      "invalid aliases",
+			query:     "?format=ndjson",
+			body:      "{\"alias\": \"URL\", \"url\": \"https://a.com\"}\n{\"alias\": \"ok\", \"url\": \"https://b.com\"}\n{\"alias\": \"a.b\", \"url\": \"https://c.com\"}\n",
+			policy:    storage.ConflictSkip,
+			code:      http.StatusBadRequest,
+			respError: "invalid records",
+			recordErrors: []importer.RecordError{
+				{Index: 0, Error: `invalid record: alias "url" is reserved`},
+				{Index: 2, Error: `invalid record: alias "a.b" may only contain latin letters, digits, '-' and '_'`},
+			},
+		},
+		{
+			name:    "normalized alias",
+			body:    `[{"alias": "GoDev", "url": "https://go.dev"}]`,
+			policy:  storage.ConflictSkip,
+			code:    http.StatusOK,
+			created: 1,
+		},
+		{
+			name:      "broken body",
+			body:      `[{"alias": `,
+			policy:    storage.ConflictSkip,
+			code:      http.StatusBadRequest,
+			respError: "failed to decode request",
+		},
+		{
+			name:      "too large",
+			query:     "?format=csv",
+			body:      csvBody,
+			maxSize:   int64(len(csvBody) - 1),
+			policy:    storage.ConflictSkip,
+			code:      http.StatusRequestEntityTooLarge,
+			respError: "request is too large",
+		},
+		{
+			name:      "storage error",
+			body:      `[]`,
+			policy:    storage.ConflictSkip,
+			mockError: errors.New("unexpected error"),
+			code:      http.StatusInternalServerError,
+			respError: "failed to import urls",
+		},
+		{
+			name:      "unknown format",
+			query:     "?format=xml",
+			code:      http.StatusBadRequest,
+			respError: "invalid format",
+		},
+		{
+			name:      "unknown policy",
+			query:     "?on_conflict=merge",
+			code:      http.StatusBadRequest,
+			respError: "on_conflict must be one of skip, overwrite, fail",
+		},
+		{
+			name:      "not admin",
+			user:      auth.User{ID: 42},
+			code:      http.StatusForbidden,
+			respError: "forbidden",
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			importerMock := mocks.NewURLImporter(t)
+
+			if tc.policy != "" {
+				call := importerMock.On("ImportURLs", mock.Anything, mock.Anything, tc.policy)
+				if tc.mockError != nil {
+					call.Return(storage.ImportResult{}, tc.mockError)
+				} else {
+					call.Return(importAll(tc.conflictAlias))
+				}
+				call.Once()
+			}
+
+			rules := alias.NewRules(config.Alias{CaseInsensitive: true})
+			rules.Reserve("url")
+
+			maxSize := tc.maxSize
+			if maxSize == 0 {
+				maxSize = 1 << 20
+			}
+
+			handler := importer.New(slogdiscard.NewDiscardLogger(), importerMock, rules, maxSize, time.Second)
+
+			user := tc.user
+			if user.ID == 0 {
+				user = auth.User{ID: 1, IsAdmin: true}
+			}
+
+			req, err := http.NewRequest(http.MethodPost, "/url/import"+tc.query, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			if tc.contentType != "" {
+				req.Header.Set("Content-Type", tc.contentType)
+			}
+			req = req.WithContext(auth.WithUser(req.Context(), user))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.code, rr.Code)
+
+			var resp struct {
+				Error   string                 `json:"error"`
+				Created int64                  `json:"created"`
+				Errors  []importer.RecordError `json:"errors"`
+			}
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+
+			assert.Equal(t, tc.respError, resp.Error)
+			assert.Equal(t, tc.created, resp.Created)
+			assert.Equal(t, tc.recordErrors, resp.Errors)
+		})
+	}
+}
+
+func TestImportHandler_ReadTimeout(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	importerMock := mocks.NewURLImporter(t)
+	importerMock.On("ImportURLs", mock.Anything, mock.Anything, storage.ConflictSkip).Return(importAll("")).Once()
+
+	handler := importer.New(
+		slogdiscard.NewDiscardLogger(), importerMock, alias.NewRules(config.Alias{}), 1<<20, timeout,
+	)
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r.WithContext(auth.WithUser(r.Context(), auth.User{ID: 1, IsAdmin: true})))
+	}))
+	srv.Config.ReadTimeout = timeout
+	srv.Config.WriteTimeout = timeout
+	srv.Start()
+	defer srv.Close()
+
+	// The client sends the records slower than the server timeouts allow for
+	// the whole body.
+	body, pw := io.Pipe()
+	go func() {
+		for i := 0; i < 5; i++ {
+			time.Sleep(timeout / 2)
+			_, _ = fmt.Fprintf(pw, "{\"alias\": \"a%d\", \"url\": \"https://go.dev\"}\n", i)
+		}
+		_ = pw.Close()
+	}()
+
+	resp, err := http.Post(srv.URL+"/url/import?format=ndjson", "application/x-ndjson", body)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	var res struct {
+		Created int64 `json:"created"`
+	}
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	assert.Equal(t, int64(5), res.Created)
+}
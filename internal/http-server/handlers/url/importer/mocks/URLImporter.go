@@ -0,0 +1,58 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "github.com/Braendie/url-shortener/internal/storage"
+)
+
+// URLImporter is an autogenerated mock type for the URLImporter type
+type URLImporter struct {
+	mock.Mock
+}
+
+// ImportURLs provides a mock function with given fields: ctx, next, policy
+func (_m *URLImporter) ImportURLs(ctx context.Context, next func() (storage.URL, error), policy storage.ConflictPolicy) (storage.ImportResult, error) {
+	ret := _m.Called(ctx, next, policy)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ImportURLs")
+	}
+
+	var r0 storage.ImportResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, func() (storage.URL, error), storage.ConflictPolicy) (storage.ImportResult, error)); ok {
+		return rf(ctx, next, policy)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, func() (storage.URL, error), storage.ConflictPolicy) storage.ImportResult); ok {
+		r0 = rf(ctx, next, policy)
+	} else {
+		r0 = ret.Get(0).(storage.ImportResult)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, func() (storage.URL, error), storage.ConflictPolicy) error); ok {
+		r1 = rf(ctx, next, policy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewURLImporter creates a new instance of URLImporter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewURLImporter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *URLImporter {
+	mock := &URLImporter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
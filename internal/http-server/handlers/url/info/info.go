@@ -0,0 +1,138 @@
+package info
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/domain"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	resp.Response
+	Alias        string     `json:"alias"`
+	ShortURL     string     `json:"short_url"`
+	URL          string     `json:"url"`
+	OwnerID      int64      `json:"owner_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	RedirectCode int        `json:"redirect_code,omitempty"`
+	ForwardQuery bool       `json:"forward_query"`
+	Protected    bool       `json:"protected"`
+	MaxClicks    int64      `json:"max_clicks,omitempty"`
+	// RemainingClicks is omitted for links without a click limit.
+	RemainingClicks *int64 `json:"remaining_clicks,omitempty"`
+	Clicks          int64  `json:"clicks"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=URLInfoGetter
+type URLInfoGetter interface {
+	GetURLInfo(ctx context.Context, domain, alias string) (storage.URL, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=ClickCounter
+type ClickCounter interface {
+	CountClicks(ctx context.Context, domain, alias string) (int64, error)
+}
+
+// LinkBuilder builds the public link of an alias, see shorturl.Builder.
+type LinkBuilder interface {
+	URL(r *http.Request, domain, alias string) string
+}
+
+// New returns the link of an alias without following it, including expired
+// and exhausted links. Only the owner of the link or an admin may see it.
+func New(log *slog.Logger, urlInfoGetter URLInfoGetter, clickCounter ClickCounter, links LinkBuilder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.info.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		dom := domain.FromContext(r.Context())
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			log.Info("unauthorized request: no user in context")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("unauthorized"))
+
+			return
+		}
+
+		link, err := urlInfoGetter.GetURLInfo(r.Context(), dom.Name, alias)
+		if err != nil {
+			responseStorageError(w, r, log, err)
+
+			return
+		}
+
+		if !user.CanManage(link.OwnerID) {
+			log.Info("forbidden: not an owner", slog.Int64("uid", user.ID), slog.Int64("owner_id", link.OwnerID))
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.Error("forbidden"))
+
+			return
+		}
+
+		clicks, err := clickCounter.CountClicks(r.Context(), dom.Name, alias)
+		if err != nil {
+			responseStorageError(w, r, log, err)
+
+			return
+		}
+
+		render.JSON(w, r, Response{
+			Response:        resp.OK(),
+			Alias:           link.Alias,
+			ShortURL:        links.URL(r, link.Domain, link.Alias),
+			URL:             link.URL,
+			OwnerID:         link.OwnerID,
+			CreatedAt:       link.CreatedAt,
+			UpdatedAt:       link.UpdatedAt,
+			ExpiresAt:       link.ExpiresAt,
+			RedirectCode:    link.RedirectCode,
+			ForwardQuery:    link.ForwardQuery,
+			Protected:       link.PasswordHash != "",
+			MaxClicks:       link.MaxClicks,
+			RemainingClicks: link.RemainingClicks(),
+			Clicks:          clicks,
+		})
+	}
+}
+
+func responseStorageError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	if errors.Is(err, storage.ErrURLNotFound) {
+		log.Info("url not found")
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, resp.Error("not found"))
+
+		return
+	}
+
+	log.Error("failed to get url info", sl.Err(err))
+	render.Status(r, http.StatusInternalServerError)
+	render.JSON(w, r, resp.Error("internal error"))
+}
@@ -0,0 +1,129 @@
+package info_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/info"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/info/mocks"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/lib/shorturl"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfoHandler(t *testing.T) {
+	owner := auth.User{ID: 1}
+
+	link := storage.URL{
+		Alias:        "test_alias",
+		URL:          "https://go.dev",
+		OwnerID:      owner.ID,
+		CreatedAt:    time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		RedirectCode: http.StatusMovedPermanently,
+		PasswordHash: "hash",
+		MaxClicks:    5,
+		UsedClicks:   2,
+	}
+
+	testCases := []struct {
+		name       string
+		user       auth.User
+		infoError  error
+		countError error
+		respError  string
+		code       int
+	}{
+		{
+			name: "owner",
+			user: owner,
+			code: http.StatusOK,
+		},
+		{
+			name: "admin",
+			user: auth.User{ID: 2, IsAdmin: true},
+			code: http.StatusOK,
+		},
+		{
+			name:      "not an owner",
+			user:      auth.User{ID: 2},
+			respError: "forbidden",
+			code:      http.StatusForbidden,
+		},
+		{
+			name:      "not found",
+			user:      owner,
+			infoError: storage.ErrURLNotFound,
+			respError: "not found",
+			code:      http.StatusNotFound,
+		},
+		{
+			name:       "storage error",
+			user:       owner,
+			countError: errors.New("some error"),
+			respError:  "internal error",
+			code:       http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlInfoGetterMock := mocks.NewURLInfoGetter(t)
+			clickCounterMock := mocks.NewClickCounter(t)
+
+			urlInfoGetterMock.On("GetURLInfo", mock.Anything, "", "test_alias").
+				Return(link, tc.infoError).
+				Once()
+
+			if tc.infoError == nil && tc.code != http.StatusForbidden {
+				clickCounterMock.On("CountClicks", mock.Anything, "", "test_alias").
+					Return(int64(7), tc.countError).
+					Once()
+			}
+
+			shortLinks, err := shorturl.New("", nil)
+			require.NoError(t, err)
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", info.New(slogdiscard.NewDiscardLogger(), urlInfoGetterMock, clickCounterMock, shortLinks))
+
+			req, err := http.NewRequest(http.MethodGet, "http://sho.rt/test_alias", nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), tc.user))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.code, rr.Code)
+
+			var resp info.Response
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+
+			if tc.respError != "" {
+				assert.Equal(t, tc.respError, resp.Error)
+				return
+			}
+
+			assert.Equal(t, link.URL, resp.URL)
+			assert.Equal(t, "http://sho.rt/test_alias", resp.ShortURL)
+			assert.Equal(t, link.CreatedAt, resp.CreatedAt)
+			assert.Equal(t, http.StatusMovedPermanently, resp.RedirectCode)
+			assert.True(t, resp.Protected)
+			require.NotNil(t, resp.RemainingClicks)
+			assert.EqualValues(t, 3, *resp.RemainingClicks)
+			assert.EqualValues(t, 7, resp.Clicks)
+		})
+	}
+}
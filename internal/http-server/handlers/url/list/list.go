@@ -0,0 +1,270 @@
+package list
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/domain"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 500
+)
+
+type Item struct {
+	Alias     string     `json:"alias"`
+	ShortURL  string     `json:"short_url"`
+	URL       string     `json:"url"`
+	OwnerID   int64      `json:"owner_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	Clicks    int64      `json:"clicks"`
+	MaxClicks int64      `json:"max_clicks,omitempty"`
+	// RemainingClicks is omitted for links without a click limit.
+	RemainingClicks *int64 `json:"remaining_clicks,omitempty"`
+}
+
+type Response struct {
+	resp.Response
+	Items      []Item `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=URLLister
+type URLLister interface {
+	ListURLs(ctx context.Context, q storage.ListQuery) ([]storage.URL, error)
+}
+
+// LinkBuilder builds the public link of an alias, see shorturl.Builder.
+type LinkBuilder interface {
+	URL(r *http.Request, domain, alias string) string
+}
+
+// New lists links of the domain of the request. Query parameters: owner, alias_prefix, domain, sort
+// (created_at, alias or url), order (asc or desc), limit and cursor.
+// Non-admin users only see their own links.
+func New(log *slog.Logger, urlLister URLLister, links LinkBuilder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.list.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			log.Info("unauthorized request: no user in context")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("unauthorized"))
+
+			return
+		}
+
+		q, err := parseQuery(r, user)
+		if err != nil {
+			log.Info("invalid list query", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(err.Error()))
+
+			return
+		}
+
+		limit := q.Limit
+		// One extra row tells whether there is a next page.
+		q.Limit++
+
+		urls, err := urlLister.ListURLs(r.Context(), q)
+		if err != nil {
+			log.Error("failed to list urls", sl.Err(err))
+			render.Status(r, http.StatusInternalServerError)
+			render.JSON(w, r, resp.Error("internal error"))
+
+			return
+		}
+
+		var next string
+		if len(urls) > limit {
+			urls = urls[:limit]
+			next = encodeCursor(q, urls[limit-1])
+		}
+
+		items := make([]Item, 0, len(urls))
+		for _, u := range urls {
+			items = append(items, Item{
+				Alias:           u.Alias,
+				ShortURL:        links.URL(r, u.Domain, u.Alias),
+				URL:             u.URL,
+				OwnerID:         u.OwnerID,
+				CreatedAt:       u.CreatedAt,
+				ExpiresAt:       u.ExpiresAt,
+				Clicks:          u.Clicks,
+				MaxClicks:       u.MaxClicks,
+				RemainingClicks: u.RemainingClicks(),
+			})
+		}
+
+		render.JSON(w, r, Response{
+			Response:   resp.OK(),
+			Items:      items,
+			NextCursor: next,
+		})
+	}
+}
+
+func parseQuery(r *http.Request, user auth.User) (storage.ListQuery, error) {
+	values := r.URL.Query()
+
+	q := storage.ListQuery{
+		Domain:       domain.FromContext(r.Context()).Name,
+		AliasPrefix:  values.Get("alias_prefix"),
+		TargetDomain: values.Get("domain"),
+		SortBy:       storage.SortByCreatedAt,
+		Desc:         true,
+		Limit:        defaultLimit,
+	}
+
+	if raw := values.Get("owner"); raw != "" {
+		owner, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return q, errors.New("owner must be a user id")
+		}
+		q.OwnerID = owner
+	}
+
+	if !user.IsAdmin {
+		if q.OwnerID != 0 && q.OwnerID != user.ID {
+			return q, errors.New("owner filter is available to admins only")
+		}
+		q.OwnerID = user.ID
+	}
+
+	switch sortBy := storage.SortField(values.Get("sort")); sortBy {
+	case "":
+	case storage.SortByCreatedAt, storage.SortByAlias, storage.SortByURL:
+		q.SortBy = sortBy
+		q.Desc = sortBy == storage.SortByCreatedAt
+	default:
+		return q, errors.New("sort must be created_at, alias or url")
+	}
+
+	switch values.Get("order") {
+	case "":
+	case "asc":
+		q.Desc = false
+	case "desc":
+		q.Desc = true
+	default:
+		return q, errors.New("order must be asc or desc")
+	}
+
+	if raw := values.Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 || limit > maxLimit {
+			return q, errors.New("limit must be between 1 and " + strconv.Itoa(maxLimit))
+		}
+		q.Limit = limit
+	}
+
+	if raw := values.Get("cursor"); raw != "" {
+		after, err := decodeCursor(q, raw)
+		if err != nil {
+			return q, err
+		}
+		q.After = after
+	}
+
+	return q, nil
+}
+
+// cursor is the opaque pagination token. It remembers the ordering and a
+// hash of the filters it was issued for, so it cannot be reused with a
+// different sort or with different filters.
+type cursor struct {
+	SortBy    storage.SortField `json:"s"`
+	Desc      bool              `json:"d,omitempty"`
+	Filters   string            `json:"f"`
+	ID        int64             `json:"i"`
+	Alias     string            `json:"a,omitempty"`
+	URL       string            `json:"u,omitempty"`
+	CreatedAt time.Time         `json:"c,omitempty"`
+}
+
+func encodeCursor(q storage.ListQuery, last storage.URL) string {
+	c := cursor{
+		SortBy:  q.SortBy,
+		Desc:    q.Desc,
+		Filters: filtersHash(q),
+		ID:      last.ID,
+	}
+
+	switch q.SortBy {
+	case storage.SortByAlias:
+		c.Alias = last.Alias
+	case storage.SortByURL:
+		c.URL = last.URL
+	default:
+		c.CreatedAt = last.CreatedAt
+	}
+
+	raw, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeCursor(q storage.ListQuery, raw string) (*storage.URL, error) {
+	errInvalid := errors.New("invalid cursor")
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, errInvalid
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, errInvalid
+	}
+
+	if c.SortBy != q.SortBy || c.Desc != q.Desc {
+		return nil, errors.New("cursor does not match sort order")
+	}
+	if c.Filters != filtersHash(q) {
+		return nil, errors.New("cursor does not match filters")
+	}
+
+	return &storage.URL{
+		ID:        c.ID,
+		Alias:     c.Alias,
+		URL:       c.URL,
+		CreatedAt: c.CreatedAt,
+	}, nil
+}
+
+// filtersHash identifies the links a query selects, regardless of order.
+func filtersHash(q storage.ListQuery) string {
+	raw, _ := json.Marshal([]string{
+		q.Domain,
+		strconv.FormatInt(q.OwnerID, 10),
+		q.AliasPrefix,
+		q.TargetDomain,
+	})
+	sum := sha256.Sum256(raw)
+
+	return base64.RawURLEncoding.EncodeToString(sum[:12])
+}
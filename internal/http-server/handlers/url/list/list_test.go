@@ -0,0 +1,212 @@
+package list_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/list"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/list/mocks"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/lib/shorturl"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListHandler(t *testing.T) {
+	links := []storage.URL{
+		{ID: 3, Alias: "c", URL: "https://c.com", OwnerID: 1, CreatedAt: time.Now()},
+		{ID: 2, Alias: "b", URL: "https://b.com", OwnerID: 1, CreatedAt: time.Now().Add(-time.Hour)},
+	}
+
+	testCases := []struct {
+		name      string
+		query     string
+		user      auth.User
+		match     func(q storage.ListQuery) bool
+		result    []storage.URL
+		mockError error
+		respError string
+		items     int
+		hasNext   bool
+		code      int
+	}{
+		{
+			name:   "own links",
+			user:   auth.User{ID: 1},
+			match:  func(q storage.ListQuery) bool { return q.OwnerID == 1 && q.Limit == 51 && q.Desc },
+			result: links,
+			items:  2,
+			code:   http.StatusOK,
+		},
+		{
+			name:  "admin filters by owner",
+			query: "?owner=5&alias_prefix=ab&domain=example.com&sort=alias",
+			user:  auth.User{ID: 1, IsAdmin: true},
+			match: func(q storage.ListQuery) bool {
+				return q.OwnerID == 5 && q.AliasPrefix == "ab" && q.TargetDomain == "example.com" &&
+					q.SortBy == storage.SortByAlias && !q.Desc
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:    "next page",
+			query:   "?limit=1",
+			user:    auth.User{ID: 1},
+			match:   func(q storage.ListQuery) bool { return q.Limit == 2 },
+			result:  links,
+			items:   1,
+			hasNext: true,
+			code:    http.StatusOK,
+		},
+		{
+			name:      "other owner for non-admin",
+			query:     "?owner=5",
+			user:      auth.User{ID: 1},
+			respError: "owner filter is available to admins only",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "invalid sort",
+			query:     "?sort=clicks",
+			user:      auth.User{ID: 1},
+			respError: "sort must be created_at, alias or url",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "invalid cursor",
+			query:     "?cursor=garbage!",
+			user:      auth.User{ID: 1},
+			respError: "invalid cursor",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "storage error",
+			user:      auth.User{ID: 1},
+			match:     func(q storage.ListQuery) bool { return true },
+			mockError: errors.New("some error"),
+			respError: "internal error",
+			code:      http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlListerMock := mocks.NewURLLister(t)
+
+			if tc.match != nil {
+				urlListerMock.On("ListURLs", mock.Anything, mock.MatchedBy(tc.match)).
+					Return(tc.result, tc.mockError).
+					Once()
+			}
+
+			shortLinks, err := shorturl.New("https://sho.rt", nil)
+			require.NoError(t, err)
+			handler := list.New(slogdiscard.NewDiscardLogger(), urlListerMock, shortLinks)
+
+			req, err := http.NewRequest(http.MethodGet, "/url"+tc.query, nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), tc.user))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.code, rr.Code)
+
+			var resp list.Response
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+
+			if tc.respError != "" {
+				assert.Equal(t, tc.respError, resp.Error)
+				return
+			}
+
+			assert.Len(t, resp.Items, tc.items)
+			assert.Equal(t, tc.hasNext, resp.NextCursor != "")
+		})
+	}
+}
+
+func TestListHandler_Cursor(t *testing.T) {
+	urlListerMock := mocks.NewURLLister(t)
+
+	last := storage.URL{ID: 2, Alias: "b", CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	urlListerMock.On("ListURLs", mock.Anything, mock.MatchedBy(func(q storage.ListQuery) bool { return q.After == nil })).
+		Return([]storage.URL{{ID: 3, Alias: "c"}, last, {ID: 1, Alias: "a"}}, nil).
+		Once()
+	urlListerMock.On("ListURLs", mock.Anything, mock.MatchedBy(func(q storage.ListQuery) bool {
+		return q.After != nil && q.After.ID == last.ID && q.After.CreatedAt.Equal(last.CreatedAt)
+	})).
+		Return([]storage.URL{{ID: 1, Alias: "a"}}, nil).
+		Once()
+
+	shortLinks, err := shorturl.New("https://sho.rt", nil)
+	require.NoError(t, err)
+	handler := list.New(slogdiscard.NewDiscardLogger(), urlListerMock, shortLinks)
+	user := auth.User{ID: 1}
+
+	get := func(query string) list.Response {
+		req, err := http.NewRequest(http.MethodGet, "/url"+query, nil)
+		require.NoError(t, err)
+		req = req.WithContext(auth.WithUser(req.Context(), user))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		require.Equal(t, http.StatusOK, rr.Code)
+
+		var resp list.Response
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+
+		return resp
+	}
+
+	first := get("?limit=2")
+	require.Len(t, first.Items, 2)
+	require.NotEmpty(t, first.NextCursor)
+	assert.Equal(t, "https://sho.rt/c", first.Items[0].ShortURL)
+
+	second := get("?limit=2&cursor=" + first.NextCursor)
+	require.Len(t, second.Items, 1)
+	assert.Empty(t, second.NextCursor)
+
+	// A cursor cannot be used with another sort order or other filters.
+	mismatches := map[string]string{
+		"?sort=alias":     "cursor does not match sort order",
+		"?alias_prefix=a": "cursor does not match filters",
+		"?domain=ya.ru":   "cursor does not match filters",
+	}
+	for query, wantErr := range mismatches {
+		req, err := http.NewRequest(http.MethodGet, "/url"+query+"&cursor="+first.NextCursor, nil)
+		require.NoError(t, err)
+		req = req.WithContext(auth.WithUser(req.Context(), user))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusBadRequest, rr.Code, query)
+
+		var resp list.Response
+		require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+		assert.Equal(t, wantErr, resp.Error, query)
+	}
+
+	// Nor can an admin reuse it for another owner.
+	admin := auth.User{ID: 1, IsAdmin: true}
+	req, err := http.NewRequest(http.MethodGet, "/url?owner=2&cursor="+first.NextCursor, nil)
+	require.NoError(t, err)
+	req = req.WithContext(auth.WithUser(req.Context(), admin))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -0,0 +1,131 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/domain"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type Response struct {
+	resp.Response
+	Alias string `json:"alias"`
+	// URL is omitted for password-protected links.
+	URL       string     `json:"url,omitempty"`
+	Protected bool       `json:"protected"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=URLGetter
+type URLGetter interface {
+	GetURL(ctx context.Context, domain, alias string) (storage.URL, error)
+}
+
+var pageTemplate = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>Link preview</title>
+</head>
+<body>
+{{if .Protected}}<p>This link is protected by a password.</p>
+{{else}}<p>This link leads to</p>
+<p><code>{{.URL}}</code></p>
+{{end}}{{with .ExpiresAt}}<p>It expires on {{.Format "2006-01-02 15:04 MST"}}.</p>
+{{end}}<p><a href="{{.Link}}" rel="nofollow">Continue</a></p>
+</body>
+</html>
+`))
+
+type page struct {
+	Response
+	Link string
+}
+
+// New shows where a link leads instead of redirecting, so that the
+// destination can be checked before following it. It is served on the
+// short link followed by "+" and records no click. The destination of
+// password-protected links is not shown. Clients that accept
+// application/json get the preview as JSON.
+func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.preview.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		dom := domain.FromContext(r.Context())
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		link, err := urlGetter.GetURL(r.Context(), dom.Name, alias)
+		if err != nil {
+			switch {
+			case errors.Is(err, storage.ErrURLNotFound):
+				log.Info("url not found", slog.String("alias", alias))
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("not found"))
+			case errors.Is(err, storage.ErrURLExpired):
+				log.Info("url expired", slog.String("alias", alias))
+				render.Status(r, http.StatusGone)
+				render.JSON(w, r, resp.Error("url expired"))
+			case errors.Is(err, storage.ErrURLExhausted):
+				log.Info("url exhausted", slog.String("alias", alias))
+				render.Status(r, http.StatusGone)
+				render.JSON(w, r, resp.Error("url exhausted"))
+			default:
+				log.Error("failed to get url", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("internal error"))
+			}
+
+			return
+		}
+
+		res := Response{
+			Response:  resp.OK(),
+			Alias:     link.Alias,
+			Protected: link.PasswordHash != "",
+			ExpiresAt: link.ExpiresAt,
+		}
+		if !res.Protected {
+			res.URL = link.URL
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+
+		if strings.Contains(r.Header.Get("Accept"), "application/json") {
+			render.JSON(w, r, res)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := pageTemplate.Execute(w, page{Response: res, Link: strings.TrimSuffix(r.URL.Path, "+")}); err != nil {
+			log.Error("failed to render preview", sl.Err(err))
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package preview_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/preview"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/preview/mocks"
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewHandler(t *testing.T) {
+	testCases := []struct {
+		name      string
+		accept    string
+		link      storage.URL
+		mockError error
+		code      int
+		respError string
+		contains  []string
+		excludes  []string
+	}{
+		{
+			name:     "page",
+			accept:   "text/html",
+			link:     storage.URL{Alias: "alias", URL: "https://go.dev/?q=<b>"},
+			code:     http.StatusOK,
+			contains: []string{"https://go.dev/?q=&lt;b&gt;", `href="/alias"`},
+		},
+		{
+			name:     "protected page",
+			link:     storage.URL{Alias: "alias", URL: "https://go.dev/secret", PasswordHash: "hash"},
+			code:     http.StatusOK,
+			contains: []string{"protected by a password"},
+			excludes: []string{"https://go.dev/secret"},
+		},
+		{
+			name:   "json",
+			accept: "application/json",
+			link:   storage.URL{Alias: "alias", URL: "https://go.dev"},
+			code:   http.StatusOK,
+		},
+		{
+			name:      "not found",
+			mockError: storage.ErrURLNotFound,
+			code:      http.StatusNotFound,
+			respError: "not found",
+		},
+		{
+			name:      "exhausted",
+			mockError: storage.ErrURLExhausted,
+			code:      http.StatusGone,
+			respError: "url exhausted",
+		},
+		{
+			name:      "storage error",
+			mockError: errors.New("some error"),
+			code:      http.StatusInternalServerError,
+			respError: "internal error",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", mock.Anything, "", "alias").Return(tc.link, tc.mockError).Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", func(w http.ResponseWriter, _ *http.Request) {
+				t.Error("preview must not be routed to the redirect")
+			})
+			r.Get("/{alias}+", preview.New(slogdiscard.NewDiscardLogger(), urlGetterMock))
+
+			req := httptest.NewRequest(http.MethodGet, "/alias+", nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept", tc.accept)
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.code, rr.Code)
+			assert.Empty(t, rr.Header().Get("Location"))
+
+			if tc.respError != "" || tc.accept == "application/json" {
+				var resp preview.Response
+				require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+				assert.Equal(t, tc.respError, resp.Error)
+				assert.Equal(t, tc.link.URL, resp.URL)
+
+				return
+			}
+
+			assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+			for _, s := range tc.contains {
+				assert.Contains(t, rr.Body.String(), s)
+			}
+			for _, s := range tc.excludes {
+				assert.NotContains(t, rr.Body.String(), s)
+			}
+		})
+	}
+}
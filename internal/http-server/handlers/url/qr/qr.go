@@ -0,0 +1,220 @@
+package qr
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/color"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/domain"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/qrcode"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"rsc.io/qr"
+)
+
+const (
+	defaultMargin = 4
+	maxMargin     = 16
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=URLInfoGetter
+type URLInfoGetter interface {
+	GetURLInfo(ctx context.Context, domain, alias string) (storage.URL, error)
+}
+
+// LinkBuilder builds the public link of an alias, see shorturl.Builder.
+type LinkBuilder interface {
+	URL(r *http.Request, domain, alias string) string
+}
+
+// New renders a QR code of the short link of an alias. Optional query
+// parameters: format (png or svg, png by default), size in pixels, level
+// (error correction: L, M, Q or H, M by default), margin in modules (4 by
+// default) and fg and bg colors (RRGGBB or RRGGBBAA). The image only depends
+// on these and the short link, so it is served with an ETag that clients can
+// revalidate. Only the owner of the link or an admin may get it.
+func New(log *slog.Logger, urlInfoGetter URLInfoGetter, links LinkBuilder, cfg config.QR) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.qr.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		dom := domain.FromContext(r.Context())
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			log.Info("unauthorized request: no user in context")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("unauthorized"))
+
+			return
+		}
+
+		format, opts, err := parseQuery(r, cfg)
+		if err != nil {
+			log.Info("invalid qr query", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(err.Error()))
+
+			return
+		}
+
+		link, err := urlInfoGetter.GetURLInfo(r.Context(), dom.Name, alias)
+		if err != nil {
+			if errors.Is(err, storage.ErrURLNotFound) {
+				log.Info("url not found", slog.String("alias", alias))
+				render.Status(r, http.StatusNotFound)
+				render.JSON(w, r, resp.Error("not found"))
+			} else {
+				log.Error("failed to get url", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("internal error"))
+			}
+
+			return
+		}
+
+		if !user.CanManage(link.OwnerID) {
+			log.Info("forbidden: not an owner", slog.Int64("uid", user.ID), slog.Int64("owner_id", link.OwnerID))
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.Error("forbidden"))
+
+			return
+		}
+
+		text := links.URL(r, link.Domain, link.Alias)
+		tag := etag(text, format, opts)
+
+		w.Header().Set("ETag", tag)
+		w.Header().Set("Cache-Control", "private, no-cache")
+
+		if matches(r.Header.Get("If-None-Match"), tag) {
+			w.WriteHeader(http.StatusNotModified)
+
+			return
+		}
+
+		var buf bytes.Buffer
+		if err := qrcode.Encode(&buf, text, format, opts); err != nil {
+			if errors.Is(err, qrcode.ErrTooSmall) {
+				log.Info("qr size is too small", slog.Int("size", opts.Size))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error(err.Error()))
+			} else {
+				log.Error("failed to encode qr code", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("internal error"))
+			}
+
+			return
+		}
+
+		w.Header().Set("Content-Type", format.ContentType())
+		w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+		_, _ = w.Write(buf.Bytes())
+	}
+}
+
+func parseQuery(r *http.Request, cfg config.QR) (qrcode.Format, qrcode.Options, error) {
+	values := r.URL.Query()
+
+	format := qrcode.FormatPNG
+	opts := qrcode.Options{
+		Size:       cfg.Size,
+		Level:      qr.M,
+		Margin:     defaultMargin,
+		Foreground: color.NRGBA{A: 0xff},
+		Background: color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff},
+	}
+
+	var err error
+
+	if raw := values.Get("format"); raw != "" {
+		if format, err = qrcode.ParseFormat(raw); err != nil {
+			return format, opts, errors.New("format must be png or svg")
+		}
+	}
+
+	if raw := values.Get("size"); raw != "" {
+		size, err := strconv.Atoi(raw)
+		if err != nil || size <= 0 || size > cfg.MaxSize {
+			return format, opts, fmt.Errorf("size must be between 1 and %d", cfg.MaxSize)
+		}
+		opts.Size = size
+	}
+
+	if raw := values.Get("level"); raw != "" {
+		if opts.Level, err = qrcode.ParseLevel(raw); err != nil {
+			return format, opts, errors.New("level must be L, M, Q or H")
+		}
+	}
+
+	if raw := values.Get("margin"); raw != "" {
+		margin, err := strconv.Atoi(raw)
+		if err != nil || margin < 0 || margin > maxMargin {
+			return format, opts, fmt.Errorf("margin must be between 0 and %d", maxMargin)
+		}
+		opts.Margin = margin
+	}
+
+	if raw := values.Get("fg"); raw != "" {
+		if opts.Foreground, err = qrcode.ParseColor(raw); err != nil {
+			return format, opts, errors.New("fg must be a hex color")
+		}
+	}
+
+	if raw := values.Get("bg"); raw != "" {
+		if opts.Background, err = qrcode.ParseColor(raw); err != nil {
+			return format, opts, errors.New("bg must be a hex color")
+		}
+	}
+
+	return format, opts, nil
+}
+
+// etag identifies the image rendered for text with the given options.
+func etag(text string, format qrcode.Format, opts qrcode.Options) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\n%s\n%d\n%d\n%d\n%v\n%v", text, format, opts.Size, opts.Level, opts.Margin, opts.Foreground, opts.Background)
+
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// matches reports whether the If-None-Match header lists tag.
+func matches(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == tag || candidate == "*" {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,180 @@
+package qr_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/qr"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/qr/mocks"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/lib/shorturl"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQRHandler(t *testing.T) {
+	owner := auth.User{ID: 1}
+	cfg := config.QR{Size: 256, MaxSize: 1024}
+
+	testCases := []struct {
+		name        string
+		query       string
+		user        auth.User
+		infoError   error
+		contentType string
+		respError   string
+		code        int
+	}{
+		{
+			name:        "png by default",
+			user:        owner,
+			contentType: "image/png",
+			code:        http.StatusOK,
+		},
+		{
+			name:        "svg by admin",
+			query:       "?format=svg&level=H&margin=0&fg=%23336699&bg=ffffff00",
+			user:        auth.User{ID: 2, IsAdmin: true},
+			contentType: "image/svg+xml",
+			code:        http.StatusOK,
+		},
+		{
+			name:      "unknown format",
+			query:     "?format=gif",
+			user:      owner,
+			respError: "format must be png or svg",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "size too large",
+			query:     "?size=4096",
+			user:      owner,
+			respError: "size must be between 1 and 1024",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "size too small for the code",
+			query:     "?size=10",
+			user:      owner,
+			respError: "size is too small for the code",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "invalid color",
+			query:     "?fg=red",
+			user:      owner,
+			respError: "fg must be a hex color",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "not an owner",
+			user:      auth.User{ID: 2},
+			respError: "forbidden",
+			code:      http.StatusForbidden,
+		},
+		{
+			name:      "not found",
+			user:      owner,
+			infoError: storage.ErrURLNotFound,
+			respError: "not found",
+			code:      http.StatusNotFound,
+		},
+		{
+			name:      "storage error",
+			user:      owner,
+			infoError: errors.New("some error"),
+			respError: "internal error",
+			code:      http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlInfoGetterMock := mocks.NewURLInfoGetter(t)
+
+			if tc.respError == "" || !strings.Contains(tc.respError, " must be ") {
+				urlInfoGetterMock.On("GetURLInfo", mock.Anything, "", "test_alias").
+					Return(storage.URL{Alias: "test_alias", OwnerID: owner.ID}, tc.infoError).
+					Once()
+			}
+
+			shortLinks, err := shorturl.New("https://sho.rt", nil)
+			require.NoError(t, err)
+
+			r := chi.NewRouter()
+			r.Get("/{alias}/qr", qr.New(slogdiscard.NewDiscardLogger(), urlInfoGetterMock, shortLinks, cfg))
+
+			req, err := http.NewRequest(http.MethodGet, "/test_alias/qr"+tc.query, nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), tc.user))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.code, rr.Code)
+
+			if tc.respError != "" {
+				var resp response.Response
+				require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+				assert.Equal(t, tc.respError, resp.Error)
+
+				return
+			}
+
+			assert.Equal(t, tc.contentType, rr.Header().Get("Content-Type"))
+			assert.NotEmpty(t, rr.Header().Get("ETag"))
+			assert.NotZero(t, rr.Body.Len())
+		})
+	}
+}
+
+func TestQRHandler_NotModified(t *testing.T) {
+	urlInfoGetterMock := mocks.NewURLInfoGetter(t)
+	urlInfoGetterMock.On("GetURLInfo", mock.Anything, "", "test_alias").
+		Return(storage.URL{Alias: "test_alias", OwnerID: 1}, nil)
+
+	shortLinks, err := shorturl.New("https://sho.rt", nil)
+	require.NoError(t, err)
+
+	r := chi.NewRouter()
+	r.Get("/{alias}/qr", qr.New(slogdiscard.NewDiscardLogger(), urlInfoGetterMock, shortLinks, config.QR{Size: 256, MaxSize: 1024}))
+
+	get := func(query, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/test_alias/qr"+query, nil)
+		req = req.WithContext(auth.WithUser(req.Context(), auth.User{ID: 1}))
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+
+		return rr
+	}
+
+	first := get("", "")
+	require.Equal(t, http.StatusOK, first.Code)
+	tag := first.Header().Get("ETag")
+
+	rr := get("", tag)
+	assert.Equal(t, http.StatusNotModified, rr.Code)
+	assert.Zero(t, rr.Body.Len())
+
+	rr = get("?size=512", tag)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotEqual(t, tag, rr.Header().Get("ETag"))
+}
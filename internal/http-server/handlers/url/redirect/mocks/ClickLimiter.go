@@ -0,0 +1,46 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ClickLimiter is an autogenerated mock type for the ClickLimiter type
+type ClickLimiter struct {
+	mock.Mock
+}
+
+// UseClick provides a mock function with given fields: ctx, domain, alias
+func (_m *ClickLimiter) UseClick(ctx context.Context, domain string, alias string) error {
+	ret := _m.Called(ctx, domain, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseClick")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, domain, alias)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewClickLimiter creates a new instance of ClickLimiter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewClickLimiter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ClickLimiter {
+	mock := &ClickLimiter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
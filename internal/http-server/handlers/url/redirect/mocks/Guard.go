@@ -0,0 +1,72 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	http "net/http"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "github.com/Braendie/url-shortener/internal/storage"
+)
+
+// Guard is an autogenerated mock type for the Guard type
+type Guard struct {
+	mock.Mock
+}
+
+// Check provides a mock function with given fields: ctx, link, password
+func (_m *Guard) Check(ctx context.Context, link storage.URL, password string) error {
+	ret := _m.Called(ctx, link, password)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Check")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.URL, string) error); ok {
+		r0 = rf(ctx, link, password)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SetCookie provides a mock function with given fields: w, r, link
+func (_m *Guard) SetCookie(w http.ResponseWriter, r *http.Request, link storage.URL) {
+	_m.Called(w, r, link)
+}
+
+// Unlocked provides a mock function with given fields: r, link
+func (_m *Guard) Unlocked(r *http.Request, link storage.URL) bool {
+	ret := _m.Called(r, link)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Unlocked")
+	}
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(*http.Request, storage.URL) bool); ok {
+		r0 = rf(r, link)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
+// NewGuard creates a new instance of Guard. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewGuard(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *Guard {
+	mock := &Guard{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,103 @@
+package redirect
+
+import (
+	"errors"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/password"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/render"
+)
+
+const (
+	// PasswordHeader carries the password of a protected link for API clients.
+	// Browsers post the form with the PasswordParam field. The query string is
+	// never read, it ends up in access logs and browser history.
+	PasswordHeader = "X-Link-Password"
+	PasswordParam  = "password"
+
+	maxFormSize = 4 << 10
+)
+
+var formTemplate = template.Must(template.New("form").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>Protected link</title>
+</head>
+<body>
+<form method="post">
+<p>This link is protected by a password.</p>
+{{if .}}<p>{{.}}</p>{{end}}
+<input type="password" name="password" autofocus required>
+<button type="submit">Continue</button>
+</form>
+</body>
+</html>
+`))
+
+// unlock checks the password sent with the request and lets the client follow
+// the link without it until the cookie expires. Otherwise it responds with the
+// password form to browsers or an error to API clients and returns false.
+func unlock(w http.ResponseWriter, r *http.Request, log *slog.Logger, guard Guard, link storage.URL) bool {
+	pass := passwordFromRequest(w, r)
+	if pass == "" {
+		log.Info("password required")
+		respondLocked(w, r, http.StatusUnauthorized, "password required", "")
+
+		return false
+	}
+
+	err := guard.Check(r.Context(), link, pass)
+	switch {
+	case errors.Is(err, password.ErrTooManyAttempts):
+		log.Info("too many password attempts")
+		respondLocked(w, r, http.StatusTooManyRequests, "too many attempts", "Too many attempts, try again later.")
+
+		return false
+	case err != nil:
+		log.Info("invalid password", sl.Err(err))
+		respondLocked(w, r, http.StatusUnauthorized, "invalid password", "Wrong password.")
+
+		return false
+	}
+
+	guard.SetCookie(w, r, link)
+
+	return true
+}
+
+func passwordFromRequest(w http.ResponseWriter, r *http.Request) string {
+	if pass := r.Header.Get(PasswordHeader); pass != "" {
+		return pass
+	}
+
+	if r.Method != http.MethodPost {
+		return ""
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxFormSize)
+
+	return r.PostFormValue(PasswordParam)
+}
+
+func respondLocked(w http.ResponseWriter, r *http.Request, code int, msg, formMsg string) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if !strings.Contains(r.Header.Get("Accept"), "text/html") {
+		render.Status(r, code)
+		render.JSON(w, r, resp.Error(msg))
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	_ = formTemplate.Execute(w, formMsg)
+}
@@ -1,12 +1,19 @@
 package redirect
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
+	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/domain"
 	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
 	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
 	"github.com/Braendie/url-shortener/internal/storage"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
@@ -15,18 +22,62 @@ import (
 
 //go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=URLGetter
 type URLGetter interface {
-	GetURL(alias string) (string, error)
+	GetURL(ctx context.Context, domain, alias string) (storage.URL, error)
 }
 
-func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
+// ClickLimiter takes clicks from the limit of links with max clicks.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=ClickLimiter
+type ClickLimiter interface {
+	UseClick(ctx context.Context, domain, alias string) error
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=ClickRecorder
+type ClickRecorder interface {
+	RecordClick(click storage.Click)
+}
+
+// Guard unlocks password-protected links, see password.Guard.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=Guard
+type Guard interface {
+	Check(ctx context.Context, link storage.URL, password string) error
+	Unlocked(r *http.Request, link storage.URL) bool
+	SetCookie(w http.ResponseWriter, r *http.Request, link storage.URL)
+}
+
+// New redirects to the URL of the alias on the domain of the request with the
+// status of the link, else of the domain, else cfg.Code. Unknown aliases of a
+// domain with a fallback URL are redirected there. Permanent redirects may be cached by clients for
+// cfg.MaxAge, but not past the expiration of the link; temporary ones are not
+// cached so that every click reaches the service. HEAD requests get the same
+// response without recording a click.
+//
+// Password-protected links are only followed once the client has unlocked
+// them, see unlock; they are never cached. Links with max clicks are not
+// cached either, and answer 410 Gone once they are exhausted.
+//
+// Clicks are recorded with the address clientIP resolves for the request,
+// see shorturl.Builder.ClientIP.
+func New(
+	log *slog.Logger,
+	urlGetter URLGetter,
+	clickLimiter ClickLimiter,
+	clickRecorder ClickRecorder,
+	guard Guard,
+	clientIP func(r *http.Request) string,
+	cfg config.Redirect,
+) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.redirect.New"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
 		)
 
+		dom := domain.FromContext(r.Context())
 		alias := chi.URLParam(r, "alias")
 		if alias == "" {
 			log.Info("alias is empty")
@@ -36,12 +87,24 @@ func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
 			return
 		}
 
-		resURL, err := urlGetter.GetURL(alias)
+		link, err := urlGetter.GetURL(r.Context(), dom.Name, alias)
 		if err != nil {
-			if errors.Is(err, storage.ErrURLNotFound) {
+			if errors.Is(err, storage.ErrURLNotFound) && dom.FallbackURL != "" {
+				log.Info("url not found, redirecting to fallback", slog.String("alias", alias))
+				w.Header().Set("Cache-Control", "no-store")
+				http.Redirect(w, r, dom.FallbackURL, http.StatusFound)
+			} else if errors.Is(err, storage.ErrURLNotFound) {
 				log.Info("url not found", "alias:", alias)
 				render.Status(r, http.StatusNotFound)
 				render.JSON(w, r, resp.Error("not found"))
+			} else if errors.Is(err, storage.ErrURLExpired) {
+				log.Info("url expired", slog.String("alias", alias))
+				render.Status(r, http.StatusGone)
+				render.JSON(w, r, resp.Error("url expired"))
+			} else if errors.Is(err, storage.ErrURLExhausted) {
+				log.Info("url exhausted", slog.String("alias", alias))
+				render.Status(r, http.StatusGone)
+				render.JSON(w, r, resp.Error("url exhausted"))
 			} else {
 				log.Error("failed to get url", sl.Err(err))
 				render.Status(r, http.StatusInternalServerError)
@@ -51,7 +114,105 @@ func New(log *slog.Logger, urlGetter URLGetter) http.HandlerFunc {
 			return
 		}
 
-		log.Info("got url", slog.String("url", resURL))
-		http.Redirect(w, r, resURL, http.StatusFound)
+		log.Info("got url", slog.String("url", link.URL))
+
+		protected := link.PasswordHash != ""
+		if protected && !guard.Unlocked(r, link) && !unlock(w, r, log, guard, link) {
+			return
+		}
+
+		limited := link.MaxClicks > 0
+		if limited && r.Method != http.MethodHead {
+			if err := clickLimiter.UseClick(r.Context(), dom.Name, alias); err != nil {
+				if errors.Is(err, storage.ErrURLExhausted) {
+					log.Info("url exhausted", slog.String("alias", alias))
+					render.Status(r, http.StatusGone)
+					render.JSON(w, r, resp.Error("url exhausted"))
+				} else if errors.Is(err, storage.ErrURLNotFound) {
+					log.Info("url not found", slog.String("alias", alias))
+					render.Status(r, http.StatusNotFound)
+					render.JSON(w, r, resp.Error("not found"))
+				} else {
+					log.Error("failed to use click", sl.Err(err))
+					render.Status(r, http.StatusInternalServerError)
+					render.JSON(w, r, resp.Error("internal error"))
+				}
+
+				return
+			}
+		}
+
+		now := time.Now()
+
+		if r.Method != http.MethodHead {
+			clickRecorder.RecordClick(storage.Click{
+				Domain:    dom.Name,
+				Alias:     alias,
+				ClickedAt: now,
+				Referrer:  r.Referer(),
+				UserAgent: r.UserAgent(),
+				ClientIP:  clientIP(r),
+				RequestID: middleware.GetReqID(r.Context()),
+			})
+		}
+
+		code := link.RedirectCode
+		if code == 0 {
+			code = dom.RedirectCode
+		}
+		if code == 0 {
+			code = cfg.Code
+		}
+
+		target := link.URL
+		if link.ForwardQuery {
+			target = forwardQuery(target, r.URL.RawQuery)
+		}
+
+		cache := cacheControl(code, link.ExpiresAt, cfg.MaxAge, now)
+		if protected || limited {
+			cache = "no-store"
+		}
+		// The password form must not be sent on to the target.
+		if r.Method == http.MethodPost {
+			code = http.StatusSeeOther
+		}
+
+		w.Header().Set("Cache-Control", cache)
+		http.Redirect(w, r, target, code)
+	}
+}
+
+// cacheControl lets clients cache permanent redirects for maxAge, but not
+// past the expiration of the link.
+func cacheControl(code int, expiresAt *time.Time, maxAge time.Duration, now time.Time) string {
+	if code != http.StatusMovedPermanently && code != http.StatusPermanentRedirect {
+		return "no-store"
+	}
+
+	if expiresAt != nil {
+		maxAge = min(maxAge, expiresAt.Sub(now))
 	}
+
+	return "public, max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+}
+
+// forwardQuery appends query to the query string of target.
+func forwardQuery(target, query string) string {
+	if query == "" {
+		return target
+	}
+
+	u, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+
+	if u.RawQuery == "" {
+		u.RawQuery = query
+	} else {
+		u.RawQuery += "&" + query
+	}
+
+	return u.String()
 }
@@ -1,21 +1,42 @@
 package redirect_test
 
 import (
+	"encoding/json"
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Braendie/url-shortener/internal/config"
 	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/redirect"
 	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/redirect/mocks"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/domain"
 	"github.com/Braendie/url-shortener/internal/lib/api"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
 	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/lib/password"
+	"github.com/Braendie/url-shortener/internal/lib/shorturl"
 	"github.com/Braendie/url-shortener/internal/storage"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-playground/assert/v2"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 )
 
+var redirectConfig = config.Redirect{Code: http.StatusFound, MaxAge: time.Hour}
+
+// clientIP trusts X-Forwarded-For from 192.0.2.1 only.
+var clientIP = func() func(*http.Request) string {
+	links, err := shorturl.New("", []string{"192.0.2.1"})
+	if err != nil {
+		panic(err)
+	}
+
+	return links.ClientIP
+}()
+
 func TestRedirectHandler(t *testing.T) {
 	testCases := []struct {
 		name      string
@@ -44,6 +65,20 @@ func TestRedirectHandler(t *testing.T) {
 			mockError: storage.ErrURLNotFound,
 			code:      http.StatusNotFound,
 		},
+		{
+			name:      "expired",
+			alias:     "some_alias",
+			respError: "url expired",
+			mockError: storage.ErrURLExpired,
+			code:      http.StatusGone,
+		},
+		{
+			name:      "exhausted",
+			alias:     "some_alias",
+			respError: "url exhausted",
+			mockError: storage.ErrURLExhausted,
+			code:      http.StatusGone,
+		},
 		{
 			name:      "storage error",
 			alias:     "some_alias",
@@ -60,14 +95,23 @@ func TestRedirectHandler(t *testing.T) {
 			t.Parallel()
 
 			urlGetterMock := mocks.NewURLGetter(t)
+			clickRecorderMock := mocks.NewClickRecorder(t)
 
 			if tc.respError == "" || tc.mockError != nil {
-				urlGetterMock.On("GetURL", tc.alias).
-					Return(tc.url, tc.mockError).Once()
+				urlGetterMock.On("GetURL", mock.Anything, "", tc.alias).
+					Return(storage.URL{URL: tc.url}, tc.mockError).Once()
+			}
+
+			if tc.respError == "" {
+				clickRecorderMock.On("RecordClick", mock.MatchedBy(func(c storage.Click) bool {
+					return c.Alias == tc.alias && c.ClientIP == "127.0.0.1"
+				})).Once()
 			}
 
 			r := chi.NewRouter()
-			r.Get("/{alias}", redirect.New(slogdiscard.NewDiscardLogger(), urlGetterMock))
+			r.Get("/{alias}", redirect.New(
+				slogdiscard.NewDiscardLogger(), urlGetterMock, mocks.NewClickLimiter(t), clickRecorderMock, mocks.NewGuard(t), clientIP, redirectConfig,
+			))
 
 			ts := httptest.NewServer(r)
 			defer ts.Close()
@@ -90,3 +134,481 @@ func TestRedirectHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestRedirectHandler_ClientIP(t *testing.T) {
+	cases := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{name: "direct", remoteAddr: "203.0.113.9:1234", want: "203.0.113.9"},
+		{name: "trusted proxy", remoteAddr: "192.0.2.1:1234", forwarded: "203.0.113.9", want: "203.0.113.9"},
+		{name: "untrusted proxy", remoteAddr: "198.51.100.7:1234", forwarded: "203.0.113.9", want: "198.51.100.7"},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", mock.Anything, "", "alias").Return(storage.URL{URL: "https://google.com"}, nil).Once()
+
+			clickRecorderMock := mocks.NewClickRecorder(t)
+			clickRecorderMock.On("RecordClick", mock.MatchedBy(func(c storage.Click) bool {
+				return c.ClientIP == tc.want
+			})).Once()
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(
+				slogdiscard.NewDiscardLogger(), urlGetterMock, mocks.NewClickLimiter(t), clickRecorderMock, mocks.NewGuard(t), clientIP,
+				redirectConfig,
+			))
+
+			req := httptest.NewRequest(http.MethodGet, "/alias", nil)
+			req.RemoteAddr = tc.remoteAddr
+			if tc.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tc.forwarded)
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusFound, rr.Code)
+		})
+	}
+}
+
+func TestRedirectHandler_Behavior(t *testing.T) {
+	// Half a second of slack, the remaining lifetime is truncated to seconds.
+	soon := time.Now().Add(10*time.Minute + 500*time.Millisecond)
+
+	testCases := []struct {
+		name         string
+		method       string
+		query        string
+		link         storage.URL
+		code         int
+		location     string
+		cacheControl string
+	}{
+		{
+			name:         "default code",
+			link:         storage.URL{URL: "https://go.dev"},
+			code:         http.StatusFound,
+			location:     "https://go.dev",
+			cacheControl: "no-store",
+		},
+		{
+			name:         "temporary",
+			link:         storage.URL{URL: "https://go.dev", RedirectCode: http.StatusTemporaryRedirect},
+			code:         http.StatusTemporaryRedirect,
+			location:     "https://go.dev",
+			cacheControl: "no-store",
+		},
+		{
+			name:         "permanent",
+			link:         storage.URL{URL: "https://go.dev", RedirectCode: http.StatusMovedPermanently},
+			code:         http.StatusMovedPermanently,
+			location:     "https://go.dev",
+			cacheControl: "public, max-age=3600",
+		},
+		{
+			name:         "permanent expiring",
+			link:         storage.URL{URL: "https://go.dev", RedirectCode: http.StatusPermanentRedirect, ExpiresAt: &soon},
+			code:         http.StatusPermanentRedirect,
+			location:     "https://go.dev",
+			cacheControl: "public, max-age=600",
+		},
+		{
+			name:         "query dropped",
+			query:        "utm_source=mail",
+			link:         storage.URL{URL: "https://go.dev/doc"},
+			code:         http.StatusFound,
+			location:     "https://go.dev/doc",
+			cacheControl: "no-store",
+		},
+		{
+			name:         "query forwarded",
+			query:        "utm_source=mail",
+			link:         storage.URL{URL: "https://go.dev/doc", ForwardQuery: true},
+			code:         http.StatusFound,
+			location:     "https://go.dev/doc?utm_source=mail",
+			cacheControl: "no-store",
+		},
+		{
+			name:         "query merged",
+			query:        "utm_source=mail",
+			link:         storage.URL{URL: "https://go.dev/doc?lang=en#top", ForwardQuery: true},
+			code:         http.StatusFound,
+			location:     "https://go.dev/doc?lang=en&utm_source=mail#top",
+			cacheControl: "no-store",
+		},
+		{
+			name:         "head",
+			method:       http.MethodHead,
+			link:         storage.URL{URL: "https://go.dev"},
+			code:         http.StatusFound,
+			location:     "https://go.dev",
+			cacheControl: "no-store",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", mock.Anything, "", "alias").Return(tc.link, nil).Once()
+
+			clickRecorderMock := mocks.NewClickRecorder(t)
+			if tc.method != http.MethodHead {
+				clickRecorderMock.On("RecordClick", mock.Anything).Once()
+			}
+
+			h := redirect.New(
+				slogdiscard.NewDiscardLogger(), urlGetterMock, mocks.NewClickLimiter(t), clickRecorderMock, mocks.NewGuard(t), clientIP,
+				redirectConfig,
+			)
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			r := chi.NewRouter()
+			r.Method(method, "/{alias}", h)
+
+			target := "/alias"
+			if tc.query != "" {
+				target += "?" + tc.query
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(method, target, nil))
+
+			assert.Equal(t, tc.code, rr.Code)
+			assert.Equal(t, tc.location, rr.Header().Get("Location"))
+			assert.Equal(t, tc.cacheControl, rr.Header().Get("Cache-Control"))
+		})
+	}
+}
+
+func TestRedirectHandler_Protected(t *testing.T) {
+	link := storage.URL{Alias: "alias", URL: "https://go.dev", PasswordHash: "hash"}
+
+	testCases := []struct {
+		name      string
+		method    string
+		target    string
+		header    http.Header
+		body      string
+		link      storage.URL
+		unlocked  bool
+		password  string
+		checkErr  error
+		code      int
+		location  string
+		respError string
+		form      bool
+	}{
+		{
+			name:      "locked",
+			link:      link,
+			code:      http.StatusUnauthorized,
+			respError: "password required",
+		},
+		{
+			name:   "locked in browser",
+			header: http.Header{"Accept": {"text/html,application/xhtml+xml"}},
+			link:   link,
+			code:   http.StatusUnauthorized,
+			form:   true,
+		},
+		{
+			name:     "unlocked by cookie",
+			link:     link,
+			unlocked: true,
+			code:     http.StatusFound,
+			location: "https://go.dev",
+		},
+		{
+			name:     "password header",
+			header:   http.Header{redirect.PasswordHeader: {"secret"}},
+			link:     link,
+			password: "secret",
+			code:     http.StatusFound,
+			location: "https://go.dev",
+		},
+		{
+			name:      "password query is ignored",
+			target:    "/alias?password=secret",
+			link:      link,
+			code:      http.StatusUnauthorized,
+			respError: "password required",
+		},
+		{
+			name:      "password query is ignored on post",
+			method:    http.MethodPost,
+			target:    "/alias?password=secret",
+			header:    http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+			link:      link,
+			code:      http.StatusUnauthorized,
+			respError: "password required",
+		},
+		{
+			name:     "form",
+			method:   http.MethodPost,
+			header:   http.Header{"Content-Type": {"application/x-www-form-urlencoded"}},
+			body:     "password=secret",
+			link:     storage.URL{Alias: "alias", URL: "https://go.dev", PasswordHash: "hash", RedirectCode: 308},
+			password: "secret",
+			code:     http.StatusSeeOther,
+			location: "https://go.dev",
+		},
+		{
+			name:      "wrong password",
+			header:    http.Header{redirect.PasswordHeader: {"wrong"}},
+			link:      link,
+			password:  "wrong",
+			checkErr:  password.ErrInvalidPassword,
+			code:      http.StatusUnauthorized,
+			respError: "invalid password",
+		},
+		{
+			name:      "too many attempts",
+			header:    http.Header{redirect.PasswordHeader: {"secret"}},
+			link:      link,
+			password:  "secret",
+			checkErr:  password.ErrTooManyAttempts,
+			code:      http.StatusTooManyRequests,
+			respError: "too many attempts",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", mock.Anything, "", "alias").Return(tc.link, nil).Once()
+
+			guardMock := mocks.NewGuard(t)
+			guardMock.On("Unlocked", mock.Anything, tc.link).Return(tc.unlocked).Once()
+			if tc.password != "" {
+				guardMock.On("Check", mock.Anything, tc.link, tc.password).Return(tc.checkErr).Once()
+			}
+			if tc.password != "" && tc.checkErr == nil {
+				guardMock.On("SetCookie", mock.Anything, mock.Anything, tc.link).Once()
+			}
+
+			clickRecorderMock := mocks.NewClickRecorder(t)
+			if tc.location != "" {
+				clickRecorderMock.On("RecordClick", mock.Anything).Once()
+			}
+
+			h := redirect.New(
+				slogdiscard.NewDiscardLogger(), urlGetterMock, mocks.NewClickLimiter(t), clickRecorderMock, guardMock, clientIP,
+				redirectConfig,
+			)
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			target := tc.target
+			if target == "" {
+				target = "/alias"
+			}
+
+			r := chi.NewRouter()
+			r.Method(method, "/{alias}", h)
+
+			req := httptest.NewRequest(method, target, strings.NewReader(tc.body))
+			for name, values := range tc.header {
+				req.Header[name] = values
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.code, rr.Code)
+			assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+			assert.Equal(t, tc.location, rr.Header().Get("Location"))
+
+			switch {
+			case tc.form:
+				assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+				assert.Equal(t, true, strings.Contains(rr.Body.String(), `<form method="post">`))
+			case tc.respError != "":
+				var body resp.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+				assert.Equal(t, tc.respError, body.Error)
+			}
+		})
+	}
+}
+
+func TestRedirectHandler_MaxClicks(t *testing.T) {
+	link := storage.URL{Alias: "alias", URL: "https://go.dev", MaxClicks: 1}
+
+	testCases := []struct {
+		name      string
+		method    string
+		useErr    error
+		code      int
+		respError string
+	}{
+		{
+			name: "click left",
+			code: http.StatusFound,
+		},
+		{
+			name:   "head does not use a click",
+			method: http.MethodHead,
+			code:   http.StatusFound,
+		},
+		{
+			name:      "exhausted concurrently",
+			useErr:    storage.ErrURLExhausted,
+			code:      http.StatusGone,
+			respError: "url exhausted",
+		},
+		{
+			name:      "deleted concurrently",
+			useErr:    storage.ErrURLNotFound,
+			code:      http.StatusNotFound,
+			respError: "not found",
+		},
+		{
+			name:      "storage error",
+			useErr:    errors.New("some error"),
+			code:      http.StatusInternalServerError,
+			respError: "internal error",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", mock.Anything, "", "alias").Return(link, nil).Once()
+
+			clickLimiterMock := mocks.NewClickLimiter(t)
+			if method != http.MethodHead {
+				clickLimiterMock.On("UseClick", mock.Anything, "", "alias").Return(tc.useErr).Once()
+			}
+
+			clickRecorderMock := mocks.NewClickRecorder(t)
+			if method != http.MethodHead && tc.useErr == nil {
+				clickRecorderMock.On("RecordClick", mock.Anything).Once()
+			}
+
+			h := redirect.New(
+				slogdiscard.NewDiscardLogger(), urlGetterMock, clickLimiterMock, clickRecorderMock, mocks.NewGuard(t), clientIP,
+				redirectConfig,
+			)
+
+			r := chi.NewRouter()
+			r.Method(method, "/{alias}", h)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(method, "/alias", nil))
+
+			assert.Equal(t, tc.code, rr.Code)
+
+			if tc.respError == "" {
+				assert.Equal(t, "https://go.dev", rr.Header().Get("Location"))
+				assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+
+				return
+			}
+
+			var body resp.Response
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			assert.Equal(t, tc.respError, body.Error)
+		})
+	}
+}
+
+func TestRedirectHandler_Domain(t *testing.T) {
+	dom := storage.Domain{
+		Name:         "go.example.org",
+		RedirectCode: http.StatusMovedPermanently,
+		FallbackURL:  "https://example.org/missing",
+	}
+
+	testCases := []struct {
+		name      string
+		link      storage.URL
+		mockError error
+		code      int
+		location  string
+	}{
+		{
+			name:     "domain code",
+			link:     storage.URL{URL: "https://go.dev"},
+			code:     http.StatusMovedPermanently,
+			location: "https://go.dev",
+		},
+		{
+			name:     "link code",
+			link:     storage.URL{URL: "https://go.dev", RedirectCode: http.StatusTemporaryRedirect},
+			code:     http.StatusTemporaryRedirect,
+			location: "https://go.dev",
+		},
+		{
+			name:      "fallback",
+			mockError: storage.ErrURLNotFound,
+			code:      http.StatusFound,
+			location:  "https://example.org/missing",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlGetterMock := mocks.NewURLGetter(t)
+			urlGetterMock.On("GetURL", mock.Anything, dom.Name, "alias").Return(tc.link, tc.mockError).Once()
+
+			clickRecorderMock := mocks.NewClickRecorder(t)
+			if tc.mockError == nil {
+				clickRecorderMock.On("RecordClick", mock.MatchedBy(func(c storage.Click) bool {
+					return c.Domain == dom.Name && c.Alias == "alias"
+				})).Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}", redirect.New(
+				slogdiscard.NewDiscardLogger(), urlGetterMock, mocks.NewClickLimiter(t), clickRecorderMock, mocks.NewGuard(t), clientIP,
+				redirectConfig,
+			))
+
+			req := httptest.NewRequest(http.MethodGet, "/alias", nil)
+			req = req.WithContext(domain.WithDomain(req.Context(), dom))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.code, rr.Code)
+			assert.Equal(t, tc.location, rr.Header().Get("Location"))
+		})
+	}
+}
@@ -1,13 +1,21 @@
 package save
 
 import (
+	"context"
 	"errors"
 	"log/slog"
 	"net/http"
+	"time"
 
+	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/domain"
+	"github.com/Braendie/url-shortener/internal/lib/alias"
 	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/expiry"
 	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
-	"github.com/Braendie/url-shortener/internal/lib/random"
+	"github.com/Braendie/url-shortener/internal/lib/password"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
 	"github.com/Braendie/url-shortener/internal/storage"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
@@ -15,29 +23,91 @@ import (
 )
 
 type Request struct {
-	URL   string `json:"url" validate:"required,url"`
-	Alias string `json:"alias,omitempty"`
+	URL          string     `json:"url" validate:"required,url"`
+	Alias        string     `json:"alias,omitempty" validate:"omitempty,alias"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	TTL          string     `json:"ttl,omitempty" validate:"excluded_with=ExpiresAt"`
+	RedirectCode int        `json:"redirect_code,omitempty" validate:"omitempty,oneof=301 302 307 308"`
+	ForwardQuery bool       `json:"forward_query,omitempty"`
+	// MaxClicks limits how many times the link can be followed, 1 makes it
+	// a one-time link.
+	MaxClicks int64 `json:"max_clicks,omitempty" validate:"omitempty,min=1"`
+	// Password protects the link, bcrypt only uses its first 72 bytes.
+	Password string `json:"password,omitempty" validate:"omitempty,max=72"`
+}
+
+// LogValue hides the password from logs.
+func (r Request) LogValue() slog.Value {
+	type plain Request
+
+	if r.Password != "" {
+		r.Password = "[hidden]"
+	}
+
+	return slog.AnyValue(plain(r))
 }
 
 type Response struct {
 	resp.Response
-	Alias string `json:"alias"`
+	Alias     string     `json:"alias"`
+	ShortURL  string     `json:"short_url"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
 //go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=URLSaver
 type URLSaver interface {
-	SaveURL(urlToSave string, alias string) (int64, error)
+	SaveURL(ctx context.Context, url storage.URL) (int64, error)
+	FindGeneratedURL(ctx context.Context, domain, url string, ownerID int64) (storage.URL, error)
+}
+
+// AliasAllocator finds a free alias for links saved without one, see alias.Allocator.
+type AliasAllocator interface {
+	Allocate(ctx context.Context, length int, save func(alias string) error) (string, error)
+}
+
+// AliasRules validate and normalize custom aliases, see alias.Rules.
+type AliasRules interface {
+	Validator() *validator.Validate
+	Normalize(alias string) string
+}
+
+// LinkBuilder builds the public link of an alias, see shorturl.Builder.
+type LinkBuilder interface {
+	URL(r *http.Request, domain, alias string) string
 }
 
-func New(log *slog.Logger, urlSaver URLSaver, aliasLength int) http.HandlerFunc {
+// New creates a link on the domain of the request. With dedup set to config.DedupOwner or config.DedupGlobal
+// a request without an alias, expiration, password, click limit and redirect
+// options gets the generated alias the URL already has among the caller's
+// links or all of them, if there is one.
+func New(
+	log *slog.Logger,
+	urlSaver URLSaver,
+	aliases AliasAllocator,
+	rules AliasRules,
+	links LinkBuilder,
+	dedup string,
+) http.HandlerFunc {
+	validate := rules.Validator()
+
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.url.save.New"
 
-		log = log.With(
+		log := log.With(
 			slog.String("op", op),
 			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
 		)
 
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			log.Info("unauthorized request: no user in context")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("unauthorized"))
+
+			return
+		}
+
 		var req Request
 
 		err := render.DecodeJSON(r.Body, &req)
@@ -51,7 +121,9 @@ func New(log *slog.Logger, urlSaver URLSaver, aliasLength int) http.HandlerFunc
 
 		log.Info("request body decoded", slog.Any("request", req))
 
-		if err := validator.New().Struct(req); err != nil {
+		req.Alias = rules.Normalize(req.Alias)
+
+		if err := validate.Struct(req); err != nil {
 			validateErr := err.(validator.ValidationErrors)
 
 			log.Error("invalid request", sl.Err(err))
@@ -61,23 +133,86 @@ func New(log *slog.Logger, urlSaver URLSaver, aliasLength int) http.HandlerFunc
 			return
 		}
 
-		alias := req.Alias
-		if alias == "" {
-			alias, err = random.NewRandomString(aliasLength)
-			if err != nil {
-				log.Error("failed to create random alias", sl.Err(err))
+		expiresAt, err := expiry.Resolve(req.TTL, req.ExpiresAt, time.Now())
+		if err != nil {
+			log.Info("invalid expiration", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(err.Error()))
+
+			return
+		}
+
+		dom := domain.FromContext(r.Context())
+
+		u := storage.URL{
+			Domain:       dom.Name,
+			URL:          req.URL,
+			Alias:        req.Alias,
+			OwnerID:      user.ID,
+			ExpiresAt:    expiresAt,
+			RedirectCode: req.RedirectCode,
+			ForwardQuery: req.ForwardQuery,
+			MaxClicks:    req.MaxClicks,
+		}
+
+		if req.Password != "" {
+			if u.PasswordHash, err = password.Hash(req.Password); err != nil {
+				log.Error("failed to hash password", sl.Err(err))
 				render.Status(r, http.StatusInternalServerError)
-				render.JSON(w, r, resp.Error("internal error"))
+				render.JSON(w, r, resp.Error("failed to add url"))
+
+				return
 			}
 		}
 
-		id, err := urlSaver.SaveURL(req.URL, alias)
+		if dedup != config.DedupOff && Reusable(u) {
+			ownerID := user.ID
+			if dedup == config.DedupGlobal {
+				ownerID = 0
+			}
+
+			existing, err := urlSaver.FindGeneratedURL(r.Context(), u.Domain, u.URL, ownerID)
+			switch {
+			case err == nil:
+				log.Info("url already shortened", slog.String("alias", existing.Alias))
+				responseOK(w, r, existing.Alias, links.URL(r, u.Domain, existing.Alias), nil)
+
+				return
+			case !errors.Is(err, storage.ErrURLNotFound):
+				log.Error("failed to find existing url", sl.Err(err))
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("failed to add url"))
+
+				return
+			}
+		}
+
+		var id int64
+
+		if u.Alias != "" {
+			id, err = urlSaver.SaveURL(r.Context(), u)
+		} else {
+			u.Generated = true
+			u.Alias, err = aliases.Allocate(r.Context(), dom.AliasLength, func(generated string) error {
+				u.Alias = generated
+
+				var err error
+				id, err = urlSaver.SaveURL(r.Context(), u)
+
+				return err
+			})
+		}
 		if err != nil {
-			if errors.Is(err, storage.ErrURLExists) {
+			switch {
+			case errors.Is(err, storage.ErrURLExists):
 				log.Info("url already exists", slog.String("url", req.URL))
 				render.Status(r, http.StatusConflict)
 				render.JSON(w, r, resp.Error("url already exists"))
-			} else {
+			case errors.Is(err, alias.ErrNoFreeAlias):
+				log.Error("failed to find a free alias", sl.Err(err))
+				render.Status(r, http.StatusServiceUnavailable)
+				render.JSON(w, r, resp.Error("failed to generate alias"))
+			default:
 				log.Error("failed to add url", sl.Err(err))
 				render.Status(r, http.StatusInternalServerError)
 				render.JSON(w, r, resp.Error("failed to add url"))
@@ -86,13 +221,21 @@ func New(log *slog.Logger, urlSaver URLSaver, aliasLength int) http.HandlerFunc
 		}
 
 		log.Info("url added", slog.Int64("id", id))
-		responseOK(w, r, alias)
+		responseOK(w, r, u.Alias, links.URL(r, u.Domain, u.Alias), expiresAt)
 	}
 }
 
-func responseOK(w http.ResponseWriter, r *http.Request, alias string) {
+// Reusable reports whether an existing generated link can stand in for u.
+func Reusable(u storage.URL) bool {
+	return u.Alias == "" && u.ExpiresAt == nil && u.RedirectCode == 0 && !u.ForwardQuery && u.PasswordHash == "" &&
+		u.MaxClicks == 0
+}
+
+func responseOK(w http.ResponseWriter, r *http.Request, alias, shortURL string, expiresAt *time.Time) {
 	render.JSON(w, r, Response{
-		Response: resp.OK(),
-		Alias:    alias,
+		Response:  resp.OK(),
+		Alias:     alias,
+		ShortURL:  shortURL,
+		ExpiresAt: expiresAt,
 	})
 }
@@ -2,23 +2,62 @@ package save_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/redirect"
+	redirectmocks "github.com/Braendie/url-shortener/internal/http-server/handlers/url/redirect/mocks"
 	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/save"
 	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/save/mocks"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/domain"
+	"github.com/Braendie/url-shortener/internal/lib/alias"
 	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/lib/random"
+	"github.com/Braendie/url-shortener/internal/lib/shorturl"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/Braendie/url-shortener/internal/storage/cache"
+	cachemocks "github.com/Braendie/url-shortener/internal/storage/cache/mocks"
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
 )
 
+func newAllocator() *alias.Allocator {
+	return alias.NewAllocator(alias.Random{Alphabet: random.Base62, Length: 6}, nil, 3, 2)
+}
+
+func newRules() *alias.Rules {
+	rules := alias.NewRules(config.Alias{MinLength: 3, MaxLength: 16})
+	rules.Reserve("url", "healthz")
+
+	return rules
+}
+
+func newLinks() *shorturl.Builder {
+	links, err := shorturl.New("https://sho.rt", nil)
+	if err != nil {
+		panic(err)
+	}
+
+	return links
+}
+
 type Response struct {
-	Alias string `json:"alias"`
+	Alias    string `json:"alias"`
+	ShortURL string `json:"short_url"`
+	Error    string `json:"error"`
 }
 
 func TestSaveHandler(t *testing.T) {
@@ -26,6 +65,7 @@ func TestSaveHandler(t *testing.T) {
 		name      string
 		alias     string
 		url       string
+		extra     string
 		respError string
 		mockError error
 		code      int
@@ -46,7 +86,7 @@ func TestSaveHandler(t *testing.T) {
 			name:      "Empty URL",
 			url:       "",
 			alias:     "some_alias",
-			respError: "field URL is a required field URL",
+			respError: "field URL is a required field",
 			code:      http.StatusBadRequest,
 		},
 		{
@@ -56,6 +96,104 @@ func TestSaveHandler(t *testing.T) {
 			respError: "field URL is not a valid URL",
 			code:      http.StatusBadRequest,
 		},
+		{
+			name:  "With TTL",
+			alias: "ttl_alias",
+			url:   "https://google.com",
+			extra: `, "ttl": "24h"`,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "With expires_at",
+			alias: "expiring_alias",
+			url:   "https://google.com",
+			extra: `, "expires_at": "2999-01-01T00:00:00Z"`,
+			code:  http.StatusOK,
+		},
+		{
+			name:      "Invalid redirect code",
+			alias:     "redirect_alias",
+			url:       "https://google.com",
+			extra:     `, "redirect_code": 303`,
+			respError: "field RedirectCode must be one of 301 302 307 308",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "Negative max clicks",
+			alias:     "one_time",
+			url:       "https://google.com",
+			extra:     `, "max_clicks": -1`,
+			respError: "field MaxClicks must be at least 1",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "Long password",
+			alias:     "protected",
+			url:       "https://google.com",
+			extra:     `, "password": "` + strings.Repeat("p", 73) + `"`,
+			respError: "field Password must be at most 72 characters long",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "Invalid TTL",
+			alias:     "ttl_alias",
+			url:       "https://google.com",
+			extra:     `, "ttl": "tomorrow"`,
+			respError: "field TTL is not a valid duration",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "Negative TTL",
+			alias:     "ttl_alias",
+			url:       "https://google.com",
+			extra:     `, "ttl": "-1h"`,
+			respError: "field TTL must be positive",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "Expires in the past",
+			alias:     "expiring_alias",
+			url:       "https://google.com",
+			extra:     `, "expires_at": "2000-01-01T00:00:00Z"`,
+			respError: "field ExpiresAt must be in the future",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "TTL with expires_at",
+			alias:     "expiring_alias",
+			url:       "https://google.com",
+			extra:     `, "ttl": "1h", "expires_at": "2999-01-01T00:00:00Z"`,
+			respError: "field TTL cannot be used together with ExpiresAt",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "Reserved alias",
+			url:       "https://google.com",
+			alias:     "HealthZ",
+			respError: "field Alias is reserved",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "Alias with slash",
+			url:       "https://google.com",
+			alias:     "a/b/c",
+			respError: "field Alias may only contain latin letters, digits, '-' and '_'",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "Short alias",
+			url:       "https://google.com",
+			alias:     "ab",
+			respError: "field Alias must be at least 3 characters long",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "Long alias",
+			url:       "https://google.com",
+			alias:     "a_very_long_alias_indeed",
+			respError: "field Alias must be at most 16 characters long",
+			code:      http.StatusBadRequest,
+		},
 		{
 			name:      "SaveURL Error",
 			url:       "https://google.com",
@@ -75,17 +213,20 @@ func TestSaveHandler(t *testing.T) {
 			urlSaverMock := mocks.NewURLSaver(t)
 
 			if tc.respError == "" || tc.mockError != nil {
-				urlSaverMock.On("SaveURL", tc.url, mock.AnythingOfType("string")).
+				urlSaverMock.On("SaveURL", mock.Anything, mock.MatchedBy(func(u storage.URL) bool {
+					return u.URL == tc.url && u.OwnerID == 42 && (tc.extra == "") == (u.ExpiresAt == nil)
+				})).
 					Return(int64(1), tc.mockError).
 					Once()
 			}
 
-			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, 6)
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, newAllocator(), newRules(), newLinks(), config.DedupOff)
 
-			input := fmt.Sprintf(`{"url": "%s", "alias": "%s"}`, tc.url, tc.alias)
+			input := fmt.Sprintf(`{"url": "%s", "alias": "%s"%s}`, tc.url, tc.alias, tc.extra)
 
 			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(input)))
 			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), auth.User{ID: 42}))
 
 			rr := httptest.NewRecorder()
 			handler.ServeHTTP(rr, req)
@@ -96,13 +237,316 @@ func TestSaveHandler(t *testing.T) {
 
 			err = json.NewDecoder(rr.Body).Decode(&resp)
 			require.NoError(t, err)
+			if tc.respError != "" {
+				assert.Equal(t, tc.respError, resp.Error)
+			}
 			if tc.respError == "" {
 				if tc.alias != "" {
 					assert.Equal(t, tc.alias, resp.Alias)
 				} else {
 					assert.NotEmpty(t, resp.Alias)
 				}
+				assert.Equal(t, "https://sho.rt/"+resp.Alias, resp.ShortURL)
+			}
+		})
+	}
+}
+
+func TestSaveHandler_AliasCollision(t *testing.T) {
+	cases := []struct {
+		name       string
+		collisions int
+		code       int
+		respError  string
+	}{
+		{name: "retried", collisions: 2, code: http.StatusOK},
+		{name: "exhausted", collisions: 3, code: http.StatusServiceUnavailable, respError: "failed to generate alias"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			urlSaverMock := mocks.NewURLSaver(t)
+
+			var aliases []string
+
+			urlSaverMock.On("SaveURL", mock.Anything, mock.Anything).
+				Return(func(_ context.Context, u storage.URL) (int64, error) {
+					aliases = append(aliases, u.Alias)
+					if len(aliases) <= tc.collisions {
+						return 0, storage.ErrURLExists
+					}
+					return 1, nil
+				})
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, newAllocator(), newRules(), newLinks(), config.DedupOff)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com"}`)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), auth.User{ID: 42}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.code, rr.Code)
+
+			var resp Response
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, tc.respError, resp.Error)
+
+			if tc.respError == "" {
+				assert.Equal(t, aliases[len(aliases)-1], resp.Alias)
+			}
+			// The third attempt is one character longer.
+			assert.Len(t, aliases[2], 7)
+		})
+	}
+}
+
+func TestSaveHandler_Dedup(t *testing.T) {
+	cases := []struct {
+		name      string
+		dedup     string
+		input     string
+		ownerID   int64
+		existing  string
+		findError error
+		code      int
+		respError string
+	}{
+		{
+			name:     "owner",
+			dedup:    config.DedupOwner,
+			input:    `{"url": "https://google.com"}`,
+			ownerID:  42,
+			existing: "abc123",
+			code:     http.StatusOK,
+		},
+		{
+			name:     "global",
+			dedup:    config.DedupGlobal,
+			input:    `{"url": "https://google.com"}`,
+			ownerID:  0,
+			existing: "abc123",
+			code:     http.StatusOK,
+		},
+		{
+			name:      "not found",
+			dedup:     config.DedupOwner,
+			input:     `{"url": "https://google.com"}`,
+			ownerID:   42,
+			findError: storage.ErrURLNotFound,
+			code:      http.StatusOK,
+		},
+		{
+			name:      "find error",
+			dedup:     config.DedupOwner,
+			input:     `{"url": "https://google.com"}`,
+			ownerID:   42,
+			findError: errors.New("some error"),
+			code:      http.StatusInternalServerError,
+			respError: "failed to add url",
+		},
+		{
+			name:  "custom alias",
+			dedup: config.DedupGlobal,
+			input: `{"url": "https://google.com", "alias": "google"}`,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "expiring",
+			dedup: config.DedupGlobal,
+			input: `{"url": "https://google.com", "ttl": "1h"}`,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "redirect options",
+			dedup: config.DedupGlobal,
+			input: `{"url": "https://google.com", "redirect_code": 301}`,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "one-time",
+			dedup: config.DedupGlobal,
+			input: `{"url": "https://google.com", "max_clicks": 1}`,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "off",
+			dedup: config.DedupOff,
+			input: `{"url": "https://google.com"}`,
+			code:  http.StatusOK,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlSaverMock := mocks.NewURLSaver(t)
+
+			find := tc.existing != "" || tc.findError != nil
+			if find {
+				urlSaverMock.On("FindGeneratedURL", mock.Anything, "", "https://google.com", tc.ownerID).
+					Return(storage.URL{Alias: tc.existing}, tc.findError).
+					Once()
+			}
+
+			var saved storage.URL
+			if tc.existing == "" && tc.respError == "" {
+				urlSaverMock.On("SaveURL", mock.Anything, mock.Anything).
+					Run(func(args mock.Arguments) { saved = args.Get(1).(storage.URL) }).
+					Return(int64(1), nil).
+					Once()
+			}
+
+			handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, newAllocator(), newRules(), newLinks(), tc.dedup)
+
+			req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), auth.User{ID: 42}))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.code, rr.Code)
+
+			var resp Response
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+			assert.Equal(t, tc.respError, resp.Error)
+
+			switch {
+			case tc.existing != "":
+				assert.Equal(t, tc.existing, resp.Alias)
+			case tc.respError == "":
+				assert.Equal(t, saved.Alias, resp.Alias)
+				assert.Equal(t, saved.Alias != "google", saved.Generated)
 			}
 		})
 	}
 }
+
+func TestSaveHandler_Password(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", mock.Anything, mock.MatchedBy(func(u storage.URL) bool {
+		return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte("secret")) == nil
+	})).Return(int64(1), nil).Once()
+
+	// Protected links are never deduplicated.
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, newAllocator(), newRules(), newLinks(), config.DedupGlobal)
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com", "password": "secret"}`)))
+	require.NoError(t, err)
+	req = req.WithContext(auth.WithUser(req.Context(), auth.User{ID: 42}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var logged bytes.Buffer
+	slog.New(slog.NewJSONHandler(&logged, nil)).Info("request", slog.Any("request", save.Request{Password: "secret"}))
+	assert.NotContains(t, logged.String(), "secret")
+}
+
+func TestSaveHandler_Cache(t *testing.T) {
+	link := storage.URL{ID: 1, Alias: "fresh", URL: "https://google.com"}
+
+	storageMock := cachemocks.NewURLStorage(t)
+	storageMock.On("GetURLInfo", mock.Anything, "", "fresh").Return(storage.URL{}, storage.ErrURLNotFound).Once()
+	storageMock.On("SaveURL", mock.Anything, mock.MatchedBy(func(u storage.URL) bool {
+		return u.Alias == "fresh"
+	})).Return(int64(1), nil).Once()
+	storageMock.On("GetURLInfo", mock.Anything, "", "fresh").Return(link, nil).Once()
+
+	clickRecorderMock := redirectmocks.NewClickRecorder(t)
+	clickRecorderMock.On("RecordClick", mock.Anything).Maybe()
+
+	c := cache.New(storageMock, 10, time.Minute, time.Minute)
+
+	router := chi.NewRouter()
+	router.Get("/{alias}", redirect.New(
+		slogdiscard.NewDiscardLogger(), c, c, clickRecorderMock, redirectmocks.NewGuard(t),
+		newLinks().ClientIP, config.Redirect{Code: http.StatusFound},
+	))
+	router.Post("/url", save.New(slogdiscard.NewDiscardLogger(), c, newAllocator(), newRules(), newLinks(), config.DedupOff))
+
+	get := func() int {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/fresh", nil))
+
+		return rr.Code
+	}
+
+	// The probe leaves the alias negatively cached.
+	require.Equal(t, http.StatusNotFound, get())
+
+	req := httptest.NewRequest(http.MethodPost, "/url", strings.NewReader(`{"url": "https://google.com", "alias": "fresh"}`))
+	req = req.WithContext(auth.WithUser(req.Context(), auth.User{ID: 42}))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	assert.Equal(t, http.StatusFound, get())
+}
+
+func TestSaveHandler_CaseInsensitive(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", mock.Anything, mock.MatchedBy(func(u storage.URL) bool {
+		return u.Alias == "myalias"
+	})).Return(int64(1), nil).Once()
+
+	rules := alias.NewRules(config.Alias{MinLength: 3, CaseInsensitive: true})
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, newAllocator(), rules, newLinks(), config.DedupOff)
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com", "alias": "MyAlias"}`)))
+	require.NoError(t, err)
+	req = req.WithContext(auth.WithUser(req.Context(), auth.User{ID: 42}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp Response
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Equal(t, "myalias", resp.Alias)
+}
+
+func TestSaveHandler_Domain(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+	urlSaverMock.On("SaveURL", mock.Anything, mock.MatchedBy(func(u storage.URL) bool {
+		return u.Domain == "go.example.org" && len(u.Alias) == 4
+	})).Return(int64(1), nil).Once()
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, newAllocator(), newRules(), newLinks(), config.DedupOff)
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com"}`)))
+	require.NoError(t, err)
+	ctx := auth.WithUser(req.Context(), auth.User{ID: 42})
+	req = req.WithContext(domain.WithDomain(ctx, storage.Domain{Name: "go.example.org", AliasLength: 4}))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var resp Response
+	require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+	assert.Equal(t, "https://go.example.org/"+resp.Alias, resp.ShortURL)
+}
+
+func TestSaveHandler_Unauthorized(t *testing.T) {
+	urlSaverMock := mocks.NewURLSaver(t)
+
+	handler := save.New(slogdiscard.NewDiscardLogger(), urlSaverMock, newAllocator(), newRules(), newLinks(), config.DedupOff)
+
+	req, err := http.NewRequest(http.MethodPost, "/save", bytes.NewReader([]byte(`{"url": "https://google.com"}`)))
+	require.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
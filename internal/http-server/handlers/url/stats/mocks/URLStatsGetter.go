@@ -0,0 +1,58 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+
+	storage "github.com/Braendie/url-shortener/internal/storage"
+)
+
+// URLStatsGetter is an autogenerated mock type for the URLStatsGetter type
+type URLStatsGetter struct {
+	mock.Mock
+}
+
+// ClickStats provides a mock function with given fields: ctx, domain, alias, q
+func (_m *URLStatsGetter) ClickStats(ctx context.Context, domain string, alias string, q storage.StatsQuery) (storage.ClickStats, error) {
+	ret := _m.Called(ctx, domain, alias, q)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ClickStats")
+	}
+
+	var r0 storage.ClickStats
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, storage.StatsQuery) (storage.ClickStats, error)); ok {
+		return rf(ctx, domain, alias, q)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, storage.StatsQuery) storage.ClickStats); ok {
+		r0 = rf(ctx, domain, alias, q)
+	} else {
+		r0 = ret.Get(0).(storage.ClickStats)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, storage.StatsQuery) error); ok {
+		r1 = rf(ctx, domain, alias, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewURLStatsGetter creates a new instance of URLStatsGetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewURLStatsGetter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *URLStatsGetter {
+	mock := &URLStatsGetter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,195 @@
+package stats
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/domain"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const (
+	defaultDayRange  = 30 * 24 * time.Hour
+	defaultHourRange = 24 * time.Hour
+)
+
+type Point struct {
+	Time           time.Time `json:"time"`
+	Clicks         int64     `json:"clicks"`
+	UniqueVisitors int64     `json:"unique_visitors"`
+}
+
+type Response struct {
+	resp.Response
+	Alias          string              `json:"alias"`
+	TotalClicks    int64               `json:"total_clicks"`
+	UniqueVisitors int64               `json:"unique_visitors"`
+	Granularity    storage.Granularity `json:"granularity"`
+	From           time.Time           `json:"from"`
+	To             time.Time           `json:"to"`
+	Series         []Point             `json:"series"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=URLStatsGetter
+type URLStatsGetter interface {
+	ClickStats(ctx context.Context, domain, alias string, q storage.StatsQuery) (storage.ClickStats, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=URLInfoGetter
+type URLInfoGetter interface {
+	GetURLInfo(ctx context.Context, domain, alias string) (storage.URL, error)
+}
+
+// New returns click statistics of an alias. Optional query parameters:
+// granularity (day or hour, day by default), from and to (RFC 3339).
+// Only the owner of the link or an admin may see them.
+func New(log *slog.Logger, statsGetter URLStatsGetter, urlInfoGetter URLInfoGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.stats.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		dom := domain.FromContext(r.Context())
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			log.Info("unauthorized request: no user in context")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("unauthorized"))
+
+			return
+		}
+
+		q, err := parseQuery(r, time.Now())
+		if err != nil {
+			log.Info("invalid stats query", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(err.Error()))
+
+			return
+		}
+
+		info, err := urlInfoGetter.GetURLInfo(r.Context(), dom.Name, alias)
+		if err != nil {
+			responseStorageError(w, r, log, err)
+
+			return
+		}
+
+		if !user.CanManage(info.OwnerID) {
+			log.Info("forbidden: not an owner", slog.Int64("uid", user.ID), slog.Int64("owner_id", info.OwnerID))
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.Error("forbidden"))
+
+			return
+		}
+
+		stats, err := statsGetter.ClickStats(r.Context(), dom.Name, alias, q)
+		if err != nil {
+			responseStorageError(w, r, log, err)
+
+			return
+		}
+
+		series := make([]Point, 0, len(stats.Series))
+		for _, p := range stats.Series {
+			series = append(series, Point{
+				Time:           p.Time,
+				Clicks:         p.Clicks,
+				UniqueVisitors: p.UniqueVisitors,
+			})
+		}
+
+		render.JSON(w, r, Response{
+			Response:       resp.OK(),
+			Alias:          alias,
+			TotalClicks:    stats.TotalClicks,
+			UniqueVisitors: stats.UniqueVisitors,
+			Granularity:    q.Granularity,
+			From:           q.From,
+			To:             q.To,
+			Series:         series,
+		})
+	}
+}
+
+func responseStorageError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	if errors.Is(err, storage.ErrURLNotFound) {
+		log.Info("url not found")
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, resp.Error("not found"))
+
+		return
+	}
+
+	log.Error("failed to get stats", sl.Err(err))
+	render.Status(r, http.StatusInternalServerError)
+	render.JSON(w, r, resp.Error("internal error"))
+}
+
+func parseQuery(r *http.Request, now time.Time) (storage.StatsQuery, error) {
+	values := r.URL.Query()
+
+	q := storage.StatsQuery{
+		Granularity: storage.GranularityDay,
+		To:          now.UTC(),
+	}
+
+	switch g := storage.Granularity(values.Get("granularity")); g {
+	case "", storage.GranularityDay:
+	case storage.GranularityHour:
+		q.Granularity = g
+	default:
+		return q, errors.New("granularity must be day or hour")
+	}
+
+	if raw := values.Get("to"); raw != "" {
+		to, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return q, errors.New("to is not a valid RFC 3339 time")
+		}
+		q.To = to.UTC()
+	}
+
+	if q.Granularity == storage.GranularityHour {
+		q.From = q.To.Add(-defaultHourRange)
+	} else {
+		q.From = q.To.Add(-defaultDayRange)
+	}
+
+	if raw := values.Get("from"); raw != "" {
+		from, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return q, errors.New("from is not a valid RFC 3339 time")
+		}
+		q.From = from.UTC()
+	}
+
+	if !q.From.Before(q.To) {
+		return q, errors.New("from must be before to")
+	}
+
+	return q, nil
+}
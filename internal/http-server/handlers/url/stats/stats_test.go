@@ -0,0 +1,165 @@
+package stats_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/stats"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/stats/mocks"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsHandler(t *testing.T) {
+	owner := auth.User{ID: 1}
+
+	testCases := []struct {
+		name        string
+		alias       string
+		query       string
+		user        auth.User
+		granularity storage.Granularity
+		stats       storage.ClickStats
+		respError   string
+		infoError   error
+		mockError   error
+		code        int
+	}{
+		{
+			name:        "valid",
+			alias:       "test_alias",
+			user:        owner,
+			granularity: storage.GranularityDay,
+			stats: storage.ClickStats{
+				TotalClicks:    3,
+				UniqueVisitors: 2,
+				Series: []storage.StatsPoint{
+					{Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Clicks: 3, UniqueVisitors: 2},
+				},
+			},
+			code: http.StatusOK,
+		},
+		{
+			name:        "hourly",
+			alias:       "test_alias",
+			user:        owner,
+			query:       "?granularity=hour&from=2025-01-01T00:00:00Z&to=2025-01-02T00:00:00Z",
+			granularity: storage.GranularityHour,
+			code:        http.StatusOK,
+		},
+		{
+			name:      "invalid granularity",
+			alias:     "test_alias",
+			user:      owner,
+			query:     "?granularity=week",
+			respError: "granularity must be day or hour",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "invalid range",
+			alias:     "test_alias",
+			user:      owner,
+			query:     "?from=2025-01-02T00:00:00Z&to=2025-01-01T00:00:00Z",
+			respError: "from must be before to",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:        "not found",
+			alias:       "test_alias",
+			user:        owner,
+			granularity: storage.GranularityDay,
+			respError:   "not found",
+			mockError:   storage.ErrURLNotFound,
+			code:        http.StatusNotFound,
+		},
+		{
+			name:        "storage error",
+			alias:       "test_alias",
+			user:        owner,
+			granularity: storage.GranularityDay,
+			respError:   "internal error",
+			mockError:   errors.New("some error"),
+			code:        http.StatusInternalServerError,
+		},
+		{
+			name:        "admin",
+			alias:       "test_alias",
+			user:        auth.User{ID: 2, IsAdmin: true},
+			granularity: storage.GranularityDay,
+			code:        http.StatusOK,
+		},
+		{
+			name:      "not an owner",
+			alias:     "test_alias",
+			user:      auth.User{ID: 2},
+			respError: "forbidden",
+			code:      http.StatusForbidden,
+		},
+		{
+			name:      "link not found",
+			alias:     "test_alias",
+			user:      owner,
+			respError: "not found",
+			infoError: storage.ErrURLNotFound,
+			code:      http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			statsGetterMock := mocks.NewURLStatsGetter(t)
+			urlInfoGetterMock := mocks.NewURLInfoGetter(t)
+
+			if tc.code != http.StatusBadRequest {
+				urlInfoGetterMock.On("GetURLInfo", mock.Anything, "", tc.alias).
+					Return(storage.URL{Alias: tc.alias, OwnerID: owner.ID}, tc.infoError).
+					Once()
+			}
+
+			if tc.respError == "" || tc.mockError != nil {
+				statsGetterMock.On("ClickStats", mock.Anything, "", tc.alias, mock.MatchedBy(func(q storage.StatsQuery) bool {
+					return q.Granularity == tc.granularity && q.From.Before(q.To)
+				})).
+					Return(tc.stats, tc.mockError).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Get("/{alias}/stats", stats.New(slogdiscard.NewDiscardLogger(), statsGetterMock, urlInfoGetterMock))
+
+			req, err := http.NewRequest(http.MethodGet, "/"+tc.alias+"/stats"+tc.query, nil)
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), tc.user))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.code, rr.Code)
+
+			var resp stats.Response
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+
+			if tc.respError != "" {
+				assert.Equal(t, tc.respError, resp.Error)
+				return
+			}
+
+			assert.Equal(t, tc.stats.TotalClicks, resp.TotalClicks)
+			assert.Equal(t, tc.stats.UniqueVisitors, resp.UniqueVisitors)
+			assert.Len(t, resp.Series, len(tc.stats.Series))
+		})
+	}
+}
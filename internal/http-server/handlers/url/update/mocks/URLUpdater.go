@@ -0,0 +1,57 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	storage "github.com/Braendie/url-shortener/internal/storage"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// URLUpdater is an autogenerated mock type for the URLUpdater type
+type URLUpdater struct {
+	mock.Mock
+}
+
+// UpdateURL provides a mock function with given fields: ctx, domain, alias, upd
+func (_m *URLUpdater) UpdateURL(ctx context.Context, domain string, alias string, upd storage.URLUpdate) (storage.URL, error) {
+	ret := _m.Called(ctx, domain, alias, upd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateURL")
+	}
+
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, storage.URLUpdate) (storage.URL, error)); ok {
+		return rf(ctx, domain, alias, upd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, storage.URLUpdate) storage.URL); ok {
+		r0 = rf(ctx, domain, alias, upd)
+	} else {
+		r0 = ret.Get(0).(storage.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, storage.URLUpdate) error); ok {
+		r1 = rf(ctx, domain, alias, upd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// NewURLUpdater creates a new instance of URLUpdater. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewURLUpdater(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *URLUpdater {
+	mock := &URLUpdater{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,233 @@
+package update
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/domain"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/expiry"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/password"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+)
+
+type Request struct {
+	URL             string     `json:"url,omitempty" validate:"omitempty,url"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	TTL             string     `json:"ttl,omitempty" validate:"excluded_with=ExpiresAt"`
+	ClearExpiration bool       `json:"clear_expiration,omitempty"`
+	// RedirectCode of zero resets the link to the server default.
+	RedirectCode *int  `json:"redirect_code,omitempty" validate:"omitempty,oneof=0 301 302 307 308"`
+	ForwardQuery *bool `json:"forward_query,omitempty"`
+	// MaxClicks of zero removes the click limit of the link.
+	MaxClicks *int64 `json:"max_clicks,omitempty" validate:"omitempty,min=0"`
+	// Password of "" removes the protection of the link.
+	Password *string `json:"password,omitempty" validate:"omitempty,max=72"`
+}
+
+// LogValue hides the password from logs.
+func (r Request) LogValue() slog.Value {
+	type plain Request
+
+	if r.Password != nil {
+		hidden := "[hidden]"
+		r.Password = &hidden
+	}
+
+	return slog.AnyValue(plain(r))
+}
+
+type Response struct {
+	resp.Response
+	Alias        string     `json:"alias"`
+	URL          string     `json:"url"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	UpdatedAt    *time.Time `json:"updated_at,omitempty"`
+	RedirectCode int        `json:"redirect_code,omitempty"`
+	ForwardQuery bool       `json:"forward_query"`
+	Protected    bool       `json:"protected"`
+	MaxClicks    int64      `json:"max_clicks,omitempty"`
+	// RemainingClicks is omitted for links without a click limit.
+	RemainingClicks *int64 `json:"remaining_clicks,omitempty"`
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=URLUpdater
+type URLUpdater interface {
+	UpdateURL(ctx context.Context, domain, alias string, upd storage.URLUpdate) (storage.URL, error)
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=URLInfoGetter
+type URLInfoGetter interface {
+	GetURLInfo(ctx context.Context, domain, alias string) (storage.URL, error)
+}
+
+// New changes the target URL, expiration, password, click limit or redirect
+// options of an existing alias.
+// Only the owner of the link or an admin may update it.
+func New(log *slog.Logger, urlUpdater URLUpdater, urlInfoGetter URLInfoGetter) http.HandlerFunc {
+	validate := validator.New()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.url.update.New"
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(r.Context())),
+			slog.String("trace_id", tracing.TraceID(r.Context())),
+		)
+
+		dom := domain.FromContext(r.Context())
+		alias := chi.URLParam(r, "alias")
+		if alias == "" {
+			log.Info("alias is empty")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("invalid request"))
+
+			return
+		}
+
+		user, ok := auth.UserFromContext(r.Context())
+		if !ok {
+			log.Info("unauthorized request: no user in context")
+			render.Status(r, http.StatusUnauthorized)
+			render.JSON(w, r, resp.Error("unauthorized"))
+
+			return
+		}
+
+		var req Request
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("failed to decode request"))
+
+			return
+		}
+
+		log.Info("request body decoded", slog.Any("request", req))
+
+		if err := validate.Struct(req); err != nil {
+			validateErr := err.(validator.ValidationErrors)
+
+			log.Error("invalid request", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.ValidationError(validateErr))
+
+			return
+		}
+
+		expiresAt, err := expiry.Resolve(req.TTL, req.ExpiresAt, time.Now())
+		if err != nil {
+			log.Info("invalid expiration", sl.Err(err))
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error(err.Error()))
+
+			return
+		}
+
+		if req.ClearExpiration && expiresAt != nil {
+			log.Info("conflicting expiration fields")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("field ClearExpiration cannot be used together with ExpiresAt or TTL"))
+
+			return
+		}
+
+		upd := storage.URLUpdate{
+			ExpiresAt:       expiresAt,
+			ClearExpiration: req.ClearExpiration,
+			RedirectCode:    req.RedirectCode,
+			ForwardQuery:    req.ForwardQuery,
+			MaxClicks:       req.MaxClicks,
+			ChangedBy:       user.ID,
+		}
+		if req.URL != "" {
+			upd.URL = &req.URL
+		}
+		if req.Password != nil {
+			var hash string
+			if *req.Password != "" {
+				if hash, err = password.Hash(*req.Password); err != nil {
+					log.Error("failed to hash password", sl.Err(err))
+					render.Status(r, http.StatusInternalServerError)
+					render.JSON(w, r, resp.Error("internal error"))
+
+					return
+				}
+			}
+			upd.PasswordHash = &hash
+		}
+
+		if upd.URL == nil && upd.ExpiresAt == nil && !upd.ClearExpiration &&
+			upd.RedirectCode == nil && upd.ForwardQuery == nil && upd.PasswordHash == nil && upd.MaxClicks == nil {
+			log.Info("nothing to update")
+			render.Status(r, http.StatusBadRequest)
+			render.JSON(w, r, resp.Error("nothing to update"))
+
+			return
+		}
+
+		info, err := urlInfoGetter.GetURLInfo(r.Context(), dom.Name, alias)
+		if err != nil {
+			responseStorageError(w, r, log, err)
+
+			return
+		}
+
+		if !user.CanManage(info.OwnerID) {
+			log.Info("forbidden: not an owner", slog.Int64("uid", user.ID), slog.Int64("owner_id", info.OwnerID))
+			render.Status(r, http.StatusForbidden)
+			render.JSON(w, r, resp.Error("forbidden"))
+
+			return
+		}
+
+		updated, err := urlUpdater.UpdateURL(r.Context(), dom.Name, alias, upd)
+		if err != nil {
+			responseStorageError(w, r, log, err)
+
+			return
+		}
+
+		log.Info("url updated", slog.String("alias", alias))
+
+		render.JSON(w, r, Response{
+			Response:        resp.OK(),
+			Alias:           updated.Alias,
+			URL:             updated.URL,
+			ExpiresAt:       updated.ExpiresAt,
+			UpdatedAt:       updated.UpdatedAt,
+			RedirectCode:    updated.RedirectCode,
+			ForwardQuery:    updated.ForwardQuery,
+			Protected:       updated.PasswordHash != "",
+			MaxClicks:       updated.MaxClicks,
+			RemainingClicks: updated.RemainingClicks(),
+		})
+	}
+}
+
+func responseStorageError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	if errors.Is(err, storage.ErrURLNotFound) {
+		log.Info("url not found")
+		render.Status(r, http.StatusNotFound)
+		render.JSON(w, r, resp.Error("not found"))
+
+		return
+	}
+
+	log.Error("failed to update url", sl.Err(err))
+	render.Status(r, http.StatusInternalServerError)
+	render.JSON(w, r, resp.Error("internal error"))
+}
@@ -0,0 +1,201 @@
+package update_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/update"
+	"github.com/Braendie/url-shortener/internal/http-server/handlers/url/update/mocks"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateHandler(t *testing.T) {
+	owner := auth.User{ID: 1}
+
+	testCases := []struct {
+		name        string
+		input       string
+		user        auth.User
+		infoError   error
+		updateError error
+		respError   string
+		code        int
+	}{
+		{
+			name:  "change url",
+			input: `{"url": "https://new.example.com"}`,
+			user:  owner,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "change expiration by admin",
+			input: `{"ttl": "1h"}`,
+			user:  auth.User{ID: 2, IsAdmin: true},
+			code:  http.StatusOK,
+		},
+		{
+			name:  "clear expiration",
+			input: `{"clear_expiration": true}`,
+			user:  owner,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "change redirect code",
+			input: `{"redirect_code": 301}`,
+			user:  owner,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "reset redirect code",
+			input: `{"redirect_code": 0}`,
+			user:  owner,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "forward query",
+			input: `{"forward_query": true}`,
+			user:  owner,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "set password",
+			input: `{"password": "secret"}`,
+			user:  owner,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "remove password",
+			input: `{"password": ""}`,
+			user:  owner,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "limit clicks",
+			input: `{"max_clicks": 1}`,
+			user:  owner,
+			code:  http.StatusOK,
+		},
+		{
+			name:  "remove click limit",
+			input: `{"max_clicks": 0}`,
+			user:  owner,
+			code:  http.StatusOK,
+		},
+		{
+			name:      "negative click limit",
+			input:     `{"max_clicks": -1}`,
+			user:      owner,
+			respError: "field MaxClicks must be at least 0",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "invalid redirect code",
+			input:     `{"redirect_code": 303}`,
+			user:      owner,
+			respError: "field RedirectCode must be one of 0 301 302 307 308",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "not an owner",
+			input:     `{"url": "https://new.example.com"}`,
+			user:      auth.User{ID: 2},
+			respError: "forbidden",
+			code:      http.StatusForbidden,
+		},
+		{
+			name:      "nothing to update",
+			input:     `{}`,
+			user:      owner,
+			respError: "nothing to update",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "invalid url",
+			input:     `{"url": "not a url"}`,
+			user:      owner,
+			respError: "field URL is not a valid URL",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "conflicting expiration",
+			input:     `{"ttl": "1h", "clear_expiration": true}`,
+			user:      owner,
+			respError: "field ClearExpiration cannot be used together with ExpiresAt or TTL",
+			code:      http.StatusBadRequest,
+		},
+		{
+			name:      "not found",
+			input:     `{"url": "https://new.example.com"}`,
+			user:      owner,
+			infoError: storage.ErrURLNotFound,
+			respError: "not found",
+			code:      http.StatusNotFound,
+		},
+		{
+			name:        "storage error",
+			input:       `{"url": "https://new.example.com"}`,
+			user:        owner,
+			updateError: errors.New("some error"),
+			respError:   "internal error",
+			code:        http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			urlUpdaterMock := mocks.NewURLUpdater(t)
+			urlInfoGetterMock := mocks.NewURLInfoGetter(t)
+
+			validRequest := tc.code != http.StatusBadRequest
+			if validRequest {
+				urlInfoGetterMock.On("GetURLInfo", mock.Anything, "", "test_alias").
+					Return(storage.URL{Alias: "test_alias", OwnerID: owner.ID}, tc.infoError).
+					Once()
+			}
+
+			if validRequest && tc.infoError == nil && tc.code != http.StatusForbidden {
+				urlUpdaterMock.On("UpdateURL", mock.Anything, "", "test_alias", mock.MatchedBy(func(upd storage.URLUpdate) bool {
+					return upd.ChangedBy == tc.user.ID
+				})).
+					Return(storage.URL{Alias: "test_alias", URL: "https://new.example.com"}, tc.updateError).
+					Once()
+			}
+
+			r := chi.NewRouter()
+			r.Patch("/{alias}", update.New(slogdiscard.NewDiscardLogger(), urlUpdaterMock, urlInfoGetterMock))
+
+			req, err := http.NewRequest(http.MethodPatch, "/test_alias", bytes.NewReader([]byte(tc.input)))
+			require.NoError(t, err)
+			req = req.WithContext(auth.WithUser(req.Context(), tc.user))
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.code, rr.Code)
+
+			var resp update.Response
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+
+			if tc.respError != "" {
+				assert.Equal(t, tc.respError, resp.Error)
+				return
+			}
+
+			assert.Equal(t, "https://new.example.com", resp.URL)
+		})
+	}
+}
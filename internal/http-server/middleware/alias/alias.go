@@ -0,0 +1,26 @@
+package alias
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// New rewrites the alias URL parameter with normalize, e.g. to resolve
+// aliases case-insensitively. It must be added to the route with With, so
+// that the parameter is already known.
+func New(normalize func(alias string) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if rctx := chi.RouteContext(r.Context()); rctx != nil {
+				for i, key := range rctx.URLParams.Keys {
+					if key == "alias" {
+						rctx.URLParams.Values[i] = normalize(rctx.URLParams.Values[i])
+					}
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package alias_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	mwalias "github.com/Braendie/url-shortener/internal/http-server/middleware/alias"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	var got string
+
+	router := chi.NewRouter()
+	router.With(mwalias.New(strings.ToLower)).Get("/{alias}", func(w http.ResponseWriter, r *http.Request) {
+		got = chi.URLParam(r, "alias")
+	})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/MyLink", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "mylink", got)
+}
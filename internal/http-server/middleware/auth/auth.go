@@ -0,0 +1,26 @@
+package auth
+
+import "context"
+
+type ctxKey struct{}
+
+// User is the authenticated caller put into the request context by the jwt middleware.
+type User struct {
+	ID      int64
+	Email   string
+	IsAdmin bool
+}
+
+// CanManage reports whether the user may modify a link owned by ownerID.
+func (u User) CanManage(ownerID int64) bool {
+	return u.IsAdmin || u.ID == ownerID
+}
+
+func WithUser(ctx context.Context, user User) context.Context {
+	return context.WithValue(ctx, ctxKey{}, user)
+}
+
+func UserFromContext(ctx context.Context) (User, bool) {
+	user, ok := ctx.Value(ctxKey{}).(User)
+	return user, ok
+}
@@ -8,29 +8,41 @@ import (
 
 	"github.com/Braendie/url-shortener/internal/clients/sso/grpc"
 	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
 	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/golang-jwt/jwt/v4"
-	"golang.org/x/net/context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
-type email string
-type userID string
-
+// New authenticates requests by a JWT issued by SSO and puts the caller
+// into the request context, see auth.UserFromContext.
 func New(cfg *config.Config, log *slog.Logger, ssoClient *grpc.Client) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			const op = "middleware.auth.jwt.New"
 
-			log = log.With(
+			ctx, span := tracing.Tracer().Start(r.Context(), op)
+
+			log := log.With(
 				slog.String("op", op),
-				slog.String("request_id", middleware.GetReqID(r.Context())),
+				slog.String("request_id", middleware.GetReqID(ctx)),
+				slog.String("trace_id", tracing.TraceID(ctx)),
 			)
 
+			// reject ends the span of the check with an error and writes the response.
+			reject := func(text string, code int) {
+				span.SetStatus(codes.Error, text)
+				span.End()
+				http.Error(w, text, code)
+			}
+
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
 				log.Info("unauthorized request: missing token")
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				reject("Unauthorized", http.StatusUnauthorized)
 				return
 			}
 
@@ -45,41 +57,49 @@ func New(cfg *config.Config, log *slog.Logger, ssoClient *grpc.Client) func(http
 			})
 			if err != nil {
 				log.Info("failed to parse token", sl.Err(err))
-				http.Error(w, "Forbidden", http.StatusForbidden)
+				reject("Forbidden", http.StatusForbidden)
 				return
 			}
 
 			if !token.Valid {
 				log.Info("unauthorized request: invalid token")
-				http.Error(w, "Forbidden", http.StatusForbidden)
+				reject("Forbidden", http.StatusForbidden)
 				return
 			}
 
 			claims, ok := token.Claims.(jwt.MapClaims)
 			if !ok {
 				log.Info("unauthorized request: invalid claims")
-				http.Error(w, "Forbidden", http.StatusForbidden)
+				reject("Forbidden", http.StatusForbidden)
 				return
 			}
 
-			isAdmin, err := ssoClient.IsAdmin(r.Context(), int64(claims["uid"].(float64)))
-			if err != nil {
-				log.Info("failed to check isAdmin")
-				http.Error(w, "Internal error", http.StatusInternalServerError)
+			uid, ok := claims["uid"].(float64)
+			if !ok {
+				log.Info("unauthorized request: missing uid claim")
+				reject("Forbidden", http.StatusForbidden)
 				return
 			}
 
-			if !isAdmin {
-				log.Info("Not admin")
-				http.Error(w, "Forbidden", http.StatusForbidden)
+			userEmail, _ := claims["email"].(string)
+
+			isAdmin, err := ssoClient.IsAdmin(ctx, int64(uid))
+			if err != nil {
+				log.Info("failed to check isAdmin", sl.Err(err))
+				reject("Internal error", http.StatusInternalServerError)
 				return
 			}
 
-			log.Info("User is admin", slog.Int64("uid", int64(claims["uid"].(float64))))
+			log.Info("user authenticated", slog.Int64("uid", int64(uid)), slog.Bool("is_admin", isAdmin))
+
+			span.SetAttributes(attribute.Int64("user.id", int64(uid)), attribute.Bool("user.is_admin", isAdmin))
+			span.End()
 
-			ctx := context.WithValue(r.Context(), email("email"), claims["email"].(string))
-			ctx = context.WithValue(ctx, userID("user_id"), int64(claims["uid"].(float64)))
-			r = r.WithContext(ctx)
+			r = r.WithContext(auth.WithUser(r.Context(), auth.User{
+				ID:      int64(uid),
+				Email:   userEmail,
+				IsAdmin: isAdmin,
+			}))
 
 			next.ServeHTTP(w, r)
 		})
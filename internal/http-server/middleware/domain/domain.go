@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/domains"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+type ctxKey struct{}
+
+// Resolver finds the domain served on a host, see domains.Registry.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=Resolver
+type Resolver interface {
+	Resolve(ctx context.Context, host string) (storage.Domain, error)
+}
+
+// New puts the domain served on the request host into the request context.
+// host returns the host the client addressed, see shorturl.Builder.Host.
+// Requests for hosts that are not served get 421 Misdirected Request.
+func New(log *slog.Logger, resolver Resolver, host func(r *http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "middleware.domain.New"
+
+			d, err := resolver.Resolve(r.Context(), host(r))
+			if errors.Is(err, domains.ErrUnknownHost) {
+				log.Info("unknown host",
+					slog.String("op", op),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+					slog.String("host", host(r)),
+				)
+
+				render.Status(r, http.StatusMisdirectedRequest)
+				render.JSON(w, r, resp.Error("unknown domain"))
+
+				return
+			}
+			if err != nil {
+				log.Error("failed to resolve domain",
+					slog.String("op", op),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+					sl.Err(err),
+				)
+
+				render.Status(r, http.StatusInternalServerError)
+				render.JSON(w, r, resp.Error("internal error"))
+
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(WithDomain(r.Context(), d)))
+		})
+	}
+}
+
+func WithDomain(ctx context.Context, d storage.Domain) context.Context {
+	return context.WithValue(ctx, ctxKey{}, d)
+}
+
+// FromContext returns the domain of the request, or the default domain when
+// none was resolved.
+func FromContext(ctx context.Context) storage.Domain {
+	d, _ := ctx.Value(ctxKey{}).(storage.Domain)
+	return d
+}
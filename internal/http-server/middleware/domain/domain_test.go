@@ -0,0 +1,65 @@
+package domain_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	mwdomain "github.com/Braendie/url-shortener/internal/http-server/middleware/domain"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/domain/mocks"
+	"github.com/Braendie/url-shortener/internal/lib/domains"
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDomainMiddleware(t *testing.T) {
+	custom := storage.Domain{Name: "go.example.org", RedirectCode: http.StatusMovedPermanently}
+
+	testCases := []struct {
+		name      string
+		domain    storage.Domain
+		mockError error
+		code      int
+	}{
+		{
+			name:   "registered",
+			domain: custom,
+			code:   http.StatusOK,
+		},
+		{
+			name:      "unknown host",
+			mockError: domains.ErrUnknownHost,
+			code:      http.StatusMisdirectedRequest,
+		},
+		{
+			name:      "storage error",
+			mockError: errors.New("some error"),
+			code:      http.StatusInternalServerError,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resolverMock := mocks.NewResolver(t)
+			resolverMock.On("Resolve", mock.Anything, "go.example.org").Return(tc.domain, tc.mockError).Once()
+
+			var got storage.Domain
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = mwdomain.FromContext(r.Context())
+			})
+
+			host := func(r *http.Request) string { return r.Host }
+			h := mwdomain.New(slogdiscard.NewDiscardLogger(), resolverMock, host)(next)
+
+			req := httptest.NewRequest(http.MethodGet, "http://go.example.org/abc", nil)
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			assert.Equal(t, tc.code, rr.Code)
+			assert.Equal(t, tc.domain, got)
+		})
+	}
+}
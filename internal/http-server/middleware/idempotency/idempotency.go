@@ -0,0 +1,214 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/idempotency"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+const (
+	// Header carries the key chosen by the client for a request it may retry.
+	Header = "Idempotency-Key"
+	// ReplayedHeader is set on responses replayed from the store.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+// Store keeps responses by key, see idempotency.MemoryStore.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=Store
+type Store interface {
+	Begin(ctx context.Context, key, fingerprint string) (*idempotency.Response, error)
+	Complete(ctx context.Context, key string, resp idempotency.Response) error
+	Release(ctx context.Context, key string) error
+}
+
+// New stores the responses of requests with an Idempotency-Key header and
+// replays them to retries with the same key. Keys are scoped to the
+// authenticated user, so it must run after the auth middleware. Reusing a key
+// for a different request is rejected, and so is a retry while the first
+// request is still running. Server errors are not stored, so such requests
+// can be retried. If the store fails the request is let through.
+//
+// Request bodies larger than maxRequest bytes are rejected. Responses larger
+// than maxResponse bytes are not stored, so their retries run again.
+func New(log *slog.Logger, store Store, maxRequest, maxResponse int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "middleware.idempotency.New"
+
+			key := r.Header.Get(Header)
+			user, ok := auth.UserFromContext(r.Context())
+			if key == "" || !ok {
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			log := log.With(
+				slog.String("op", op),
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+			)
+
+			if len(key) > maxKeyLength {
+				log.Info("idempotency key is too long")
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("invalid idempotency key"))
+
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequest))
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				log.Info("request body is too large")
+				render.Status(r, http.StatusRequestEntityTooLarge)
+				render.JSON(w, r, resp.Error("request is too large"))
+
+				return
+			}
+			if err != nil {
+				log.Error("failed to read request body", sl.Err(err))
+				render.Status(r, http.StatusBadRequest)
+				render.JSON(w, r, resp.Error("failed to read request"))
+
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			key = "user:" + strconv.FormatInt(user.ID, 10) + ":" + key
+
+			stored, err := store.Begin(r.Context(), key, fingerprint(r, body))
+			switch {
+			case errors.Is(err, idempotency.ErrMismatch):
+				log.Info("idempotency key reused for a different request")
+				render.Status(r, http.StatusUnprocessableEntity)
+				render.JSON(w, r, resp.Error("idempotency key was used for a different request"))
+
+				return
+			case errors.Is(err, idempotency.ErrInProgress):
+				log.Info("request with the idempotency key is in progress")
+				render.Status(r, http.StatusConflict)
+				render.JSON(w, r, resp.Error("request with this idempotency key is in progress"))
+
+				return
+			case err != nil:
+				log.Error("failed to begin idempotent request", sl.Err(err))
+				next.ServeHTTP(w, r)
+
+				return
+			case stored != nil:
+				log.Info("replaying stored response")
+				replay(w, *stored)
+
+				return
+			}
+
+			completed := false
+			defer func() {
+				if !completed {
+					if err := store.Release(context.WithoutCancel(r.Context()), key); err != nil {
+						log.Error("failed to release idempotency key", sl.Err(err))
+					}
+				}
+			}()
+
+			// Headers set before the handler, such as rate limits, belong to
+			// this response only.
+			before := w.Header().Clone()
+
+			buf := &limitedBuffer{max: maxResponse}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(buf)
+
+			next.ServeHTTP(ww, r)
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			if status >= http.StatusInternalServerError {
+				return
+			}
+			if buf.overflow {
+				log.Info("response is too large to store")
+
+				return
+			}
+
+			header := make(http.Header)
+			for name, values := range w.Header() {
+				if _, ok := before[name]; !ok {
+					header[name] = values
+				}
+			}
+
+			err = store.Complete(context.WithoutCancel(r.Context()), key, idempotency.Response{
+				StatusCode: status,
+				Header:     header,
+				Body:       buf.Bytes(),
+			})
+			if err != nil {
+				log.Error("failed to store idempotent response", sl.Err(err))
+
+				return
+			}
+			completed = true
+		})
+	}
+}
+
+// limitedBuffer keeps up to max bytes written to it and drops the rest,
+// noting the overflow.
+type limitedBuffer struct {
+	bytes.Buffer
+	max      int64
+	overflow bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.overflow {
+		return len(p), nil
+	}
+	if int64(b.Len()+len(p)) > b.max {
+		b.overflow = true
+		b.Reset()
+
+		return len(p), nil
+	}
+
+	return b.Buffer.Write(p)
+}
+
+// fingerprint identifies the request a key was first used for. The host is
+// included because every domain has its own links.
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method + " " + r.Host + r.URL.Path + "\n"))
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func replay(w http.ResponseWriter, stored idempotency.Response) {
+	for name, values := range stored.Header {
+		w.Header()[name] = values
+	}
+	w.Header().Set(ReplayedHeader, "true")
+	w.WriteHeader(stored.StatusCode)
+	_, _ = w.Write(stored.Body)
+}
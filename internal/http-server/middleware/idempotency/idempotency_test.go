@@ -0,0 +1,156 @@
+package idempotency_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	mwidempotency "github.com/Braendie/url-shortener/internal/http-server/middleware/idempotency"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/idempotency/mocks"
+	"github.com/Braendie/url-shortener/internal/lib/idempotency"
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newRequest(userID int64, key, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/url", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(mwidempotency.Header, key)
+	}
+
+	return req.WithContext(auth.WithUser(req.Context(), auth.User{ID: userID}))
+}
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	calls := 0
+	status := http.StatusOK
+
+	h := mwidempotency.New(slogdiscard.NewDiscardLogger(), idempotency.NewMemoryStore(time.Hour), 1<<20, 1<<20)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_, _ = w.Write([]byte(`{"alias":"abc"}`))
+		}),
+	)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve(newRequest(42, "key", `{"url":"https://google.com"}`))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get(mwidempotency.ReplayedHeader))
+
+	// The retry gets the identical response without calling the handler.
+	rr = serve(newRequest(42, "key", `{"url":"https://google.com"}`))
+	require.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "true", rr.Header().Get(mwidempotency.ReplayedHeader))
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `{"alias":"abc"}`, rr.Body.String())
+	assert.Equal(t, 1, calls)
+
+	rr = serve(newRequest(42, "key", `{"url":"https://ya.ru"}`))
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	// Keys are scoped to the user.
+	serve(newRequest(7, "key", `{"url":"https://ya.ru"}`))
+	assert.Equal(t, 2, calls)
+
+	// Requests without a key are not stored.
+	serve(newRequest(42, "", `{"url":"https://google.com"}`))
+	serve(newRequest(42, "", `{"url":"https://google.com"}`))
+	assert.Equal(t, 4, calls)
+
+	// Server errors can be retried.
+	status = http.StatusInternalServerError
+	serve(newRequest(42, "failing", `{}`))
+	status = http.StatusOK
+	rr = serve(newRequest(42, "failing", `{}`))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get(mwidempotency.ReplayedHeader))
+	assert.Equal(t, 6, calls)
+
+	rr = serve(newRequest(42, strings.Repeat("k", 256), `{}`))
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestIdempotencyMiddleware_Limits(t *testing.T) {
+	calls := 0
+	body := `{"alias":"abc"}`
+
+	h := mwidempotency.New(slogdiscard.NewDiscardLogger(), idempotency.NewMemoryStore(time.Hour), 32, 16)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			_, _ = w.Write([]byte(body))
+		}),
+	)
+
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		h.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve(newRequest(42, "large", strings.Repeat("a", 33)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+	assert.Equal(t, 0, calls)
+
+	// Responses within the limit are replayed.
+	serve(newRequest(42, "small", `{}`))
+	rr = serve(newRequest(42, "small", `{}`))
+	assert.Equal(t, "true", rr.Header().Get(mwidempotency.ReplayedHeader))
+	assert.Equal(t, body, rr.Body.String())
+	assert.Equal(t, 1, calls)
+
+	// Larger responses are sent in full but not stored.
+	body = `{"alias":"abcdefghijklmnop"}`
+	rr = serve(newRequest(42, "big", `{}`))
+	assert.Equal(t, body, rr.Body.String())
+	rr = serve(newRequest(42, "big", `{}`))
+	assert.Empty(t, rr.Header().Get(mwidempotency.ReplayedHeader))
+	assert.Equal(t, body, rr.Body.String())
+	assert.Equal(t, 3, calls)
+}
+
+func TestIdempotencyMiddleware_Store(t *testing.T) {
+	cases := []struct {
+		name      string
+		mockError error
+		code      int
+		calls     int
+	}{
+		{name: "in progress", mockError: idempotency.ErrInProgress, code: http.StatusConflict},
+		{name: "store error", mockError: errors.New("some error"), code: http.StatusOK, calls: 1},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			storeMock := mocks.NewStore(t)
+			storeMock.On("Begin", mock.Anything, "user:42:key", mock.Anything).Return(nil, tc.mockError).Once()
+
+			calls := 0
+			h := mwidempotency.New(slogdiscard.NewDiscardLogger(), storeMock, 1<<20, 1<<20)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { calls++ }),
+			)
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, newRequest(42, "key", `{}`))
+
+			assert.Equal(t, tc.code, rr.Code)
+			assert.Equal(t, tc.calls, calls)
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// unmatchedRoute labels requests that did not match any route, so that
+// arbitrary paths do not create new series.
+const unmatchedRoute = "unmatched"
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=RequestObserver
+type RequestObserver interface {
+	ObserveHTTPRequest(method, route string, code int, d time.Duration)
+}
+
+// New records the latency and status code of every request by its chi route pattern.
+func New(observer RequestObserver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			next.ServeHTTP(ww, r)
+
+			route := unmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+
+			code := ww.Status()
+			if code == 0 {
+				code = http.StatusOK
+			}
+
+			observer.ObserveHTTPRequest(r.Method, route, code, time.Since(start))
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/metrics"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/metrics/mocks"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMetricsMiddleware(t *testing.T) {
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		route  string
+		code   int
+	}{
+		{
+			name:   "redirect",
+			method: http.MethodGet,
+			path:   "/some_alias",
+			route:  "/{alias}",
+			code:   http.StatusFound,
+		},
+		{
+			name:   "nested route",
+			method: http.MethodDelete,
+			path:   "/url/some_alias",
+			route:  "/url/{alias}",
+			code:   http.StatusOK,
+		},
+		{
+			name:   "unmatched",
+			method: http.MethodGet,
+			path:   "/some/unknown/path",
+			route:  "unmatched",
+			code:   http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			observerMock := mocks.NewRequestObserver(t)
+			observerMock.On("ObserveHTTPRequest", tc.method, tc.route, tc.code, mock.AnythingOfType("time.Duration")).Once()
+
+			r := chi.NewRouter()
+			r.Use(metrics.New(observerMock))
+			r.Get("/{alias}", func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "https://google.com", http.StatusFound)
+			})
+			r.Route("/url", func(r chi.Router) {
+				r.Delete("/{alias}", func(w http.ResponseWriter, r *http.Request) {})
+			})
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, nil))
+
+			assert.Equal(t, tc.code, rr.Code)
+		})
+	}
+}
@@ -0,0 +1,33 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// RequestObserver is an autogenerated mock type for the RequestObserver type
+type RequestObserver struct {
+	mock.Mock
+}
+
+// ObserveHTTPRequest provides a mock function with given fields: method, route, code, d
+func (_m *RequestObserver) ObserveHTTPRequest(method string, route string, code int, d time.Duration) {
+	_m.Called(method, route, code, d)
+}
+
+// NewRequestObserver creates a new instance of RequestObserver. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewRequestObserver(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *RequestObserver {
+	mock := &RequestObserver{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
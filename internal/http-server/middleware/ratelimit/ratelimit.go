@@ -0,0 +1,96 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/lib/ratelimit"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+)
+
+// Store keeps token buckets, see ratelimit.MemoryStore.
+//
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=Store
+type Store interface {
+	Take(ctx context.Context, key string, limit ratelimit.Limit) (ratelimit.Result, error)
+}
+
+// KeyFunc returns the key the request is limited by.
+type KeyFunc func(r *http.Request) string
+
+// ByIP limits requests by client address. clientIP resolves it, taking
+// trusted proxies into account, see shorturl.Builder.ClientIP.
+func ByIP(clientIP func(r *http.Request) string) KeyFunc {
+	return func(r *http.Request) string {
+		return "ip:" + clientIP(r)
+	}
+}
+
+// ByUser limits requests by the authenticated user and falls back to the
+// client address. It must run after the auth middleware.
+func ByUser(clientIP func(r *http.Request) string) KeyFunc {
+	byIP := ByIP(clientIP)
+
+	return func(r *http.Request) string {
+		if user, ok := auth.UserFromContext(r.Context()); ok {
+			return "user:" + strconv.FormatInt(user.ID, 10)
+		}
+
+		return byIP(r)
+	}
+}
+
+// New limits requests of the named route. Every key gets its own bucket per
+// route. If the store fails the request is let through.
+func New(log *slog.Logger, store Store, route string, limit ratelimit.Limit, key KeyFunc) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			const op = "middleware.ratelimit.New"
+
+			res, err := store.Take(r.Context(), route+":"+key(r), limit)
+			if err != nil {
+				log.Error("failed to take rate limit token",
+					slog.String("op", op),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+					slog.String("route", route),
+					sl.Err(err),
+				)
+				next.ServeHTTP(w, r)
+
+				return
+			}
+
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			w.Header().Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+
+			if !res.Allowed {
+				log.Info("rate limit exceeded",
+					slog.String("op", op),
+					slog.String("request_id", middleware.GetReqID(r.Context())),
+					slog.String("route", route),
+				)
+
+				w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+				render.Status(r, http.StatusTooManyRequests)
+				render.JSON(w, r, resp.Error("too many requests"))
+
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
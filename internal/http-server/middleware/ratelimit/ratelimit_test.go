@@ -0,0 +1,126 @@
+package ratelimit_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/auth"
+	mwratelimit "github.com/Braendie/url-shortener/internal/http-server/middleware/ratelimit"
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/ratelimit/mocks"
+	resp "github.com/Braendie/url-shortener/internal/lib/api/response"
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/lib/ratelimit"
+	"github.com/Braendie/url-shortener/internal/lib/shorturl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitMiddleware(t *testing.T) {
+	limit := ratelimit.Limit{Rate: 1, Burst: 10}
+
+	// Requests come from 192.0.2.1, which is a trusted proxy.
+	links, err := shorturl.New("", []string{"192.0.2.1"})
+	require.NoError(t, err)
+	byIP, byUser := mwratelimit.ByIP(links.ClientIP), mwratelimit.ByUser(links.ClientIP)
+
+	testCases := []struct {
+		name       string
+		key        mwratelimit.KeyFunc
+		forwarded  string
+		user       *auth.User
+		wantKey    string
+		result     ratelimit.Result
+		mockError  error
+		code       int
+		retryAfter string
+	}{
+		{
+			name:    "allowed by ip",
+			key:     byIP,
+			wantKey: "redirect:ip:192.0.2.1",
+			result:  ratelimit.Result{Allowed: true, Limit: 10, Remaining: 9, Reset: 500 * time.Millisecond},
+			code:    http.StatusOK,
+		},
+		{
+			name:      "allowed by forwarded ip",
+			key:       byIP,
+			forwarded: "203.0.113.9",
+			wantKey:   "redirect:ip:203.0.113.9",
+			result:    ratelimit.Result{Allowed: true, Limit: 10, Remaining: 9, Reset: 500 * time.Millisecond},
+			code:      http.StatusOK,
+		},
+		{
+			name:    "allowed by user",
+			key:     byUser,
+			user:    &auth.User{ID: 42},
+			wantKey: "redirect:user:42",
+			result:  ratelimit.Result{Allowed: true, Limit: 10, Remaining: 9, Reset: 500 * time.Millisecond},
+			code:    http.StatusOK,
+		},
+		{
+			name:       "denied",
+			key:        byIP,
+			wantKey:    "redirect:ip:192.0.2.1",
+			result:     ratelimit.Result{Limit: 10, RetryAfter: 1500 * time.Millisecond, Reset: 10 * time.Second},
+			code:       http.StatusTooManyRequests,
+			retryAfter: "2",
+		},
+		{
+			name:      "store error",
+			key:       byIP,
+			wantKey:   "redirect:ip:192.0.2.1",
+			mockError: errors.New("some error"),
+			code:      http.StatusOK,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			storeMock := mocks.NewStore(t)
+			storeMock.On("Take", mock.Anything, tc.wantKey, limit).Return(tc.result, tc.mockError).Once()
+
+			h := mwratelimit.New(slogdiscard.NewDiscardLogger(), storeMock, "redirect", limit, tc.key)(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+			)
+
+			req := httptest.NewRequest(http.MethodGet, "/alias", nil)
+			req.RemoteAddr = "192.0.2.1:1234"
+			if tc.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tc.forwarded)
+			}
+			if tc.user != nil {
+				req = req.WithContext(auth.WithUser(req.Context(), *tc.user))
+			}
+
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			require.Equal(t, tc.code, rr.Code)
+			assert.Equal(t, tc.retryAfter, rr.Header().Get("Retry-After"))
+
+			if tc.mockError != nil {
+				assert.Empty(t, rr.Header().Get("X-RateLimit-Limit"))
+				return
+			}
+
+			assert.Equal(t, "10", rr.Header().Get("X-RateLimit-Limit"))
+
+			if tc.code == http.StatusTooManyRequests {
+				var body resp.Response
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+				assert.Equal(t, resp.Error("too many requests"), body)
+				assert.Equal(t, "0", rr.Header().Get("X-RateLimit-Remaining"))
+				assert.Equal(t, "10", rr.Header().Get("X-RateLimit-Reset"))
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package tracing_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Braendie/url-shortener/internal/http-server/middleware/tracing"
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	testCases := []struct {
+		name        string
+		path        string
+		traceparent string
+		spanName    string
+		code        int
+		status      codes.Code
+	}{
+		{
+			name:     "new trace",
+			path:     "/some_alias",
+			spanName: "GET /{alias}",
+			code:     http.StatusFound,
+		},
+		{
+			name:        "continues incoming trace",
+			path:        "/some_alias",
+			traceparent: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			spanName:    "GET /{alias}",
+			code:        http.StatusFound,
+		},
+		{
+			name:     "server error",
+			path:     "/fail",
+			spanName: "GET /fail",
+			code:     http.StatusInternalServerError,
+			status:   codes.Error,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+			r := chi.NewRouter()
+			r.Use(middleware.RequestID)
+			r.Use(tracing.New(tp.Tracer("test")))
+			r.Get("/fail", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			})
+			r.Get("/{alias}", func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "https://google.com", http.StatusFound)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			req.Header.Set(middleware.RequestIDHeader, "request-1")
+			if tc.traceparent != "" {
+				req.Header.Set("traceparent", tc.traceparent)
+			}
+
+			r.ServeHTTP(httptest.NewRecorder(), req)
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+
+			span := spans[0]
+			assert.Equal(t, tc.spanName, span.Name())
+			assert.Equal(t, tc.status, span.Status().Code)
+			assert.Contains(t, span.Attributes(), attribute.String("request_id", "request-1"))
+			assert.Contains(t, span.Attributes(), attribute.Int("http.response.status_code", tc.code))
+
+			if tc.traceparent != "" {
+				assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", span.SpanContext().TraceID().String())
+				assert.Equal(t, "00f067aa0ba902b7", span.Parent().SpanID().String())
+			}
+		})
+	}
+}
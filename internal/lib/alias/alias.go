@@ -0,0 +1,189 @@
+// Package alias generates aliases for links saved without one.
+package alias
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/Braendie/url-shortener/internal/lib/random"
+	"github.com/Braendie/url-shortener/internal/storage"
+)
+
+const (
+	StrategyRandom     = "random"
+	StrategySequential = "sequential"
+	StrategyHashids    = "hashids"
+	StrategyWords      = "words"
+)
+
+// ErrNoFreeAlias is returned when every attempt hit an alias that is taken.
+var ErrNoFreeAlias = errors.New("no free alias")
+
+// Generator produces aliases. grow is the number of times the alias has been
+// escalated after collisions: generators make their aliases longer by that
+// many characters or words.
+type Generator interface {
+	Generate(ctx context.Context, grow int) (string, error)
+}
+
+// Resizer is implemented by generators whose aliases have a base length that
+// can be changed, such as for domains with their own alias length.
+type Resizer interface {
+	Resize(length int) Generator
+}
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=Sequence
+type Sequence interface {
+	NextAliasSeq(ctx context.Context) (int64, error)
+}
+
+// NewGenerator returns the generator of the configured strategy. length is
+// the size of random, sequential and hashids aliases before escalation.
+func NewGenerator(cfg config.Alias, length int, seq Sequence) (Generator, error) {
+	const op = "lib.alias.NewGenerator"
+
+	if length <= 0 {
+		return nil, fmt.Errorf("%s: alias length must be positive", op)
+	}
+
+	alphabet := cfg.Alphabet
+	if alphabet == "" {
+		alphabet = random.Base62
+	}
+	if cfg.CaseInsensitive {
+		alphabet = lowerAlphabet(alphabet)
+	}
+	if err := validateAlphabet(alphabet); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch cfg.Strategy {
+	case StrategyRandom, "":
+		return Random{Alphabet: alphabet, Length: length}, nil
+	case StrategySequential:
+		return Sequential{Seq: seq, Alphabet: alphabet, Length: length}, nil
+	case StrategyHashids:
+		return NewHashids(seq, alphabet, cfg.Salt, length), nil
+	case StrategyWords:
+		if cfg.Words <= 0 {
+			return nil, fmt.Errorf("%s: words must be positive", op)
+		}
+
+		if !validChars(cfg.Separator) {
+			return nil, fmt.Errorf("%s: separator %q has characters that are not allowed in aliases", op, cfg.Separator)
+		}
+
+		return Words{Count: cfg.Words, Separator: cfg.Separator}, nil
+	default:
+		return nil, fmt.Errorf("%s: unknown strategy %q", op, cfg.Strategy)
+	}
+}
+
+// lowerAlphabet lower-cases alphabet and drops the letters that repeat.
+func lowerAlphabet(alphabet string) string {
+	var b strings.Builder
+
+	for _, c := range strings.ToLower(alphabet) {
+		if !strings.ContainsRune(b.String(), c) {
+			b.WriteRune(c)
+		}
+	}
+
+	return b.String()
+}
+
+func validateAlphabet(alphabet string) error {
+	if len(alphabet) < 2 {
+		return errors.New("alphabet must contain at least 2 characters")
+	}
+
+	for i := 0; i < len(alphabet); i++ {
+		c := alphabet[i]
+		if !validChar(c) {
+			return fmt.Errorf("alphabet contains character %q that is not allowed in aliases", c)
+		}
+		if strings.IndexByte(alphabet[i+1:], c) >= 0 {
+			return fmt.Errorf("alphabet contains %q more than once", c)
+		}
+	}
+
+	return nil
+}
+
+// Allocator finds a free alias by trying generated ones until the storage
+// accepts one.
+type Allocator struct {
+	gen           Generator
+	rules         *Rules
+	maxAttempts   int
+	escalateAfter int
+}
+
+// NewAllocator makes at most maxAttempts attempts and grows the alias every
+// escalateAfter collisions; zero escalateAfter keeps the length fixed.
+// Generated aliases that rules reserve are skipped; rules may be nil.
+func NewAllocator(gen Generator, rules *Rules, maxAttempts int, escalateAfter int) *Allocator {
+	return &Allocator{
+		gen:           gen,
+		rules:         rules,
+		maxAttempts:   max(maxAttempts, 1),
+		escalateAfter: escalateAfter,
+	}
+}
+
+func (a *Allocator) MaxAttempts() int {
+	return a.maxAttempts
+}
+
+// Alias returns an alias for the given attempt, counting from zero. A
+// positive length replaces the base length of generators that are Resizers.
+func (a *Allocator) Alias(ctx context.Context, length int, attempt int) (string, error) {
+	grow := 0
+	if a.escalateAfter > 0 {
+		grow = attempt / a.escalateAfter
+	}
+
+	gen := a.gen
+	if r, ok := gen.(Resizer); ok && length > 0 {
+		gen = r.Resize(length)
+	}
+
+	for range a.maxAttempts {
+		alias, err := gen.Generate(ctx, grow)
+		if err != nil || a.rules == nil || !a.rules.IsReserved(alias) {
+			return alias, err
+		}
+	}
+
+	return "", ErrNoFreeAlias
+}
+
+// Allocate calls save with new aliases while it fails with
+// storage.ErrURLExists and returns the alias that was saved. Other errors are
+// returned as is; running out of attempts returns ErrNoFreeAlias. length is
+// passed to Alias.
+func (a *Allocator) Allocate(ctx context.Context, length int, save func(alias string) error) (string, error) {
+	const op = "lib.alias.Allocate"
+
+	for attempt := 0; attempt < a.maxAttempts; attempt++ {
+		alias, err := a.Alias(ctx, length, attempt)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", op, err)
+		}
+
+		err = save(alias)
+		if errors.Is(err, storage.ErrURLExists) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		return alias, nil
+	}
+
+	return "", fmt.Errorf("%s: %w", op, ErrNoFreeAlias)
+}
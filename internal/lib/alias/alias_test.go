@@ -0,0 +1,238 @@
+package alias_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/Braendie/url-shortener/internal/lib/alias"
+	"github.com/Braendie/url-shortener/internal/lib/alias/mocks"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// counter returns a sequence mock that counts from 1.
+func counter(t *testing.T) *mocks.Sequence {
+	seq := mocks.NewSequence(t)
+
+	var n int64
+	seq.On("NextAliasSeq", mock.Anything).Return(func(context.Context) (int64, error) {
+		n++
+		return n, nil
+	}).Maybe()
+
+	return seq
+}
+
+func TestNewGenerator(t *testing.T) {
+	cases := []struct {
+		name    string
+		cfg     config.Alias
+		length  int
+		wantErr string
+	}{
+		{name: "default", length: 6},
+		{name: "random", cfg: config.Alias{Strategy: alias.StrategyRandom, Alphabet: "abc"}, length: 6},
+		{name: "sequential", cfg: config.Alias{Strategy: alias.StrategySequential}, length: 6},
+		{name: "hashids", cfg: config.Alias{Strategy: alias.StrategyHashids, Salt: "salt"}, length: 6},
+		{name: "words", cfg: config.Alias{Strategy: alias.StrategyWords, Words: 2}, length: 6},
+		{name: "no words", cfg: config.Alias{Strategy: alias.StrategyWords}, length: 6, wantErr: "words must be positive"},
+		{name: "unknown", cfg: config.Alias{Strategy: "uuid"}, length: 6, wantErr: `unknown strategy "uuid"`},
+		{name: "zero length", length: 0, wantErr: "alias length must be positive"},
+		{name: "short alphabet", cfg: config.Alias{Alphabet: "a"}, length: 6, wantErr: "at least 2 characters"},
+		{name: "duplicates", cfg: config.Alias{Alphabet: "abca"}, length: 6, wantErr: "'a' more than once"},
+		{name: "slash", cfg: config.Alias{Alphabet: "ab/"}, length: 6, wantErr: "not allowed in aliases"},
+		{name: "dot", cfg: config.Alias{Alphabet: "ab."}, length: 6, wantErr: "not allowed in aliases"},
+		{name: "plus", cfg: config.Alias{Alphabet: "ab+"}, length: 6, wantErr: "not allowed in aliases"},
+		{name: "underscore", cfg: config.Alias{Alphabet: "ab_-"}, length: 6},
+		{name: "separator", cfg: config.Alias{Strategy: alias.StrategyWords, Words: 2, Separator: "_"}, length: 6},
+		{name: "bad separator", cfg: config.Alias{Strategy: alias.StrategyWords, Words: 2, Separator: "."}, length: 6, wantErr: "not allowed in aliases"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := alias.NewGenerator(tc.cfg, tc.length, mocks.NewSequence(t))
+			if tc.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	ctx := context.Background()
+
+	random := alias.Random{Alphabet: "xyz", Length: 4}
+	got, err := random.Generate(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, got, 5)
+	assert.Empty(t, strings.Trim(got, "xyz"))
+
+	sequential := alias.Sequential{Seq: counter(t), Alphabet: "0123456789", Length: 3}
+	for _, want := range []string{"001", "002", "0003"} {
+		grow := 0
+		if len(want) > 3 {
+			grow = 1
+		}
+		got, err := sequential.Generate(ctx, grow)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	words := alias.Words{Count: 2, Separator: "-"}
+	got, err = words.Generate(ctx, 1)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(got, "-"), 3)
+}
+
+func TestHashids_Unique(t *testing.T) {
+	ctx := context.Background()
+
+	g := alias.NewHashids(counter(t), "abcdef", "salt", 3)
+
+	seen := make(map[string]bool)
+	for i := 0; i < 5000; i++ {
+		grow := i % 3
+		got, err := g.Generate(ctx, grow)
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(got), 3+grow)
+		require.False(t, seen[got], "duplicate alias %q", got)
+		seen[got] = true
+	}
+
+	// The salt changes the aliases.
+	other := alias.NewHashids(counter(t), "abcdef", "pepper", 3)
+	a, err := alias.NewHashids(counter(t), "abcdef", "salt", 3).Generate(ctx, 0)
+	require.NoError(t, err)
+	b, err := other.Generate(ctx, 0)
+	require.NoError(t, err)
+	assert.NotEqual(t, a, b)
+}
+
+func TestAllocator_Allocate(t *testing.T) {
+	ctx := context.Background()
+	gen := alias.Sequential{Seq: counter(t), Alphabet: "0123456789", Length: 2}
+
+	var tried []string
+
+	a := alias.NewAllocator(gen, nil, 4, 2)
+	got, err := a.Allocate(ctx, 0, func(alias string) error {
+		tried = append(tried, alias)
+		if len(tried) < 4 {
+			return storage.ErrURLExists
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "004", got)
+	assert.Equal(t, []string{"01", "02", "003", "004"}, tried)
+
+	_, err = a.Allocate(ctx, 0, func(string) error { return storage.ErrURLExists })
+	assert.ErrorIs(t, err, alias.ErrNoFreeAlias)
+
+	boom := errors.New("boom")
+	_, err = a.Allocate(ctx, 0, func(string) error { return boom })
+	assert.ErrorIs(t, err, boom)
+
+	seq := mocks.NewSequence(t)
+	seq.On("NextAliasSeq", mock.Anything).Return(int64(0), boom).Once()
+	_, err = alias.NewAllocator(alias.Sequential{Seq: seq, Alphabet: "01", Length: 1}, nil, 3, 0).
+		Allocate(ctx, 0, func(string) error { return nil })
+	assert.ErrorIs(t, err, boom)
+}
+
+func TestAllocator_Length(t *testing.T) {
+	ctx := context.Background()
+	a := alias.NewAllocator(alias.Sequential{Seq: counter(t), Alphabet: "0123456789", Length: 2}, nil, 3, 0)
+
+	got, err := a.Alias(ctx, 5, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "00001", got)
+
+	got, err = a.Alias(ctx, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "02", got)
+
+	// Word aliases have no length to change.
+	words := alias.NewAllocator(alias.Words{Count: 2, Separator: "-"}, nil, 3, 0)
+	got, err = words.Alias(ctx, 5, 0)
+	require.NoError(t, err)
+	assert.Len(t, strings.Split(got, "-"), 2)
+}
+
+func TestAllocator_SkipsReserved(t *testing.T) {
+	rules := alias.NewRules(config.Alias{})
+	rules.Reserve("01", "02")
+
+	gen := alias.Sequential{Seq: counter(t), Alphabet: "0123456789", Length: 2}
+
+	got, err := alias.NewAllocator(gen, rules, 3, 0).Alias(context.Background(), 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "03", got)
+}
+
+func TestNewGenerator_CaseInsensitive(t *testing.T) {
+	gen, err := alias.NewGenerator(config.Alias{CaseInsensitive: true, Alphabet: "aAbB1"}, 32, nil)
+	require.NoError(t, err)
+
+	got, err := gen.Generate(context.Background(), 0)
+	require.NoError(t, err)
+	assert.Empty(t, strings.Trim(got, "ab1"))
+}
+
+func TestRules_Validator(t *testing.T) {
+	type request struct {
+		Alias string `validate:"omitempty,alias"`
+	}
+
+	rules := alias.NewRules(config.Alias{MinLength: 3, MaxLength: 8, Reserved: []string{"admin"}})
+	rules.Reserve("healthz")
+
+	cases := []struct {
+		alias string
+		tag   string
+	}{
+		{alias: ""},
+		{alias: "my-link1"},
+		{alias: "ab", tag: "min"},
+		{alias: "abcdefghi", tag: "max"},
+		{alias: "a/b", tag: "alias_chars"},
+		{alias: "ссылка", tag: "alias_chars"},
+		{alias: "a b c", tag: "alias_chars"},
+		{alias: "Admin", tag: "alias_reserved"},
+		{alias: "healthz", tag: "alias_reserved"},
+	}
+
+	v := rules.Validator()
+
+	for _, tc := range cases {
+		t.Run(tc.alias, func(t *testing.T) {
+			if tc.alias != "" {
+				// Check applies the same rules as the tag.
+				assert.Equal(t, tc.tag == "", rules.Check(tc.alias) == nil)
+			}
+
+			err := v.Struct(request{Alias: tc.alias})
+			if tc.tag == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var validateErr validator.ValidationErrors
+			require.ErrorAs(t, err, &validateErr)
+			assert.Equal(t, tc.tag, validateErr[0].ActualTag())
+		})
+	}
+}
+
+func TestRules_Normalize(t *testing.T) {
+	assert.Equal(t, "MyLink", alias.NewRules(config.Alias{}).Normalize("MyLink"))
+	assert.Equal(t, "mylink", alias.NewRules(config.Alias{CaseInsensitive: true}).Normalize("MyLink"))
+}
@@ -0,0 +1,160 @@
+package alias
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/Braendie/url-shortener/internal/lib/random"
+)
+
+// Random generates uniformly random aliases over Alphabet.
+type Random struct {
+	Alphabet string
+	Length   int
+}
+
+func (g Random) Generate(_ context.Context, grow int) (string, error) {
+	return random.NewString(g.Alphabet, g.Length+grow)
+}
+
+func (g Random) Resize(length int) Generator {
+	g.Length = length
+	return g
+}
+
+// Sequential encodes the next value of a counter in Alphabet, left-padded to
+// Length with the first character of Alphabet.
+type Sequential struct {
+	Seq      Sequence
+	Alphabet string
+	Length   int
+}
+
+func (g Sequential) Generate(ctx context.Context, grow int) (string, error) {
+	const op = "lib.alias.Sequential.Generate"
+
+	n, err := g.Seq.NextAliasSeq(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return encode(n, g.Alphabet, g.Length+grow), nil
+}
+
+func (g Sequential) Resize(length int) Generator {
+	g.Length = length
+	return g
+}
+
+// Hashids encodes the next value of a counter like Hashids does: the alphabet
+// is shuffled with a salt, so consecutive links do not get consecutive and
+// guessable aliases, while every value still maps to a distinct alias.
+type Hashids struct {
+	seq      Sequence
+	alphabet string
+	salt     string
+	length   int
+}
+
+func NewHashids(seq Sequence, alphabet string, salt string, length int) Hashids {
+	return Hashids{
+		seq:      seq,
+		alphabet: shuffle(alphabet, salt),
+		salt:     salt,
+		length:   length,
+	}
+}
+
+func (g Hashids) Generate(ctx context.Context, grow int) (string, error) {
+	const op = "lib.alias.Hashids.Generate"
+
+	n, err := g.seq.NextAliasSeq(ctx)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return g.encode(n, g.length+grow), nil
+}
+
+func (g Hashids) Resize(length int) Generator {
+	g.length = length
+	return g
+}
+
+// encode picks a lottery character from the value and encodes the value with
+// the alphabet shuffled by the lottery and the salt. Values with the same
+// lottery share the alphabet, so the encoding stays unique.
+func (g Hashids) encode(n int64, length int) string {
+	lottery := g.alphabet[n%int64(len(g.alphabet))]
+	alphabet := shuffle(g.alphabet, string(lottery)+g.salt)
+
+	return string(lottery) + encode(n, alphabet, length-1)
+}
+
+// encode writes n in base len(alphabet), left-padded to length with the zero
+// digit. Padding keeps the encoding unique: strings of the same length
+// differ whenever the values differ.
+func encode(n int64, alphabet string, length int) string {
+	base := int64(len(alphabet))
+
+	var digits []byte
+	for {
+		digits = append(digits, alphabet[n%base])
+		n /= base
+		if n == 0 {
+			break
+		}
+	}
+
+	for len(digits) < length {
+		digits = append(digits, alphabet[0])
+	}
+
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i]
+	}
+
+	return string(digits)
+}
+
+// shuffle is the consistent shuffle of Hashids: the same alphabet and salt
+// always give the same permutation.
+func shuffle(alphabet string, salt string) string {
+	b := []byte(alphabet)
+	if salt == "" {
+		return alphabet
+	}
+
+	for i, v, p := len(b)-1, 0, 0; i > 0; i-- {
+		v %= len(salt)
+		c := int(salt[v])
+		p += c
+		j := (c + v + p) % i
+		b[i], b[j] = b[j], b[i]
+		v++
+	}
+
+	return string(b)
+}
+
+// Words joins Count random words with Separator, e.g. "brave-otter-lamp".
+type Words struct {
+	Count     int
+	Separator string
+}
+
+func (g Words) Generate(_ context.Context, grow int) (string, error) {
+	words := make([]string, g.Count+grow)
+	for i := range words {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(wordList))))
+		if err != nil {
+			return "", err
+		}
+		words[i] = wordList[n.Int64()]
+	}
+
+	return strings.Join(words, g.Separator), nil
+}
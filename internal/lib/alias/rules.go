@@ -0,0 +1,114 @@
+package alias
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/go-playground/validator/v10"
+)
+
+// Tag is the validation tag of custom aliases registered by Rules.Validator.
+// It expands to alias_chars, min, max and alias_reserved, so validation
+// errors name the rule that failed.
+const Tag = "alias"
+
+// Rules restrict the aliases users choose themselves.
+//
+// Reserved words are matched case-insensitively. Reserve must only be called
+// while the service is being set up, before any validation.
+type Rules struct {
+	minLength int
+	// maxLength of zero does not bound the length.
+	maxLength       int
+	caseInsensitive bool
+	reserved        map[string]struct{}
+}
+
+func NewRules(cfg config.Alias) *Rules {
+	r := &Rules{
+		minLength:       max(cfg.MinLength, 1),
+		maxLength:       cfg.MaxLength,
+		caseInsensitive: cfg.CaseInsensitive,
+		reserved:        make(map[string]struct{}),
+	}
+	r.Reserve(cfg.Reserved...)
+
+	return r
+}
+
+// Reserve forbids the given aliases.
+func (r *Rules) Reserve(words ...string) {
+	for _, w := range words {
+		r.reserved[strings.ToLower(w)] = struct{}{}
+	}
+}
+
+func (r *Rules) IsReserved(alias string) bool {
+	_, ok := r.reserved[strings.ToLower(alias)]
+	return ok
+}
+
+// Normalize returns the form an alias is stored and looked up in.
+func (r *Rules) Normalize(alias string) string {
+	if r.caseInsensitive {
+		return strings.ToLower(alias)
+	}
+
+	return alias
+}
+
+// Check reports why alias may not be chosen as a custom alias, applying the
+// same rules as the alias tag. alias is expected to be normalized.
+func (r *Rules) Check(alias string) error {
+	switch {
+	case !validChars(alias):
+		return fmt.Errorf("alias %q may only contain latin letters, digits, '-' and '_'", alias)
+	case len(alias) < r.minLength:
+		return fmt.Errorf("alias %q must be at least %d characters long", alias, r.minLength)
+	case r.maxLength > 0 && len(alias) > r.maxLength:
+		return fmt.Errorf("alias %q must be at most %d characters long", alias, r.maxLength)
+	case r.IsReserved(alias):
+		return fmt.Errorf("alias %q is reserved", alias)
+	}
+
+	return nil
+}
+
+// Validator returns a validator that knows the alias tag.
+func (r *Rules) Validator() *validator.Validate {
+	v := validator.New()
+
+	_ = v.RegisterValidation("alias_chars", func(fl validator.FieldLevel) bool {
+		return validChars(fl.Field().String())
+	})
+	_ = v.RegisterValidation("alias_reserved", func(fl validator.FieldLevel) bool {
+		return !r.IsReserved(fl.Field().String())
+	})
+
+	tags := fmt.Sprintf("alias_chars,min=%d", r.minLength)
+	if r.maxLength > 0 {
+		tags += fmt.Sprintf(",max=%d", r.maxLength)
+	}
+	v.RegisterAlias(Tag, tags+",alias_reserved")
+
+	return v
+}
+
+// validChars reports whether alias only has characters allowed by validChar.
+// Generated aliases are held to the same set through the alphabet and the
+// word separator.
+func validChars(alias string) bool {
+	for i := 0; i < len(alias); i++ {
+		if !validChar(alias[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validChar reports whether c is an ASCII letter, digit, '-' or '_'.
+func validChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_'
+}
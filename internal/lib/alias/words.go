@@ -0,0 +1,41 @@
+package alias
+
+// wordList holds short, unambiguous English words for word aliases.
+var wordList = [...]string{
+	"able", "acid", "aged", "also", "amber", "angle", "ankle", "apple", "april", "arch",
+	"arena", "army", "atom", "aunt", "autumn", "baby", "bacon", "badge", "baker", "ball",
+	"bamboo", "banjo", "barn", "basil", "beach", "bean", "bear", "beef", "bell", "belt",
+	"bench", "berry", "bird", "black", "blade", "blank", "blue", "boat", "body", "bold",
+	"bolt", "bone", "book", "boot", "brave", "bread", "brick", "bridge", "brief", "bright",
+	"brook", "brown", "brush", "bucket", "bulb", "butter", "cabin", "cable", "cake", "calm",
+	"camel", "candy", "canoe", "canvas", "cape", "card", "cargo", "carpet", "carrot",
+	"castle", "cedar", "chair", "chalk", "charm", "cheese", "cherry", "chess", "chief",
+	"chip", "cider", "circle", "city", "clay", "clever", "cliff", "cloud", "clover", "coal",
+	"coast", "cobalt", "cocoa", "coffee", "comet", "coral", "corn", "cotton", "cozy",
+	"crab", "crane", "crisp", "crow", "crown", "cube", "cycle", "daisy", "dance", "dawn",
+	"deep", "delta", "desert", "diamond", "dinner", "dizzy", "dolphin", "dragon", "dream",
+	"drum", "dune", "eagle", "early", "earth", "echo", "eight", "elbow", "elder", "ember",
+	"empty", "engine", "equal", "fable", "falcon", "fancy", "farm", "feather", "fence",
+	"fern", "ferry", "field", "fig", "final", "flame", "flint", "flute", "foam", "focus",
+	"forest", "fossil", "fox", "frost", "fruit", "funny", "gamma", "garden", "gentle",
+	"giant", "ginger", "glad", "glass", "globe", "gold", "grape", "grass", "gravel",
+	"green", "grid", "guitar", "hammer", "happy", "harbor", "hazel", "heart", "hedge",
+	"helmet", "hero", "honey", "horse", "hotel", "humble", "husky", "icon", "igloo",
+	"index", "iron", "island", "ivory", "jacket", "jade", "jazz", "jelly", "jewel", "jolly",
+	"juice", "jungle", "kayak", "kettle", "kind", "kite", "koala", "ladder", "lake", "lamp",
+	"laser", "lava", "lemon", "light", "lilac", "lime", "linen", "lion", "lively",
+	"lobster", "local", "lucky", "lunar", "magic", "maple", "marble", "meadow", "melon",
+	"metal", "mild", "mint", "mirror", "modest", "moon", "mossy", "motor", "mountain",
+	"music", "navy", "nest", "noble", "north", "nutmeg", "oak", "oasis", "ocean", "olive",
+	"onion", "orange", "orbit", "otter", "owl", "paddle", "palm", "panda", "paper", "pearl",
+	"pepper", "piano", "pilot", "pine", "planet", "plum", "polar", "pond", "poppy", "proud",
+	"pumpkin", "quick", "quiet", "rabbit", "radar", "rain", "rapid", "raven", "ready",
+	"reef", "ribbon", "river", "robin", "rocket", "rose", "royal", "ruby", "rusty",
+	"saddle", "sage", "salt", "sand", "satin", "scarf", "shell", "shiny", "silver",
+	"simple", "sky", "slate", "smooth", "snow", "solar", "spark", "spice", "spring",
+	"square", "stone", "storm", "sugar", "summer", "sunny", "swift", "table", "tango",
+	"tea", "thunder", "tiger", "timber", "toast", "topaz", "tower", "tulip", "tundra",
+	"twin", "umber", "unit", "urban", "valley", "velvet", "violet", "vivid", "wagon",
+	"walnut", "warm", "water", "wave", "willow", "window", "winter", "wise", "wolf", "wool",
+	"yellow", "young", "zebra", "zero", "zesty",
+}
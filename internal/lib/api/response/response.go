@@ -2,6 +2,7 @@ package response
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
@@ -39,6 +40,18 @@ func ValidationError(errs validator.ValidationErrors) Response {
 			errMsgs = append(errMsgs, fmt.Sprintf("field %s is a required field", err.Field()))
 		case "url":
 			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not a valid URL", err.Field()))
+		case "excluded_with":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s cannot be used together with %s", err.Field(), err.Param()))
+		case "min":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s must be at least %s%s", err.Field(), err.Param(), unit(err)))
+		case "max":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s must be at most %s%s", err.Field(), err.Param(), unit(err)))
+		case "oneof":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s must be one of %s", err.Field(), err.Param()))
+		case "alias_chars":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s may only contain latin letters, digits, '-' and '_'", err.Field()))
+		case "alias_reserved":
+			errMsgs = append(errMsgs, fmt.Sprintf("field %s is reserved", err.Field()))
 		default:
 			errMsgs = append(errMsgs, fmt.Sprintf("field %s is not valid", err.Field()))
 		}
@@ -49,3 +62,12 @@ func ValidationError(errs validator.ValidationErrors) Response {
 		Error:  strings.Join(errMsgs, ", "),
 	}
 }
+
+// unit is the unit of the limit of a min or max error, strings are limited by length.
+func unit(err validator.FieldError) string {
+	if err.Kind() == reflect.String {
+		return " characters long"
+	}
+
+	return ""
+}
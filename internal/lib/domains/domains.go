@@ -0,0 +1,188 @@
+// Package domains resolves request hosts to the domains whose links they serve.
+package domains
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/storage"
+)
+
+// ErrUnknownHost is returned for hosts that are neither registered nor the
+// default host.
+var ErrUnknownHost = errors.New("unknown host")
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=Storage
+type Storage interface {
+	SaveDomain(ctx context.Context, d storage.Domain) error
+	ListDomains(ctx context.Context) ([]storage.Domain, error)
+	DeleteDomain(ctx context.Context, name string) error
+}
+
+// minRetry is the delay before the first retry of a failed reload. It doubles
+// with every further failure, up to the refresh interval.
+const minRetry = time.Second
+
+// Registry keeps the registered domains in memory. They are reloaded every
+// refresh interval, so that changes made on another instance are picked up,
+// and right after a change made through the registry. While a reload fails,
+// the last loaded domains keep being served.
+type Registry struct {
+	storage     Storage
+	defaultHost string
+	refresh     time.Duration
+
+	mu       sync.Mutex
+	domains  map[string]storage.Domain
+	loadedAt time.Time
+	// gen is bumped by every change, so that a reload started before it
+	// does not count as fresh.
+	gen     uint64
+	loading bool
+	// err is the last reload error, retried no earlier than retryAt.
+	err      error
+	failures int
+	retryAt  time.Time
+}
+
+// NewRegistry serves links of the default domain on defaultHost, which must
+// not be empty.
+func NewRegistry(s Storage, defaultHost string, refresh time.Duration) *Registry {
+	return &Registry{
+		storage:     s,
+		defaultHost: defaultHost,
+		refresh:     refresh,
+	}
+}
+
+// Resolve returns the domain served on host, which must be lower-cased and
+// without a port. The default domain has an empty name.
+func (r *Registry) Resolve(ctx context.Context, host string) (storage.Domain, error) {
+	const op = "lib.domains.Resolve"
+
+	if host == r.defaultHost {
+		return storage.Domain{}, nil
+	}
+
+	domains, err := r.load(ctx)
+	if err != nil {
+		return storage.Domain{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if d, ok := domains[host]; ok {
+		return d, nil
+	}
+
+	return storage.Domain{}, fmt.Errorf("%s: %w", op, ErrUnknownHost)
+}
+
+// SaveDomain implements the domain save handler's storage.
+func (r *Registry) SaveDomain(ctx context.Context, d storage.Domain) error {
+	err := r.storage.SaveDomain(ctx, d)
+	r.invalidate()
+
+	return err
+}
+
+// ListDomains reads the domains from the storage, bypassing the registry.
+func (r *Registry) ListDomains(ctx context.Context) ([]storage.Domain, error) {
+	return r.storage.ListDomains(ctx)
+}
+
+// DeleteDomain implements the domain delete handler's storage.
+func (r *Registry) DeleteDomain(ctx context.Context, name string) error {
+	err := r.storage.DeleteDomain(ctx, name)
+	r.invalidate()
+
+	return err
+}
+
+// load returns the domains by name, reloading them once they are older than
+// the refresh interval. The storage is queried without holding the lock, and
+// only one reload runs at a time once the domains have been loaded.
+func (r *Registry) load(ctx context.Context) (map[string]storage.Domain, error) {
+	r.mu.Lock()
+
+	stale := r.loadedAt.IsZero() || time.Since(r.loadedAt) >= r.refresh
+	if !stale || (r.domains != nil && r.loading) {
+		domains := r.domains
+		r.mu.Unlock()
+
+		return domains, nil
+	}
+
+	if r.err != nil && time.Now().Before(r.retryAt) {
+		domains, err := r.domains, r.err
+		r.mu.Unlock()
+
+		if domains != nil {
+			return domains, nil
+		}
+
+		return nil, err
+	}
+
+	r.loading = true
+	gen := r.gen
+	r.mu.Unlock()
+
+	list, err := r.storage.ListDomains(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.loading = false
+
+	if err != nil {
+		r.err = err
+		r.failures++
+		r.retryAt = time.Now().Add(r.backoff())
+
+		if r.domains != nil {
+			return r.domains, nil
+		}
+
+		return nil, err
+	}
+
+	domains := make(map[string]storage.Domain, len(list))
+	for _, d := range list {
+		domains[d.Name] = d
+	}
+
+	r.domains = domains
+	r.err = nil
+	r.failures = 0
+	r.loadedAt = time.Time{}
+	if gen == r.gen {
+		r.loadedAt = time.Now()
+	}
+
+	return domains, nil
+}
+
+// backoff returns the delay before the next retry after r.failures failed
+// reloads in a row.
+func (r *Registry) backoff() time.Duration {
+	d := minRetry
+	for i := 1; i < r.failures && d < r.refresh; i++ {
+		d *= 2
+	}
+
+	return min(d, max(r.refresh, minRetry))
+}
+
+// invalidate makes the next lookup reload the domains. The loaded ones are
+// kept, so that they are still served if the reload fails.
+func (r *Registry) invalidate() {
+	r.mu.Lock()
+	r.gen++
+	r.loadedAt = time.Time{}
+	r.err = nil
+	r.failures = 0
+	r.retryAt = time.Time{}
+	r.mu.Unlock()
+}
@@ -0,0 +1,131 @@
+package domains_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/lib/domains"
+	"github.com/Braendie/url-shortener/internal/lib/domains/mocks"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Resolve(t *testing.T) {
+	ctx := context.Background()
+	custom := storage.Domain{Name: "go.example.org", AliasLength: 4}
+
+	storageMock := mocks.NewStorage(t)
+	storageMock.On("ListDomains", mock.Anything).Return([]storage.Domain{custom}, nil).Once()
+
+	r := domains.NewRegistry(storageMock, "sho.rt", time.Hour)
+
+	d, err := r.Resolve(ctx, "sho.rt")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Domain{}, d)
+
+	d, err = r.Resolve(ctx, "go.example.org")
+	require.NoError(t, err)
+	assert.Equal(t, custom, d)
+
+	_, err = r.Resolve(ctx, "evil.com")
+	assert.ErrorIs(t, err, domains.ErrUnknownHost)
+}
+
+func TestRegistry_Reload(t *testing.T) {
+	ctx := context.Background()
+	custom := storage.Domain{Name: "go.example.org"}
+
+	storageMock := mocks.NewStorage(t)
+	storageMock.On("ListDomains", mock.Anything).Return(nil, nil).Once()
+	storageMock.On("SaveDomain", mock.Anything, custom).Return(nil).Once()
+	storageMock.On("ListDomains", mock.Anything).Return([]storage.Domain{custom}, nil).Once()
+
+	r := domains.NewRegistry(storageMock, "sho.rt", time.Hour)
+
+	_, err := r.Resolve(ctx, "go.example.org")
+	require.ErrorIs(t, err, domains.ErrUnknownHost)
+
+	require.NoError(t, r.SaveDomain(ctx, custom))
+
+	d, err := r.Resolve(ctx, "go.example.org")
+	require.NoError(t, err)
+	assert.Equal(t, custom, d)
+
+	boom := errors.New("boom")
+	storageMock.On("ListDomains", mock.Anything).Return(nil, boom).Once()
+
+	r = domains.NewRegistry(storageMock, "sho.rt", time.Hour)
+
+	_, err = r.Resolve(ctx, "go.example.org")
+	assert.ErrorIs(t, err, boom)
+
+	// The failed reload is not retried before the backoff expires.
+	_, err = r.Resolve(ctx, "go.example.org")
+	assert.ErrorIs(t, err, boom)
+}
+
+func TestRegistry_StaleOnError(t *testing.T) {
+	ctx := context.Background()
+	custom := storage.Domain{Name: "go.example.org"}
+
+	storageMock := mocks.NewStorage(t)
+	storageMock.On("ListDomains", mock.Anything).Return([]storage.Domain{custom}, nil).Once()
+	storageMock.On("DeleteDomain", mock.Anything, "other.example.org").Return(nil).Once()
+	storageMock.On("ListDomains", mock.Anything).Return(nil, errors.New("boom")).Once()
+
+	r := domains.NewRegistry(storageMock, "sho.rt", time.Hour)
+
+	_, err := r.Resolve(ctx, "go.example.org")
+	require.NoError(t, err)
+
+	require.NoError(t, r.DeleteDomain(ctx, "other.example.org"))
+
+	for range 2 {
+		d, err := r.Resolve(ctx, "go.example.org")
+		require.NoError(t, err)
+		assert.Equal(t, custom, d)
+	}
+}
+
+func TestRegistry_ReloadUnlocked(t *testing.T) {
+	ctx := context.Background()
+	custom := storage.Domain{Name: "go.example.org"}
+	started, release := make(chan struct{}), make(chan struct{})
+
+	storageMock := mocks.NewStorage(t)
+	storageMock.On("ListDomains", mock.Anything).Return([]storage.Domain{custom}, nil).Once()
+	storageMock.On("SaveDomain", mock.Anything, custom).Return(nil).Once()
+	storageMock.On("ListDomains", mock.Anything).
+		Run(func(mock.Arguments) {
+			close(started)
+			<-release
+		}).
+		Return([]storage.Domain{custom}, nil).Once()
+
+	r := domains.NewRegistry(storageMock, "sho.rt", time.Hour)
+
+	_, err := r.Resolve(ctx, "go.example.org")
+	require.NoError(t, err)
+
+	require.NoError(t, r.SaveDomain(ctx, custom))
+
+	done := make(chan error)
+	go func() {
+		_, err := r.Resolve(ctx, "go.example.org")
+		done <- err
+	}()
+
+	// Lookups are served from the loaded domains while the reload is stuck.
+	<-started
+
+	d, err := r.Resolve(ctx, "go.example.org")
+	require.NoError(t, err)
+	assert.Equal(t, custom, d)
+
+	close(release)
+	require.NoError(t, <-done)
+}
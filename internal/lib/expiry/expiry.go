@@ -0,0 +1,36 @@
+package expiry
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrInvalidTTL  = errors.New("field TTL is not a valid duration")
+	ErrNegativeTTL = errors.New("field TTL must be positive")
+	ErrInPast      = errors.New("field ExpiresAt must be in the future")
+)
+
+// Resolve turns the optional ttl or absolute expiration time of a request into
+// an absolute expiration time. It returns nil if neither is set.
+func Resolve(ttl string, expiresAt *time.Time, now time.Time) (*time.Time, error) {
+	if ttl != "" {
+		d, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, ErrInvalidTTL
+		}
+		if d <= 0 {
+			return nil, ErrNegativeTTL
+		}
+
+		t := now.Add(d)
+
+		return &t, nil
+	}
+
+	if expiresAt != nil && !expiresAt.After(now) {
+		return nil, ErrInPast
+	}
+
+	return expiresAt, nil
+}
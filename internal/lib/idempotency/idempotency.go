@@ -0,0 +1,118 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInProgress means a request with the same key has not finished yet.
+	ErrInProgress = errors.New("request is in progress")
+	// ErrMismatch means the key was already used for a different request.
+	ErrMismatch = errors.New("key was used for a different request")
+)
+
+// Response is a stored response that is replayed to retries.
+type Response struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// sweepInterval is how often expired entries are dropped from memory.
+const sweepInterval = time.Minute
+
+type entry struct {
+	fingerprint string
+	done        bool
+	resp        Response
+	expiresAt   time.Time
+}
+
+// MemoryStore keeps responses in process memory for ttl. It is suitable for a
+// single instance; replicas need a store backed by shared storage.
+type MemoryStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	entries   map[string]*entry
+	lastSweep time.Time
+}
+
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		ttl:       ttl,
+		entries:   make(map[string]*entry),
+		lastSweep: time.Now(),
+	}
+}
+
+// Begin claims key for the request identified by fingerprint. If the request
+// has already been completed its response is returned and nothing is claimed.
+// A claimed key must be either completed or released.
+func (s *MemoryStore) Begin(_ context.Context, key, fingerprint string) (*Response, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	e, ok := s.entries[key]
+	if !ok || !now.Before(e.expiresAt) {
+		s.entries[key] = &entry{fingerprint: fingerprint, expiresAt: now.Add(s.ttl)}
+
+		return nil, nil
+	}
+
+	switch {
+	case e.fingerprint != fingerprint:
+		return nil, ErrMismatch
+	case !e.done:
+		return nil, ErrInProgress
+	}
+
+	resp := e.resp
+
+	return &resp, nil
+}
+
+// Complete stores the response of a claimed key.
+func (s *MemoryStore) Complete(_ context.Context, key string, resp Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		e.done = true
+		e.resp = resp
+	}
+
+	return nil
+}
+
+// Release frees a claimed key without storing a response, so that the request
+// can be retried.
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e, ok := s.entries[key]; ok && !e.done {
+		delete(s.entries, key)
+	}
+
+	return nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	for key, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+
+	s.lastSweep = now
+}
@@ -0,0 +1,73 @@
+package idempotency_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/lib/idempotency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	s := idempotency.NewMemoryStore(time.Hour)
+
+	stored, err := s.Begin(ctx, "key", "request")
+	require.NoError(t, err)
+	require.Nil(t, stored)
+
+	_, err = s.Begin(ctx, "key", "request")
+	assert.ErrorIs(t, err, idempotency.ErrInProgress)
+
+	_, err = s.Begin(ctx, "key", "other request")
+	assert.ErrorIs(t, err, idempotency.ErrMismatch)
+
+	resp := idempotency.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       []byte(`{"status":"OK"}`),
+	}
+	require.NoError(t, s.Complete(ctx, "key", resp))
+
+	stored, err = s.Begin(ctx, "key", "request")
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Equal(t, resp, *stored)
+
+	// Completed keys are not released.
+	require.NoError(t, s.Release(ctx, "key"))
+	stored, err = s.Begin(ctx, "key", "request")
+	require.NoError(t, err)
+	assert.NotNil(t, stored)
+}
+
+func TestMemoryStore_Release(t *testing.T) {
+	ctx := context.Background()
+	s := idempotency.NewMemoryStore(time.Hour)
+
+	_, err := s.Begin(ctx, "key", "request")
+	require.NoError(t, err)
+	require.NoError(t, s.Release(ctx, "key"))
+
+	stored, err := s.Begin(ctx, "key", "other request")
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+}
+
+func TestMemoryStore_Expiry(t *testing.T) {
+	ctx := context.Background()
+	s := idempotency.NewMemoryStore(20 * time.Millisecond)
+
+	_, err := s.Begin(ctx, "key", "request")
+	require.NoError(t, err)
+	require.NoError(t, s.Complete(ctx, "key", idempotency.Response{StatusCode: http.StatusOK}))
+
+	time.Sleep(30 * time.Millisecond)
+
+	stored, err := s.Begin(ctx, "key", "other request")
+	require.NoError(t, err)
+	assert.Nil(t, stored)
+}
@@ -0,0 +1,194 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	namespace = "url_shortener"
+
+	// OtherAlias is the label used for redirects of aliases above the cardinality limit.
+	OtherAlias = "other"
+)
+
+// Metrics holds the service collectors registered on a dedicated registry.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests    *prometheus.CounterVec
+	httpDuration    *prometheus.HistogramVec
+	storageDuration *prometheus.HistogramVec
+	storageErrors   *prometheus.CounterVec
+	ssoDuration     *prometheus.HistogramVec
+	ssoRetries      prometheus.Counter
+	redirects       *prometheus.CounterVec
+
+	maxAliases int
+	aliasesMu  sync.Mutex
+	aliases    map[string]struct{}
+}
+
+// New creates the collectors. At most maxAliases distinct aliases get their
+// own redirects series, the rest are counted under OtherAlias.
+func New(maxAliases int) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Number of HTTP requests by route pattern and status code.",
+		}, []string{"method", "route", "code"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "HTTP request latency by route pattern and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "code"}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "operation_duration_seconds",
+			Help:      "Storage operation latency.",
+			Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}, []string{"operation"}),
+		storageErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "storage",
+			Name:      "operation_errors_total",
+			Help:      "Number of failed storage operations.",
+		}, []string{"operation"}),
+		ssoDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "sso",
+			Name:      "call_duration_seconds",
+			Help:      "SSO call latency including retries.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "code"}),
+		ssoRetries: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sso",
+			Name:      "retries_total",
+			Help:      "Number of retried SSO calls.",
+		}),
+		redirects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "redirects_total",
+			Help:      "Number of redirects by alias.",
+		}, []string{"alias"}),
+		maxAliases: maxAliases,
+		aliases:    make(map[string]struct{}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.storageDuration,
+		m.storageErrors,
+		m.ssoDuration,
+		m.ssoRetries,
+		m.redirects,
+	)
+
+	return m
+}
+
+// Handler serves the registered metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// ObserveHTTPRequest records a served request. route is the chi route pattern.
+func (m *Metrics) ObserveHTTPRequest(method, route string, code int, d time.Duration) {
+	status := strconv.Itoa(code)
+
+	m.httpRequests.WithLabelValues(method, route, status).Inc()
+	m.httpDuration.WithLabelValues(method, route, status).Observe(d.Seconds())
+}
+
+// ObserveStorage records a storage operation that started at start.
+func (m *Metrics) ObserveStorage(operation string, start time.Time, err error) {
+	m.storageDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+
+	if err != nil {
+		m.storageErrors.WithLabelValues(operation).Inc()
+	}
+}
+
+// ObserveSSOCall implements grpc.Observer.
+func (m *Metrics) ObserveSSOCall(method string, code codes.Code, d time.Duration) {
+	m.ssoDuration.WithLabelValues(method, code.String()).Observe(d.Seconds())
+}
+
+// ObserveSSORetry implements grpc.Observer.
+func (m *Metrics) ObserveSSORetry() {
+	m.ssoRetries.Inc()
+}
+
+// ObserveRedirect counts a redirect of the alias.
+func (m *Metrics) ObserveRedirect(alias string) {
+	m.redirects.WithLabelValues(m.aliasLabel(alias)).Inc()
+}
+
+// RegisterCache exposes the hit and miss counters and the size of the redirect cache.
+func (m *Metrics) RegisterCache(stats func() (hits, misses uint64, size int)) {
+	m.registry.MustRegister(
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "hits_total",
+			Help:      "Number of redirect cache hits.",
+		}, func() float64 {
+			hits, _, _ := stats()
+			return float64(hits)
+		}),
+		prometheus.NewCounterFunc(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "misses_total",
+			Help:      "Number of redirect cache misses.",
+		}, func() float64 {
+			_, misses, _ := stats()
+			return float64(misses)
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "cache",
+			Name:      "entries",
+			Help:      "Number of entries in the redirect cache.",
+		}, func() float64 {
+			_, _, size := stats()
+			return float64(size)
+		}),
+	)
+}
+
+// aliasLabel returns the alias itself while the number of tracked aliases is
+// under the limit, and OtherAlias afterwards.
+func (m *Metrics) aliasLabel(alias string) string {
+	m.aliasesMu.Lock()
+	defer m.aliasesMu.Unlock()
+
+	if _, ok := m.aliases[alias]; ok {
+		return alias
+	}
+
+	if len(m.aliases) >= m.maxAliases {
+		return OtherAlias
+	}
+
+	m.aliases[alias] = struct{}{}
+
+	return alias
+}
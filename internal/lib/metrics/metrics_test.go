@@ -0,0 +1,66 @@
+package metrics_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/lib/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+)
+
+func scrape(t *testing.T, m *metrics.Metrics) string {
+	t.Helper()
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	body, err := io.ReadAll(rr.Body)
+	require.NoError(t, err)
+
+	return string(body)
+}
+
+func TestMetrics_RedirectsCardinalityLimit(t *testing.T) {
+	m := metrics.New(2)
+
+	for _, alias := range []string{"a", "b", "c", "a", "d"} {
+		m.ObserveRedirect(alias)
+	}
+
+	out := scrape(t, m)
+
+	assert.Contains(t, out, `url_shortener_redirects_total{alias="a"} 2`)
+	assert.Contains(t, out, `url_shortener_redirects_total{alias="b"} 1`)
+	assert.Contains(t, out, `url_shortener_redirects_total{alias="other"} 2`)
+	assert.NotContains(t, out, `alias="c"`)
+}
+
+func TestMetrics_Observe(t *testing.T) {
+	m := metrics.New(10)
+
+	m.ObserveHTTPRequest(http.MethodGet, "/{alias}", http.StatusFound, time.Millisecond)
+	m.ObserveStorage("GetURL", time.Now(), nil)
+	m.ObserveStorage("SaveURL", time.Now(), errors.New("some error"))
+	m.ObserveSSOCall("/auth.Auth/IsAdmin", codes.Unavailable, time.Millisecond)
+	m.ObserveSSORetry()
+	m.RegisterCache(func() (uint64, uint64, int) { return 3, 1, 2 })
+
+	out := scrape(t, m)
+
+	assert.Contains(t, out, `url_shortener_http_requests_total{code="302",method="GET",route="/{alias}"} 1`)
+	assert.Contains(t, out, `url_shortener_storage_operation_duration_seconds_count{operation="GetURL"} 1`)
+	assert.Contains(t, out, `url_shortener_storage_operation_errors_total{operation="SaveURL"} 1`)
+	assert.NotContains(t, out, `url_shortener_storage_operation_errors_total{operation="GetURL"}`)
+	assert.Contains(t, out, `url_shortener_sso_call_duration_seconds_count{code="Unavailable",method="/auth.Auth/IsAdmin"} 1`)
+	assert.Contains(t, out, `url_shortener_sso_retries_total 1`)
+	assert.Contains(t, out, `url_shortener_cache_hits_total 3`)
+	assert.Contains(t, out, `url_shortener_cache_misses_total 1`)
+	assert.Contains(t, out, `url_shortener_cache_entries 2`)
+}
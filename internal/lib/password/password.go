@@ -0,0 +1,124 @@
+// Package password protects links with a password.
+package password
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/Braendie/url-shortener/internal/lib/ratelimit"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// CookieName is the cookie that remembers an unlocked link. It is scoped to
+// the path of the link.
+const CookieName = "unlock"
+
+var (
+	ErrInvalidPassword = errors.New("invalid password")
+	ErrTooManyAttempts = errors.New("too many attempts")
+)
+
+// Hash returns the hash of password to store with a link.
+func Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// Guard checks passwords of protected links and issues signed cookies, so
+// that clients do not have to enter the password on every visit.
+type Guard struct {
+	secret   []byte
+	ttl      time.Duration
+	scheme   func(r *http.Request) string
+	attempts *ratelimit.MemoryStore
+	limit    ratelimit.Limit
+}
+
+// NewGuard creates a guard whose cookies are Secure when scheme resolves
+// requests to https, see shorturl.Builder.Scheme.
+func NewGuard(secret string, cfg config.Protection, scheme func(r *http.Request) string) *Guard {
+	return &Guard{
+		secret:   []byte(secret),
+		ttl:      cfg.UnlockTTL,
+		scheme:   scheme,
+		attempts: ratelimit.NewMemoryStore(),
+		limit: ratelimit.Limit{
+			Rate:  1 / cfg.AttemptsRefill.Seconds(),
+			Burst: max(cfg.Attempts, 1),
+		},
+	}
+}
+
+// Check verifies password of the link. Attempts are throttled per link, so
+// that a password cannot be guessed by brute force.
+func (g *Guard) Check(ctx context.Context, link storage.URL, password string) error {
+	res, err := g.attempts.Take(ctx, link.Domain+"/"+link.Alias, g.limit)
+	if err == nil && !res.Allowed {
+		return ErrTooManyAttempts
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(link.PasswordHash), []byte(password)) != nil {
+		return ErrInvalidPassword
+	}
+
+	return nil
+}
+
+// Unlocked reports whether the request carries a valid cookie for the link.
+// Changing the password of the link invalidates its cookies.
+func (g *Guard) Unlocked(r *http.Request, link storage.URL) bool {
+	cookie, err := r.Cookie(CookieName)
+	if err != nil {
+		return false
+	}
+
+	exp, mac, ok := strings.Cut(cookie.Value, ".")
+	if !ok {
+		return false
+	}
+
+	expiresAt, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || time.Now().Unix() >= expiresAt {
+		return false
+	}
+
+	want := g.sign(link, exp)
+
+	return hmac.Equal([]byte(mac), []byte(want))
+}
+
+// SetCookie remembers that the client has unlocked the link.
+func (g *Guard) SetCookie(w http.ResponseWriter, r *http.Request, link storage.URL) {
+	expiresAt := time.Now().Add(g.ttl)
+	exp := strconv.FormatInt(expiresAt.Unix(), 10)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     CookieName,
+		Value:    exp + "." + g.sign(link, exp),
+		Path:     r.URL.Path,
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   g.scheme(r) == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (g *Guard) sign(link storage.URL, exp string) string {
+	h := hmac.New(sha256.New, g.secret)
+	h.Write([]byte("unlock\n" + link.Alias + "\n" + link.PasswordHash + "\n" + exp))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,110 @@
+package password_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/Braendie/url-shortener/internal/lib/password"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func plainHTTP(*http.Request) string { return "http" }
+
+func newLink(t *testing.T, pass string) storage.URL {
+	t.Helper()
+
+	hash, err := password.Hash(pass)
+	require.NoError(t, err)
+
+	return storage.URL{Alias: "docs", URL: "https://go.dev", PasswordHash: hash}
+}
+
+func TestGuard_Check(t *testing.T) {
+	ctx := context.Background()
+	guard := password.NewGuard("secret", config.Protection{UnlockTTL: time.Hour, Attempts: 3, AttemptsRefill: time.Hour}, plainHTTP)
+	link := newLink(t, "open sesame")
+
+	assert.NoError(t, guard.Check(ctx, link, "open sesame"))
+	assert.ErrorIs(t, guard.Check(ctx, link, "wrong"), password.ErrInvalidPassword)
+	assert.ErrorIs(t, guard.Check(ctx, link, "wrong"), password.ErrInvalidPassword)
+
+	// Attempts are throttled per alias, even with the right password.
+	assert.ErrorIs(t, guard.Check(ctx, link, "open sesame"), password.ErrTooManyAttempts)
+
+	other := link
+	other.Alias = "other"
+	assert.NoError(t, guard.Check(ctx, other, "open sesame"))
+}
+
+func TestGuard_Cookie(t *testing.T) {
+	guard := password.NewGuard("secret", config.Protection{UnlockTTL: time.Hour, Attempts: 3, AttemptsRefill: time.Minute}, plainHTTP)
+	link := newLink(t, "open sesame")
+
+	rr := httptest.NewRecorder()
+	guard.SetCookie(rr, httptest.NewRequest(http.MethodPost, "/docs", nil), link)
+
+	cookies := rr.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, password.CookieName, cookies[0].Name)
+	assert.Equal(t, "/docs", cookies[0].Path)
+	assert.True(t, cookies[0].HttpOnly)
+	assert.False(t, cookies[0].Secure)
+
+	withCookie := func(c *http.Cookie) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+		req.AddCookie(c)
+		return req
+	}
+
+	assert.True(t, guard.Unlocked(withCookie(cookies[0]), link))
+	assert.False(t, guard.Unlocked(httptest.NewRequest(http.MethodGet, "/docs", nil), link))
+
+	other := link
+	other.Alias = "other"
+	assert.False(t, guard.Unlocked(withCookie(cookies[0]), other))
+
+	// A new password invalidates the cookies of the old one.
+	assert.False(t, guard.Unlocked(withCookie(cookies[0]), newLink(t, "new password")))
+
+	tampered := *cookies[0]
+	tampered.Value = "9999999999" + tampered.Value[len("9999999999"):]
+	assert.False(t, guard.Unlocked(withCookie(&tampered), link))
+
+	foreign := password.NewGuard("other secret", config.Protection{UnlockTTL: time.Hour, AttemptsRefill: time.Minute}, plainHTTP)
+	assert.False(t, foreign.Unlocked(withCookie(cookies[0]), link))
+}
+
+func TestGuard_CookieExpiry(t *testing.T) {
+	guard := password.NewGuard("secret", config.Protection{UnlockTTL: -time.Second, AttemptsRefill: time.Minute}, plainHTTP)
+	link := newLink(t, "open sesame")
+
+	rr := httptest.NewRecorder()
+	guard.SetCookie(rr, httptest.NewRequest(http.MethodPost, "/docs", nil), link)
+
+	req := httptest.NewRequest(http.MethodGet, "/docs", nil)
+	req.Header.Set("Cookie", password.CookieName+"="+rr.Result().Cookies()[0].Value)
+
+	assert.False(t, guard.Unlocked(req, link))
+}
+
+func TestGuard_SecureCookie(t *testing.T) {
+	guard := password.NewGuard(
+		"secret",
+		config.Protection{UnlockTTL: time.Hour, AttemptsRefill: time.Minute},
+		func(*http.Request) string { return "https" },
+	)
+
+	// Behind a TLS-terminating proxy the request itself is plain HTTP.
+	rr := httptest.NewRecorder()
+	guard.SetCookie(rr, httptest.NewRequest(http.MethodPost, "/docs", nil), newLink(t, "open sesame"))
+
+	cookies := rr.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.True(t, cookies[0].Secure)
+}
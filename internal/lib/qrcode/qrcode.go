@@ -0,0 +1,193 @@
+// Package qrcode renders QR codes as PNG and SVG images.
+package qrcode
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+
+	"rsc.io/qr"
+)
+
+type Format string
+
+const (
+	FormatPNG Format = "png"
+	FormatSVG Format = "svg"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown format")
+	ErrUnknownLevel  = errors.New("unknown error correction level")
+	ErrInvalidColor  = errors.New("invalid color")
+	// ErrTooSmall means the image cannot fit a pixel per module of the code.
+	ErrTooSmall = errors.New("size is too small for the code")
+)
+
+// Options control how a code is rendered.
+type Options struct {
+	// Size is the width and height of the image in pixels.
+	Size int
+	// Level is the error correction level, see ParseLevel.
+	Level qr.Level
+	// Margin is the width of the quiet zone around the code in modules.
+	Margin     int
+	Foreground color.NRGBA
+	Background color.NRGBA
+}
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case FormatPNG, FormatSVG:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownFormat, s)
+	}
+}
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	if f == FormatSVG {
+		return "image/svg+xml"
+	}
+
+	return "image/png"
+}
+
+// ParseLevel parses an error correction level: L, M, Q or H, from the least
+// to the most tolerant of damage.
+func ParseLevel(s string) (qr.Level, error) {
+	switch strings.ToUpper(s) {
+	case "L":
+		return qr.L, nil
+	case "M":
+		return qr.M, nil
+	case "Q":
+		return qr.Q, nil
+	case "H":
+		return qr.H, nil
+	default:
+		return 0, fmt.Errorf("%w: %q", ErrUnknownLevel, s)
+	}
+}
+
+// ParseColor parses a hex color in the RRGGBB or RRGGBBAA form, with an
+// optional leading '#'.
+func ParseColor(s string) (color.NRGBA, error) {
+	s = strings.TrimPrefix(s, "#")
+	if len(s) != 6 && len(s) != 8 {
+		return color.NRGBA{}, fmt.Errorf("%w: %q", ErrInvalidColor, s)
+	}
+
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("%w: %q", ErrInvalidColor, s)
+	}
+
+	c := color.NRGBA{R: b[0], G: b[1], B: b[2], A: 0xff}
+	if len(b) == 4 {
+		c.A = b[3]
+	}
+
+	return c, nil
+}
+
+// Encode writes the QR code of text to w in the given format.
+func Encode(w io.Writer, text string, f Format, opts Options) error {
+	code, err := qr.Encode(text, opts.Level)
+	if err != nil {
+		return err
+	}
+
+	modules := code.Size + 2*opts.Margin
+	if opts.Size < modules {
+		return ErrTooSmall
+	}
+
+	if f == FormatSVG {
+		return encodeSVG(w, code, modules, opts)
+	}
+
+	return encodePNG(w, code, modules, opts)
+}
+
+// encodePNG scales every module to the same whole number of pixels and
+// centers the code, so that its edges stay sharp.
+func encodePNG(w io.Writer, code *qr.Code, modules int, opts Options) error {
+	scale := opts.Size / modules
+	offset := (opts.Size-scale*modules)/2 + opts.Margin*scale
+
+	img := image.NewPaletted(
+		image.Rect(0, 0, opts.Size, opts.Size),
+		color.Palette{opts.Background, opts.Foreground},
+	)
+
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; x++ {
+			if !code.Black(x, y) {
+				continue
+			}
+
+			for py := 0; py < scale; py++ {
+				row := img.Pix[(offset+y*scale+py)*img.Stride:]
+				for px := 0; px < scale; px++ {
+					row[offset+x*scale+px] = 1
+				}
+			}
+		}
+	}
+
+	enc := png.Encoder{CompressionLevel: png.BestCompression}
+
+	return enc.Encode(w, img)
+}
+
+// encodeSVG draws the dark modules as a single path in a viewBox measured in
+// modules, so that the image scales without losing sharpness.
+func encodeSVG(w io.Writer, code *qr.Code, modules int, opts Options) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		opts.Size, opts.Size, modules, modules)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d"%s/>`, modules, modules, fill(opts.Background))
+	b.WriteString(`<path d="`)
+
+	for y := 0; y < code.Size; y++ {
+		for x := 0; x < code.Size; {
+			if !code.Black(x, y) {
+				x++
+				continue
+			}
+
+			run := 1
+			for code.Black(x+run, y) {
+				run++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%dz", x+opts.Margin, y+opts.Margin, run, run)
+			x += run
+		}
+	}
+
+	fmt.Fprintf(&b, `"%s/></svg>`, fill(opts.Foreground))
+	b.WriteByte('\n')
+
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// fill returns the SVG fill attributes of c.
+func fill(c color.NRGBA) string {
+	attr := fmt.Sprintf(` fill="#%02x%02x%02x"`, c.R, c.G, c.B)
+	if c.A != 0xff {
+		attr += ` fill-opacity="` + strconv.FormatFloat(float64(c.A)/0xff, 'f', 3, 64) + `"`
+	}
+
+	return attr
+}
@@ -0,0 +1,93 @@
+package qrcode_test
+
+import (
+	"bytes"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/Braendie/url-shortener/internal/lib/qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"rsc.io/qr"
+)
+
+var (
+	black = color.NRGBA{A: 0xff}
+	white = color.NRGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+)
+
+func TestEncode_PNG(t *testing.T) {
+	opts := qrcode.Options{Size: 300, Level: qr.M, Margin: 4, Foreground: black, Background: white}
+
+	var buf bytes.Buffer
+	require.NoError(t, qrcode.Encode(&buf, "https://sho.rt/abc", qrcode.FormatPNG, opts))
+
+	img, err := png.Decode(&buf)
+	require.NoError(t, err)
+	assert.Equal(t, 300, img.Bounds().Dx())
+	assert.Equal(t, 300, img.Bounds().Dy())
+
+	code, err := qr.Encode("https://sho.rt/abc", qr.M)
+	require.NoError(t, err)
+
+	modules := code.Size + 2*opts.Margin
+	scale := opts.Size / modules
+	start := (opts.Size-scale*modules)/2 + opts.Margin*scale
+
+	toNRGBA := func(x, y int) color.NRGBA { return color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA) }
+	assert.Equal(t, white, toNRGBA(0, 0))
+	assert.Equal(t, white, toNRGBA(start-1, start-1))
+	// The top-left finder pattern starts right after the quiet zone.
+	assert.Equal(t, black, toNRGBA(start, start))
+	assert.Equal(t, black, toNRGBA(start+7*scale-1, start))
+	assert.Equal(t, white, toNRGBA(start+7*scale, start))
+}
+
+func TestEncode_SVG(t *testing.T) {
+	fg := color.NRGBA{R: 0x11, G: 0x22, B: 0x33, A: 0xff}
+	bg := color.NRGBA{}
+	opts := qrcode.Options{Size: 128, Level: qr.L, Margin: 2, Foreground: fg, Background: bg}
+
+	var buf bytes.Buffer
+	require.NoError(t, qrcode.Encode(&buf, "https://sho.rt/abc", qrcode.FormatSVG, opts))
+
+	svg := buf.String()
+	assert.True(t, strings.HasPrefix(svg, `<svg xmlns="http://www.w3.org/2000/svg" width="128" height="128" viewBox="0 0 29 29"`))
+	assert.Contains(t, svg, `fill="#000000" fill-opacity="0.000"`)
+	assert.Contains(t, svg, `fill="#112233"/>`)
+	// The top-left finder pattern is a run of 7 modules inside the margin.
+	assert.Contains(t, svg, `<path d="M2 2h7v1h-7z`)
+}
+
+func TestEncode_TooSmall(t *testing.T) {
+	opts := qrcode.Options{Size: 20, Level: qr.H, Margin: 4, Foreground: black, Background: white}
+
+	err := qrcode.Encode(&bytes.Buffer{}, "https://sho.rt/abc", qrcode.FormatPNG, opts)
+	assert.ErrorIs(t, err, qrcode.ErrTooSmall)
+}
+
+func TestParseColor(t *testing.T) {
+	c, err := qrcode.ParseColor("#ff8000")
+	require.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 0xff, G: 0x80, A: 0xff}, c)
+
+	c, err = qrcode.ParseColor("ffffff00")
+	require.NoError(t, err)
+	assert.Equal(t, color.NRGBA{R: 0xff, G: 0xff, B: 0xff}, c)
+
+	for _, s := range []string{"", "fff", "red", "gg0000", "#1234567"} {
+		_, err := qrcode.ParseColor(s)
+		assert.ErrorIs(t, err, qrcode.ErrInvalidColor, s)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	l, err := qrcode.ParseLevel("q")
+	require.NoError(t, err)
+	assert.Equal(t, qr.Q, l)
+
+	_, err = qrcode.ParseLevel("X")
+	assert.ErrorIs(t, err, qrcode.ErrUnknownLevel)
+}
@@ -5,16 +5,22 @@ import (
 	"math/big"
 )
 
-const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+// Base62 is the default alphabet of random strings.
+const Base62 = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
 func NewRandomString(aliasLength int) (string, error) {
-	b := make([]byte, aliasLength)
+	return NewString(Base62, aliasLength)
+}
+
+// NewString returns a random string of the given length made of the bytes of alphabet.
+func NewString(alphabet string, length int) (string, error) {
+	b := make([]byte, length)
 	for i := range b {
-		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
 		if err != nil {
 			return "", err
 		}
-		b[i] = letters[n.Int64()]
+		b[i] = alphabet[n.Int64()]
 	}
 	return string(b), nil
 }
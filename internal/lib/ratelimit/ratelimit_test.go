@@ -0,0 +1,54 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/lib/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_Take(t *testing.T) {
+	s := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Rate: 0.5, Burst: 2}
+
+	for _, remaining := range []int{1, 0} {
+		res, err := s.Take(context.Background(), "client", limit)
+		require.NoError(t, err)
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 2, res.Limit)
+		assert.Equal(t, remaining, res.Remaining)
+	}
+
+	res, err := s.Take(context.Background(), "client", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.InDelta(t, 2*time.Second, res.RetryAfter, float64(100*time.Millisecond))
+	assert.InDelta(t, 4*time.Second, res.Reset, float64(100*time.Millisecond))
+
+	// Buckets are independent per key.
+	res, err = s.Take(context.Background(), "other", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+}
+
+func TestMemoryStore_Refill(t *testing.T) {
+	s := ratelimit.NewMemoryStore()
+	limit := ratelimit.Limit{Rate: 50, Burst: 1}
+
+	res, err := s.Take(context.Background(), "client", limit)
+	require.NoError(t, err)
+	require.True(t, res.Allowed)
+
+	res, err = s.Take(context.Background(), "client", limit)
+	require.NoError(t, err)
+	require.False(t, res.Allowed)
+
+	time.Sleep(res.RetryAfter + 10*time.Millisecond)
+
+	res, err = s.Take(context.Background(), "client", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+}
@@ -0,0 +1,209 @@
+// Package shorturl builds the public links of aliases.
+package shorturl
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+)
+
+var ErrInvalidBaseURL = errors.New("base url must be an absolute http or https url")
+
+// Builder builds the short link of an alias from the configured base URL or,
+// without one, from the request: its Host and whether it came over TLS.
+// Requests from trusted proxies may override both with the X-Forwarded-Host
+// and X-Forwarded-Proto headers, and the client address with
+// X-Forwarded-For.
+type Builder struct {
+	base    string
+	baseURL *url.URL
+	proxies []netip.Prefix
+}
+
+// New parses the base URL and the trusted proxies, which are IP addresses or
+// CIDR ranges. An empty base URL makes short links follow the request.
+func New(baseURL string, trustedProxies []string) (*Builder, error) {
+	const op = "shorturl.New"
+
+	b := &Builder{}
+
+	if baseURL != "" {
+		u, err := url.Parse(baseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" ||
+			u.RawQuery != "" || u.Fragment != "" {
+			return nil, fmt.Errorf("%s: %w: %q", op, ErrInvalidBaseURL, baseURL)
+		}
+		b.base = strings.TrimSuffix(u.String(), "/")
+		b.baseURL = u
+	}
+
+	for _, p := range trustedProxies {
+		prefix, err := parsePrefix(p)
+		if err != nil {
+			return nil, fmt.Errorf("%s: trusted proxy %q: %w", op, p, err)
+		}
+		b.proxies = append(b.proxies, prefix)
+	}
+
+	return b, nil
+}
+
+// URL returns the short link of alias as seen by the client of r. Aliases of
+// a custom domain link to that domain; the default domain is empty.
+func (b *Builder) URL(r *http.Request, domain string, alias string) string {
+	if domain != "" {
+		return b.Scheme(r) + "://" + domain + "/" + url.PathEscape(alias)
+	}
+
+	return b.origin(r) + "/" + url.PathEscape(alias)
+}
+
+// Scheme returns the scheme of short links as seen by the client of r: that
+// of the base URL or, without one, of the request.
+func (b *Builder) Scheme(r *http.Request) string {
+	if b.baseURL != nil {
+		return b.baseURL.Scheme
+	}
+
+	scheme, _ := b.request(r)
+
+	return scheme
+}
+
+// Host returns the lower-cased host name, without a port, that the client of
+// r addressed.
+func (b *Builder) Host(r *http.Request) string {
+	_, host := b.request(r)
+
+	return hostname(host)
+}
+
+// ClientIP returns the address of the client of r. Behind trusted proxies it
+// is the rightmost X-Forwarded-For address that is not a trusted proxy
+// itself: the addresses left of it are sent by the client and can be forged.
+func (b *Builder) ClientIP(r *http.Request) string {
+	ip := hostname(r.RemoteAddr)
+	if !b.trusted(r.RemoteAddr) {
+		return ip
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if _, err := netip.ParseAddr(hop); err != nil {
+			break
+		}
+
+		ip = strings.ToLower(hop)
+		if !b.trusted(hop) {
+			break
+		}
+	}
+
+	return ip
+}
+
+// DefaultHost returns the host name of the base URL, or an empty string
+// without one.
+func (b *Builder) DefaultHost() string {
+	if b.baseURL == nil {
+		return ""
+	}
+
+	return hostname(b.baseURL.Host)
+}
+
+// origin returns what short links of r start with, without a trailing slash.
+func (b *Builder) origin(r *http.Request) string {
+	if b.base != "" {
+		return b.base
+	}
+
+	scheme, host := b.request(r)
+
+	return scheme + "://" + host
+}
+
+// request returns the scheme and host of r as the client sent them.
+func (b *Builder) request(r *http.Request) (scheme string, host string) {
+	scheme = "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	host = r.Host
+
+	if b.trusted(r.RemoteAddr) {
+		if proto := strings.ToLower(first(r.Header.Get("X-Forwarded-Proto"))); proto == "http" || proto == "https" {
+			scheme = proto
+		}
+		if fwdHost := first(r.Header.Get("X-Forwarded-Host")); fwdHost != "" {
+			host = fwdHost
+		}
+	}
+
+	return scheme, host
+}
+
+func (b *Builder) trusted(remoteAddr string) bool {
+	if len(b.proxies) == 0 {
+		return false
+	}
+
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, p := range b.proxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parsePrefix(s string) (netip.Prefix, error) {
+	if strings.Contains(s, "/") {
+		p, err := netip.ParsePrefix(s)
+		if err != nil {
+			return netip.Prefix{}, err
+		}
+
+		return p.Masked(), nil
+	}
+
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func hostname(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	return strings.ToLower(strings.Trim(host, "[]"))
+}
+
+// first returns the leftmost value of a comma-separated header set by a chain
+// of proxies, which is the one the client sent to the first proxy.
+func first(header string) string {
+	value, _, _ := strings.Cut(header, ",")
+
+	return strings.TrimSpace(value)
+}
@@ -0,0 +1,183 @@
+package shorturl_test
+
+import (
+	"crypto/tls"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Braendie/url-shortener/internal/lib/shorturl"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder_URL(t *testing.T) {
+	testCases := []struct {
+		name       string
+		baseURL    string
+		proxies    []string
+		remoteAddr string
+		tls        bool
+		headers    map[string]string
+		domain     string
+		want       string
+	}{
+		{
+			name: "request host",
+			want: "http://example.com/abc",
+		},
+		{
+			name: "tls",
+			tls:  true,
+			want: "https://example.com/abc",
+		},
+		{
+			name:    "base url",
+			baseURL: "https://sho.rt/",
+			headers: map[string]string{"X-Forwarded-Host": "evil.com"},
+			want:    "https://sho.rt/abc",
+		},
+		{
+			name:    "base url with path",
+			baseURL: "https://example.com/s",
+			want:    "https://example.com/s/abc",
+		},
+		{
+			name:    "forwarded by untrusted client",
+			headers: map[string]string{"X-Forwarded-Host": "evil.com", "X-Forwarded-Proto": "https"},
+			want:    "http://example.com/abc",
+		},
+		{
+			name:       "forwarded by trusted proxy",
+			proxies:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.1.2.3:4567",
+			headers:    map[string]string{"X-Forwarded-Host": "sho.rt, internal", "X-Forwarded-Proto": "HTTPS"},
+			want:       "https://sho.rt/abc",
+		},
+		{
+			name:       "trusted proxy address",
+			proxies:    []string{"::1"},
+			remoteAddr: "[::1]:4567",
+			headers:    map[string]string{"X-Forwarded-Proto": "https"},
+			want:       "https://example.com/abc",
+		},
+		{
+			name:       "unsupported forwarded proto",
+			proxies:    []string{"10.0.0.0/8"},
+			remoteAddr: "10.1.2.3:4567",
+			headers:    map[string]string{"X-Forwarded-Proto": "ftp"},
+			want:       "http://example.com/abc",
+		},
+		{
+			name:   "custom domain",
+			tls:    true,
+			domain: "go.example.org",
+			want:   "https://go.example.org/abc",
+		},
+		{
+			name:    "custom domain with base url",
+			baseURL: "https://sho.rt/s",
+			domain:  "go.example.org",
+			want:    "https://go.example.org/abc",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			b, err := shorturl.New(tc.baseURL, tc.proxies)
+			require.NoError(t, err)
+
+			r := httptest.NewRequest("GET", "/url", nil)
+			if tc.remoteAddr != "" {
+				r.RemoteAddr = tc.remoteAddr
+			}
+			if tc.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+			for k, v := range tc.headers {
+				r.Header.Set(k, v)
+			}
+
+			assert.Equal(t, tc.want, b.URL(r, tc.domain, "abc"))
+		})
+	}
+}
+
+func TestBuilder_Host(t *testing.T) {
+	b, err := shorturl.New("https://Sho.rt:8443/s", []string{"10.0.0.0/8"})
+	require.NoError(t, err)
+	assert.Equal(t, "sho.rt", b.DefaultHost())
+
+	r := httptest.NewRequest("GET", "/abc", nil)
+	r.Host = "Go.Example.org:8080"
+	assert.Equal(t, "go.example.org", b.Host(r))
+
+	r.Header.Set("X-Forwarded-Host", "other.org")
+	assert.Equal(t, "go.example.org", b.Host(r))
+
+	r.RemoteAddr = "10.1.2.3:4567"
+	assert.Equal(t, "other.org", b.Host(r))
+
+	b, err = shorturl.New("", nil)
+	require.NoError(t, err)
+	assert.Empty(t, b.DefaultHost())
+}
+
+func TestBuilder_Scheme(t *testing.T) {
+	b, err := shorturl.New("", []string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	r := httptest.NewRequest("GET", "/abc", nil)
+	r.Header.Set("X-Forwarded-Proto", "https")
+	assert.Equal(t, "http", b.Scheme(r))
+
+	r.RemoteAddr = "10.1.2.3:4567"
+	assert.Equal(t, "https", b.Scheme(r))
+
+	b, err = shorturl.New("https://sho.rt", nil)
+	require.NoError(t, err)
+	assert.Equal(t, "https", b.Scheme(httptest.NewRequest("GET", "/abc", nil)))
+}
+
+func TestBuilder_ClientIP(t *testing.T) {
+	b, err := shorturl.New("", []string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		want       string
+	}{
+		{name: "direct", remoteAddr: "203.0.113.7:4567", want: "203.0.113.7"},
+		{name: "untrusted proxy", remoteAddr: "203.0.113.7:4567", forwarded: "198.51.100.1", want: "203.0.113.7"},
+		{name: "trusted proxy", remoteAddr: "10.0.0.1:4567", forwarded: "198.51.100.1", want: "198.51.100.1"},
+		{name: "forged", remoteAddr: "10.0.0.1:4567", forwarded: "1.2.3.4, 198.51.100.1, 10.0.0.2", want: "198.51.100.1"},
+		{name: "only proxies", remoteAddr: "10.0.0.1:4567", forwarded: "10.0.0.3, 10.0.0.2", want: "10.0.0.3"},
+		{name: "no header", remoteAddr: "10.0.0.1:4567", want: "10.0.0.1"},
+		{name: "garbage", remoteAddr: "10.0.0.1:4567", forwarded: "198.51.100.1, unknown", want: "10.0.0.1"},
+		{name: "ipv6", remoteAddr: "[2001:DB8::1]:4567", want: "2001:db8::1"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/abc", nil)
+			r.RemoteAddr = tc.remoteAddr
+			if tc.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tc.forwarded)
+			}
+
+			assert.Equal(t, tc.want, b.ClientIP(r))
+		})
+	}
+}
+
+func TestNew_Invalid(t *testing.T) {
+	_, err := shorturl.New("sho.rt", nil)
+	assert.ErrorIs(t, err, shorturl.ErrInvalidBaseURL)
+
+	_, err = shorturl.New("https://sho.rt?a=b", nil)
+	assert.ErrorIs(t, err, shorturl.ErrInvalidBaseURL)
+
+	_, err = shorturl.New("", []string{"not an ip"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,46 @@
+package tracing_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/Braendie/url-shortener/internal/lib/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetup_FileExporter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+
+	shutdown, err := tracing.Setup(context.Background(), config.Tracing{
+		Exporter:    tracing.ExporterFile,
+		FilePath:    path,
+		SampleRatio: 1,
+		ServiceName: "url-shortener",
+	})
+	require.NoError(t, err)
+
+	ctx, span := tracing.Tracer().Start(context.Background(), "test")
+	traceID := tracing.TraceID(ctx)
+	span.End()
+
+	assert.Len(t, traceID, 32)
+
+	require.NoError(t, shutdown(context.Background()))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), traceID)
+}
+
+func TestSetup_UnknownExporter(t *testing.T) {
+	_, err := tracing.Setup(context.Background(), config.Tracing{Exporter: "jaeger"})
+	require.Error(t, err)
+}
+
+func TestTraceID_NoSpan(t *testing.T) {
+	assert.Empty(t, tracing.TraceID(context.Background()))
+}
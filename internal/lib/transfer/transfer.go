@@ -0,0 +1,424 @@
+// Package transfer encodes and decodes links for export and import.
+package transfer
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/storage"
+)
+
+type Format string
+
+const (
+	FormatJSON   Format = "json"
+	FormatCSV    Format = "csv"
+	FormatNDJSON Format = "ndjson"
+)
+
+var (
+	ErrUnknownFormat = errors.New("unknown format")
+	ErrInvalidRecord = errors.New("invalid record")
+)
+
+// csvHeader is the first line of a CSV export and the expected column order on import.
+var csvHeader = []string{
+	"alias", "url", "owner_id", "created_at", "expires_at", "redirect_code", "forward_query", "password_hash",
+	"max_clicks", "used_clicks", "generated",
+}
+
+// csvMinColumns is the number of columns of the oldest exports. The columns
+// added since are optional on import.
+const csvMinColumns = 5
+
+// Record is a link as it is exported.
+type Record struct {
+	Alias        string     `json:"alias"`
+	URL          string     `json:"url"`
+	OwnerID      int64      `json:"owner_id"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	RedirectCode int        `json:"redirect_code,omitempty"`
+	ForwardQuery bool       `json:"forward_query,omitempty"`
+	PasswordHash string     `json:"password_hash,omitempty"`
+	MaxClicks    int64      `json:"max_clicks,omitempty"`
+	// UsedClicks keeps exhausted links exhausted after a restore.
+	UsedClicks int64 `json:"used_clicks,omitempty"`
+	// Generated keeps restored links with generated aliases reusable for
+	// deduplication.
+	Generated bool `json:"generated,omitempty"`
+}
+
+func FromURL(u storage.URL) Record {
+	return Record{
+		Alias:        u.Alias,
+		URL:          u.URL,
+		OwnerID:      u.OwnerID,
+		CreatedAt:    u.CreatedAt,
+		ExpiresAt:    u.ExpiresAt,
+		RedirectCode: u.RedirectCode,
+		ForwardQuery: u.ForwardQuery,
+		PasswordHash: u.PasswordHash,
+		MaxClicks:    u.MaxClicks,
+		UsedClicks:   u.UsedClicks,
+		Generated:    u.Generated,
+	}
+}
+
+func (r Record) ToURL() storage.URL {
+	return storage.URL{
+		Alias:        r.Alias,
+		URL:          r.URL,
+		OwnerID:      r.OwnerID,
+		CreatedAt:    r.CreatedAt,
+		ExpiresAt:    r.ExpiresAt,
+		RedirectCode: r.RedirectCode,
+		ForwardQuery: r.ForwardQuery,
+		PasswordHash: r.PasswordHash,
+		MaxClicks:    r.MaxClicks,
+		UsedClicks:   r.UsedClicks,
+		Generated:    r.Generated,
+	}
+}
+
+// Validate checks the fields an imported record must have.
+func (r Record) Validate() error {
+	if r.Alias == "" {
+		return fmt.Errorf("%w: alias is empty", ErrInvalidRecord)
+	}
+
+	if u, err := url.ParseRequestURI(r.URL); err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("%w: alias %q: url is not valid", ErrInvalidRecord, r.Alias)
+	}
+
+	switch r.RedirectCode {
+	case 0, http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return fmt.Errorf("%w: alias %q: unsupported redirect_code %d", ErrInvalidRecord, r.Alias, r.RedirectCode)
+	}
+
+	if r.MaxClicks < 0 || r.UsedClicks < 0 {
+		return fmt.Errorf("%w: alias %q: click counts must not be negative", ErrInvalidRecord, r.Alias)
+	}
+
+	return nil
+}
+
+// AliasRules are the rules of custom aliases, see alias.Rules.
+type AliasRules interface {
+	Normalize(alias string) string
+	Check(alias string) error
+}
+
+// Prepare validates r for import and returns it with its alias normalized,
+// holding it to the same rules as the aliases users choose.
+func (r Record) Prepare(rules AliasRules) (Record, error) {
+	if err := r.Validate(); err != nil {
+		return r, err
+	}
+
+	r.Alias = rules.Normalize(r.Alias)
+	if err := rules.Check(r.Alias); err != nil {
+		return r, fmt.Errorf("%w: %w", ErrInvalidRecord, err)
+	}
+
+	return r, nil
+}
+
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatJSON, FormatCSV, FormatNDJSON:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnknownFormat, s)
+	}
+}
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatCSV:
+		return "text/csv"
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	default:
+		return "application/json"
+	}
+}
+
+// FormatFromContentType is the inverse of Format.ContentType.
+func FormatFromContentType(contentType string) (Format, bool) {
+	for _, f := range []Format{FormatJSON, FormatCSV, FormatNDJSON} {
+		if f.ContentType() == contentType {
+			return f, true
+		}
+	}
+
+	return "", false
+}
+
+// Encoder writes records one by one. Close must be called to finish the output.
+type Encoder interface {
+	Encode(r Record) error
+	Close() error
+}
+
+// Decoder reads records one by one and returns io.EOF after the last one.
+type Decoder interface {
+	Decode() (Record, error)
+}
+
+func NewEncoder(w io.Writer, f Format) Encoder {
+	switch f {
+	case FormatCSV:
+		return &csvEncoder{w: csv.NewWriter(w)}
+	case FormatNDJSON:
+		return &ndjsonEncoder{enc: json.NewEncoder(w)}
+	default:
+		return &jsonEncoder{w: w}
+	}
+}
+
+func NewDecoder(r io.Reader, f Format) Decoder {
+	switch f {
+	case FormatCSV:
+		return &csvDecoder{r: csv.NewReader(r)}
+	case FormatNDJSON:
+		return &ndjsonDecoder{dec: json.NewDecoder(r)}
+	default:
+		return &jsonDecoder{dec: json.NewDecoder(r)}
+	}
+}
+
+type jsonEncoder struct {
+	w     io.Writer
+	count int
+}
+
+func (e *jsonEncoder) Encode(r Record) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	sep := ",\n"
+	if e.count == 0 {
+		sep = "[\n"
+	}
+	e.count++
+
+	if _, err := io.WriteString(e.w, sep); err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+
+	return err
+}
+
+func (e *jsonEncoder) Close() error {
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+
+	_, err := io.WriteString(e.w, end)
+
+	return err
+}
+
+type ndjsonEncoder struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonEncoder) Encode(r Record) error {
+	return e.enc.Encode(r)
+}
+
+func (e *ndjsonEncoder) Close() error {
+	return nil
+}
+
+type csvEncoder struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func (e *csvEncoder) Encode(r Record) error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	expiresAt := ""
+	if r.ExpiresAt != nil {
+		expiresAt = r.ExpiresAt.Format(time.RFC3339)
+	}
+
+	return e.w.Write([]string{
+		r.Alias,
+		r.URL,
+		strconv.FormatInt(r.OwnerID, 10),
+		r.CreatedAt.Format(time.RFC3339),
+		expiresAt,
+		strconv.Itoa(r.RedirectCode),
+		strconv.FormatBool(r.ForwardQuery),
+		r.PasswordHash,
+		strconv.FormatInt(r.MaxClicks, 10),
+		strconv.FormatInt(r.UsedClicks, 10),
+		strconv.FormatBool(r.Generated),
+	})
+}
+
+func (e *csvEncoder) Close() error {
+	if err := e.writeHeader(); err != nil {
+		return err
+	}
+
+	e.w.Flush()
+
+	return e.w.Error()
+}
+
+func (e *csvEncoder) writeHeader() error {
+	if e.wroteHeader {
+		return nil
+	}
+	e.wroteHeader = true
+
+	return e.w.Write(csvHeader)
+}
+
+type jsonDecoder struct {
+	dec     *json.Decoder
+	started bool
+}
+
+func (d *jsonDecoder) Decode() (Record, error) {
+	if !d.started {
+		tok, err := d.dec.Token()
+		if err != nil {
+			return Record{}, err
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return Record{}, errors.New("expected a JSON array")
+		}
+		d.started = true
+	}
+
+	if !d.dec.More() {
+		if _, err := d.dec.Token(); err != nil {
+			return Record{}, err
+		}
+
+		return Record{}, io.EOF
+	}
+
+	var r Record
+	if err := d.dec.Decode(&r); err != nil {
+		return Record{}, err
+	}
+
+	return r, nil
+}
+
+type ndjsonDecoder struct {
+	dec *json.Decoder
+}
+
+func (d *ndjsonDecoder) Decode() (Record, error) {
+	var r Record
+	if err := d.dec.Decode(&r); err != nil {
+		return Record{}, err
+	}
+
+	return r, nil
+}
+
+type csvDecoder struct {
+	r          *csv.Reader
+	readHeader bool
+}
+
+func (d *csvDecoder) Decode() (Record, error) {
+	if !d.readHeader {
+		header, err := d.r.Read()
+		if err != nil {
+			return Record{}, err
+		}
+		if len(header) < csvMinColumns || len(header) > len(csvHeader) || !slices.Equal(header, csvHeader[:len(header)]) {
+			return Record{}, fmt.Errorf("unexpected csv header %v", header)
+		}
+		d.readHeader = true
+	}
+
+	row, err := d.r.Read()
+	if err != nil {
+		return Record{}, err
+	}
+
+	line, _ := d.r.FieldPos(0)
+
+	ownerID, err := strconv.ParseInt(row[2], 10, 64)
+	if err != nil {
+		return Record{}, fmt.Errorf("line %d: invalid owner_id: %w", line, err)
+	}
+
+	r := Record{Alias: row[0], URL: row[1], OwnerID: ownerID}
+
+	if row[3] != "" {
+		if r.CreatedAt, err = time.Parse(time.RFC3339, row[3]); err != nil {
+			return Record{}, fmt.Errorf("line %d: invalid created_at: %w", line, err)
+		}
+	}
+
+	if row[4] != "" {
+		expiresAt, err := time.Parse(time.RFC3339, row[4])
+		if err != nil {
+			return Record{}, fmt.Errorf("line %d: invalid expires_at: %w", line, err)
+		}
+		r.ExpiresAt = &expiresAt
+	}
+
+	if len(row) > 5 {
+		if r.RedirectCode, err = strconv.Atoi(row[5]); err != nil {
+			return Record{}, fmt.Errorf("line %d: invalid redirect_code: %w", line, err)
+		}
+	}
+
+	if len(row) > 6 {
+		if r.ForwardQuery, err = strconv.ParseBool(row[6]); err != nil {
+			return Record{}, fmt.Errorf("line %d: invalid forward_query: %w", line, err)
+		}
+	}
+
+	if len(row) > 7 {
+		r.PasswordHash = row[7]
+	}
+
+	if len(row) > 8 {
+		if r.MaxClicks, err = strconv.ParseInt(row[8], 10, 64); err != nil {
+			return Record{}, fmt.Errorf("line %d: invalid max_clicks: %w", line, err)
+		}
+	}
+
+	if len(row) > 9 {
+		if r.UsedClicks, err = strconv.ParseInt(row[9], 10, 64); err != nil {
+			return Record{}, fmt.Errorf("line %d: invalid used_clicks: %w", line, err)
+		}
+	}
+
+	if len(row) > 10 {
+		if r.Generated, err = strconv.ParseBool(row[10]); err != nil {
+			return Record{}, fmt.Errorf("line %d: invalid generated: %w", line, err)
+		}
+	}
+
+	return r, nil
+}
@@ -0,0 +1,167 @@
+package transfer_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/config"
+	"github.com/Braendie/url-shortener/internal/lib/alias"
+	"github.com/Braendie/url-shortener/internal/lib/transfer"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRoundTrip(t *testing.T) {
+	expiresAt := time.Date(2999, 1, 1, 0, 0, 0, 0, time.UTC)
+	records := []transfer.Record{
+		{
+			Alias:     "google",
+			URL:       "https://google.com/search?q=a,b",
+			OwnerID:   1,
+			CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			Alias:     "go",
+			URL:       "https://go.dev",
+			OwnerID:   2,
+			CreatedAt: time.Date(2024, 5, 2, 12, 0, 0, 0, time.UTC),
+			ExpiresAt: &expiresAt,
+		},
+		{
+			Alias:        "docs",
+			URL:          "https://go.dev/doc",
+			OwnerID:      2,
+			CreatedAt:    time.Date(2024, 5, 3, 12, 0, 0, 0, time.UTC),
+			RedirectCode: 301,
+			ForwardQuery: true,
+			PasswordHash: "$2a$10$abcdefghijklmnopqrstuv",
+			MaxClicks:    5,
+			UsedClicks:   2,
+		},
+		{
+			Alias:     "x7Kq2",
+			URL:       "https://go.dev/blog",
+			OwnerID:   3,
+			CreatedAt: time.Date(2024, 5, 4, 12, 0, 0, 0, time.UTC),
+			Generated: true,
+		},
+	}
+
+	for _, format := range []transfer.Format{transfer.FormatJSON, transfer.FormatCSV, transfer.FormatNDJSON} {
+		for _, n := range []int{0, len(records)} {
+			var buf bytes.Buffer
+
+			enc := transfer.NewEncoder(&buf, format)
+			for _, r := range records[:n] {
+				require.NoError(t, enc.Encode(r))
+			}
+			require.NoError(t, enc.Close())
+
+			dec := transfer.NewDecoder(&buf, format)
+
+			var got []transfer.Record
+			for {
+				r, err := dec.Decode()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				require.NoError(t, err, "format %s", format)
+				got = append(got, r)
+			}
+
+			assert.Equal(t, len(records[:n]), len(got), "format %s", format)
+			for i := range got {
+				assert.Equal(t, records[i], got[i], "format %s", format)
+			}
+		}
+	}
+}
+
+func TestRecord_URL(t *testing.T) {
+	u := storage.URL{Alias: "x7Kq2", URL: "https://go.dev", OwnerID: 1, Generated: true, UsedClicks: 2}
+
+	assert.Equal(t, u, transfer.FromURL(u).ToURL())
+}
+
+func TestDecoder_Invalid(t *testing.T) {
+	cases := []struct {
+		name   string
+		format transfer.Format
+		input  string
+	}{
+		{name: "json object", format: transfer.FormatJSON, input: `{"alias": "a"}`},
+		{name: "broken json", format: transfer.FormatJSON, input: `[{"alias": `},
+		{name: "broken ndjson", format: transfer.FormatNDJSON, input: "{\"alias\": \"a\"}\n{\n"},
+		{name: "csv header", format: transfer.FormatCSV, input: "url,alias\n"},
+		{name: "csv owner", format: transfer.FormatCSV, input: "alias,url,owner_id,created_at,expires_at\na,https://go.dev,x,,\n"},
+		{name: "csv columns", format: transfer.FormatCSV, input: "alias,url,owner_id,created_at,expires_at\na,https://go.dev\n"},
+		{
+			name:   "csv redirect code",
+			format: transfer.FormatCSV,
+			input:  "alias,url,owner_id,created_at,expires_at,redirect_code,forward_query\na,https://go.dev,1,,,x,false\n",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dec := transfer.NewDecoder(strings.NewReader(tc.input), tc.format)
+
+			var err error
+			for err == nil {
+				_, err = dec.Decode()
+			}
+
+			assert.NotErrorIs(t, err, io.EOF)
+		})
+	}
+}
+
+func TestRecord_Validate(t *testing.T) {
+	assert.NoError(t, transfer.Record{Alias: "a", URL: "https://go.dev"}.Validate())
+	assert.ErrorIs(t, transfer.Record{URL: "https://go.dev"}.Validate(), transfer.ErrInvalidRecord)
+	assert.ErrorIs(t, transfer.Record{Alias: "a", URL: "go.dev"}.Validate(), transfer.ErrInvalidRecord)
+	assert.ErrorIs(t, transfer.Record{Alias: "a", URL: "https://go.dev", RedirectCode: 303}.Validate(), transfer.ErrInvalidRecord)
+	assert.ErrorIs(t, transfer.Record{Alias: "a", URL: "https://go.dev", MaxClicks: -1}.Validate(), transfer.ErrInvalidRecord)
+}
+
+func TestRecord_Prepare(t *testing.T) {
+	rules := alias.NewRules(config.Alias{MinLength: 2, MaxLength: 8, CaseInsensitive: true})
+	rules.Reserve("url")
+
+	r, err := transfer.Record{Alias: "GoDev", URL: "https://go.dev"}.Prepare(rules)
+	require.NoError(t, err)
+	assert.Equal(t, "godev", r.Alias)
+
+	for _, a := range []string{"URL", "go.dev", "a", "go dev", "verylongalias"} {
+		_, err := transfer.Record{Alias: a, URL: "https://go.dev"}.Prepare(rules)
+		assert.ErrorIs(t, err, transfer.ErrInvalidRecord, a)
+	}
+
+	_, err = transfer.Record{Alias: "godev", URL: "go.dev"}.Prepare(rules)
+	assert.ErrorIs(t, err, transfer.ErrInvalidRecord)
+}
+
+func TestDecoder_LegacyCSV(t *testing.T) {
+	dec := transfer.NewDecoder(strings.NewReader("alias,url,owner_id,created_at,expires_at\na,https://go.dev,1,,\n"), transfer.FormatCSV)
+
+	r, err := dec.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, transfer.Record{Alias: "a", URL: "https://go.dev", OwnerID: 1}, r)
+
+	_, err = dec.Decode()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestParseFormat(t *testing.T) {
+	f, err := transfer.ParseFormat("csv")
+	require.NoError(t, err)
+	assert.Equal(t, transfer.FormatCSV, f)
+
+	_, err = transfer.ParseFormat("xml")
+	assert.ErrorIs(t, err, transfer.ErrUnknownFormat)
+}
@@ -0,0 +1,181 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/storage"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=URLStorage
+type URLStorage interface {
+	GetURLInfo(ctx context.Context, domain, alias string) (storage.URL, error)
+	SaveURL(ctx context.Context, url storage.URL) (int64, error)
+	SaveURLs(ctx context.Context, urls []storage.URL) ([]storage.SaveResult, error)
+	FindGeneratedURL(ctx context.Context, domain, url string, ownerID int64) (storage.URL, error)
+	DeleteURL(ctx context.Context, domain, alias string) error
+	UpdateURL(ctx context.Context, domain, alias string, upd storage.URLUpdate) (storage.URL, error)
+	UseClick(ctx context.Context, domain, alias string) error
+}
+
+type Stats struct {
+	Hits   uint64
+	Misses uint64
+	Size   int
+}
+
+// Cache is a read-through cache for alias lookups on the redirect path.
+//
+// Found links are kept for at most ttl and never past their own expiration
+// time; unknown, expired and exhausted aliases are remembered for negativeTTL. Entries
+// are invalidated when a link is created, deleted or updated through the cache, so
+// with several replicas a change made on another instance becomes visible
+// once the entry expires.
+type Cache struct {
+	next        URLStorage
+	lru         *lru
+	ttl         time.Duration
+	negativeTTL time.Duration
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+func New(next URLStorage, size int, ttl time.Duration, negativeTTL time.Duration) *Cache {
+	return &Cache{
+		next:        next,
+		lru:         newLRU(size),
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+	}
+}
+
+// GetURL implements redirect.URLGetter.
+func (c *Cache) GetURL(ctx context.Context, domain, alias string) (storage.URL, error) {
+	const op = "storage.cache.GetURL"
+
+	now := time.Now()
+	key := cacheKey(domain, alias)
+
+	if e, ok := c.lru.get(key, now); ok {
+		c.hits.Add(1)
+
+		if e.err != nil {
+			return storage.URL{}, fmt.Errorf("%s: %w", op, e.err)
+		}
+
+		return e.url, nil
+	}
+
+	c.misses.Add(1)
+
+	info, err := c.next.GetURLInfo(ctx, domain, alias)
+	if err != nil {
+		if errors.Is(err, storage.ErrURLNotFound) {
+			c.lru.set(entry{key: key, err: storage.ErrURLNotFound, expiresAt: now.Add(c.negativeTTL)})
+		}
+
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if info.Expired(now) {
+		c.lru.set(entry{key: key, err: storage.ErrURLExpired, expiresAt: now.Add(c.negativeTTL)})
+
+		return storage.URL{}, fmt.Errorf("%s: %w", op, storage.ErrURLExpired)
+	}
+
+	if info.Exhausted() {
+		c.lru.set(entry{key: key, err: storage.ErrURLExhausted, expiresAt: now.Add(c.negativeTTL)})
+
+		return storage.URL{}, fmt.Errorf("%s: %w", op, storage.ErrURLExhausted)
+	}
+
+	expiresAt := now.Add(c.ttl)
+	if info.ExpiresAt != nil && info.ExpiresAt.Before(expiresAt) {
+		expiresAt = *info.ExpiresAt
+	}
+
+	c.lru.set(entry{key: key, url: info, expiresAt: expiresAt})
+
+	return info, nil
+}
+
+// GetURLInfo reads the link from the underlying storage, bypassing the cache.
+func (c *Cache) GetURLInfo(ctx context.Context, domain, alias string) (storage.URL, error) {
+	return c.next.GetURLInfo(ctx, domain, alias)
+}
+
+// SaveURL saves the link and drops its alias from the cache, which may
+// remember it as unknown.
+func (c *Cache) SaveURL(ctx context.Context, u storage.URL) (int64, error) {
+	id, err := c.next.SaveURL(ctx, u)
+	c.lru.remove(cacheKey(u.Domain, u.Alias))
+
+	return id, err
+}
+
+// SaveURLs saves the links and drops the aliases of the saved ones from the cache.
+func (c *Cache) SaveURLs(ctx context.Context, urls []storage.URL) ([]storage.SaveResult, error) {
+	res, err := c.next.SaveURLs(ctx, urls)
+	for i, r := range res {
+		if r.Err == nil {
+			c.lru.remove(cacheKey(urls[i].Domain, urls[i].Alias))
+		}
+	}
+
+	return res, err
+}
+
+// FindGeneratedURL reads from the underlying storage, bypassing the cache.
+func (c *Cache) FindGeneratedURL(ctx context.Context, domain, url string, ownerID int64) (storage.URL, error) {
+	return c.next.FindGeneratedURL(ctx, domain, url, ownerID)
+}
+
+// DeleteURL deletes the link and drops it from the cache.
+func (c *Cache) DeleteURL(ctx context.Context, domain, alias string) error {
+	err := c.next.DeleteURL(ctx, domain, alias)
+	c.lru.remove(cacheKey(domain, alias))
+
+	return err
+}
+
+// UpdateURL updates the link and drops it from the cache.
+func (c *Cache) UpdateURL(ctx context.Context, domain, alias string, upd storage.URLUpdate) (storage.URL, error) {
+	u, err := c.next.UpdateURL(ctx, domain, alias, upd)
+	c.lru.remove(cacheKey(domain, alias))
+
+	return u, err
+}
+
+// UseClick implements redirect.ClickLimiter. Once the link is exhausted the
+// cache answers for it without reaching the storage.
+func (c *Cache) UseClick(ctx context.Context, domain, alias string) error {
+	err := c.next.UseClick(ctx, domain, alias)
+	if errors.Is(err, storage.ErrURLExhausted) {
+		c.lru.set(entry{key: cacheKey(domain, alias), err: storage.ErrURLExhausted, expiresAt: time.Now().Add(c.negativeTTL)})
+	}
+
+	return err
+}
+
+// Invalidate drops the alias of the domain from the cache.
+func (c *Cache) Invalidate(domain, alias string) {
+	c.lru.remove(cacheKey(domain, alias))
+}
+
+// cacheKey identifies an alias across domains. Neither domains nor aliases
+// contain a slash.
+func cacheKey(domain, alias string) string {
+	return domain + "/" + alias
+}
+
+func (c *Cache) Stats() Stats {
+	return Stats{
+		Hits:   c.hits.Load(),
+		Misses: c.misses.Load(),
+		Size:   c.lru.len(),
+	}
+}
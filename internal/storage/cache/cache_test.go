@@ -0,0 +1,237 @@
+package cache_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/Braendie/url-shortener/internal/storage/cache"
+	"github.com/Braendie/url-shortener/internal/storage/cache/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCache_GetURL(t *testing.T) {
+	ctx := context.Background()
+
+	past := time.Now().Add(-time.Minute)
+
+	testCases := []struct {
+		name      string
+		info      storage.URL
+		mockError error
+		wantError error
+	}{
+		{
+			name: "found",
+			info: storage.URL{Alias: "alias", URL: "https://google.com"},
+		},
+		{
+			name:      "not found",
+			mockError: storage.ErrURLNotFound,
+			wantError: storage.ErrURLNotFound,
+		},
+		{
+			name:      "expired",
+			info:      storage.URL{Alias: "alias", URL: "https://google.com", ExpiresAt: &past},
+			wantError: storage.ErrURLExpired,
+		},
+		{
+			name:      "exhausted",
+			info:      storage.URL{Alias: "alias", URL: "https://google.com", MaxClicks: 1, UsedClicks: 1},
+			wantError: storage.ErrURLExhausted,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			storageMock := mocks.NewURLStorage(t)
+			storageMock.On("GetURLInfo", mock.Anything, "", "alias").Return(tc.info, tc.mockError).Once()
+
+			c := cache.New(storageMock, 10, time.Minute, time.Minute)
+
+			for i := 0; i < 2; i++ {
+				u, err := c.GetURL(ctx, "", "alias")
+				if tc.wantError != nil {
+					require.ErrorIs(t, err, tc.wantError)
+					continue
+				}
+
+				require.NoError(t, err)
+				assert.Equal(t, tc.info.URL, u.URL)
+			}
+
+			assert.Equal(t, cache.Stats{Hits: 1, Misses: 1, Size: 1}, c.Stats())
+		})
+	}
+}
+
+func TestCache_StorageErrorIsNotCached(t *testing.T) {
+	ctx := context.Background()
+
+	storageMock := mocks.NewURLStorage(t)
+	storageMock.On("GetURLInfo", mock.Anything, "", "alias").Return(storage.URL{}, errors.New("some error")).Twice()
+
+	c := cache.New(storageMock, 10, time.Minute, time.Minute)
+
+	for i := 0; i < 2; i++ {
+		_, err := c.GetURL(ctx, "", "alias")
+		require.Error(t, err)
+	}
+
+	assert.Equal(t, 0, c.Stats().Size)
+}
+
+func TestCache_TTL(t *testing.T) {
+	ctx := context.Background()
+
+	soon := time.Now().Add(20 * time.Millisecond)
+
+	storageMock := mocks.NewURLStorage(t)
+	storageMock.On("GetURLInfo", mock.Anything, "", "short").Return(storage.URL{URL: "https://a.com"}, nil).Twice()
+	storageMock.On("GetURLInfo", mock.Anything, "", "expiring").Return(storage.URL{URL: "https://b.com", ExpiresAt: &soon}, nil).Twice()
+
+	c := cache.New(storageMock, 10, 20*time.Millisecond, time.Minute)
+
+	for _, alias := range []string{"short", "expiring"} {
+		_, err := c.GetURL(ctx, "", alias)
+		require.NoError(t, err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	for _, alias := range []string{"short", "expiring"} {
+		_, err := c.GetURL(ctx, "", alias)
+		if alias == "expiring" {
+			require.ErrorIs(t, err, storage.ErrURLExpired)
+			continue
+		}
+		require.NoError(t, err)
+	}
+}
+
+func TestCache_Eviction(t *testing.T) {
+	ctx := context.Background()
+
+	storageMock := mocks.NewURLStorage(t)
+	storageMock.On("GetURLInfo", mock.Anything, "", "a").Return(storage.URL{URL: "https://a.com"}, nil).Twice()
+	storageMock.On("GetURLInfo", mock.Anything, "", "b").Return(storage.URL{URL: "https://b.com"}, nil).Once()
+	storageMock.On("GetURLInfo", mock.Anything, "", "c").Return(storage.URL{URL: "https://c.com"}, nil).Once()
+
+	c := cache.New(storageMock, 2, time.Minute, time.Minute)
+
+	// "a" is the least recently used entry once "b" is read again, so "c" evicts it.
+	for _, alias := range []string{"a", "b", "b", "c", "b", "c", "a"} {
+		_, err := c.GetURL(ctx, "", alias)
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, 2, c.Stats().Size)
+}
+
+func TestCache_Domains(t *testing.T) {
+	ctx := context.Background()
+
+	storageMock := mocks.NewURLStorage(t)
+	storageMock.On("GetURLInfo", mock.Anything, "", "alias").Return(storage.URL{URL: "https://a.com"}, nil).Once()
+	storageMock.On("GetURLInfo", mock.Anything, "go.example.com", "alias").
+		Return(storage.URL{URL: "https://b.com"}, nil).Once()
+
+	c := cache.New(storageMock, 10, time.Minute, time.Minute)
+
+	for range 2 {
+		u, err := c.GetURL(ctx, "", "alias")
+		require.NoError(t, err)
+		assert.Equal(t, "https://a.com", u.URL)
+
+		u, err = c.GetURL(ctx, "go.example.com", "alias")
+		require.NoError(t, err)
+		assert.Equal(t, "https://b.com", u.URL)
+	}
+}
+
+func TestCache_Invalidation(t *testing.T) {
+	ctx := context.Background()
+
+	newURL := "https://new.com"
+
+	storageMock := mocks.NewURLStorage(t)
+	storageMock.On("GetURLInfo", mock.Anything, "", "alias").Return(storage.URL{URL: "https://old.com"}, nil).Once()
+	storageMock.On("UpdateURL", mock.Anything, "", "alias", mock.AnythingOfType("storage.URLUpdate")).
+		Return(storage.URL{URL: newURL}, nil).Once()
+	storageMock.On("GetURLInfo", mock.Anything, "", "alias").Return(storage.URL{URL: newURL}, nil).Once()
+	storageMock.On("DeleteURL", mock.Anything, "", "alias").Return(nil).Once()
+	storageMock.On("GetURLInfo", mock.Anything, "", "alias").Return(storage.URL{}, storage.ErrURLNotFound).Once()
+
+	c := cache.New(storageMock, 10, time.Minute, time.Minute)
+
+	u, err := c.GetURL(ctx, "", "alias")
+	require.NoError(t, err)
+	assert.Equal(t, "https://old.com", u.URL)
+
+	_, err = c.UpdateURL(ctx, "", "alias", storage.URLUpdate{URL: &newURL})
+	require.NoError(t, err)
+
+	u, err = c.GetURL(ctx, "", "alias")
+	require.NoError(t, err)
+	assert.Equal(t, newURL, u.URL)
+
+	require.NoError(t, c.DeleteURL(ctx, "", "alias"))
+
+	_, err = c.GetURL(ctx, "", "alias")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestCache_SaveInvalidates(t *testing.T) {
+	ctx := context.Background()
+
+	storageMock := mocks.NewURLStorage(t)
+	storageMock.On("GetURLInfo", mock.Anything, "", mock.Anything).Return(storage.URL{}, storage.ErrURLNotFound).Twice()
+	storageMock.On("SaveURLs", mock.Anything, mock.Anything).
+		Return([]storage.SaveResult{{ID: 1}, {Err: storage.ErrURLExists}}, nil).Once()
+	storageMock.On("GetURLInfo", mock.Anything, "", "saved").Return(storage.URL{URL: "https://google.com"}, nil).Once()
+
+	c := cache.New(storageMock, 10, time.Minute, time.Minute)
+
+	for _, alias := range []string{"saved", "taken"} {
+		_, err := c.GetURL(ctx, "", alias)
+		require.ErrorIs(t, err, storage.ErrURLNotFound)
+	}
+
+	_, err := c.SaveURLs(ctx, []storage.URL{{Alias: "saved"}, {Alias: "taken"}})
+	require.NoError(t, err)
+
+	u, err := c.GetURL(ctx, "", "saved")
+	require.NoError(t, err)
+	assert.Equal(t, "https://google.com", u.URL)
+
+	// Aliases that failed to save are still answered from the cache.
+	_, err = c.GetURL(ctx, "", "taken")
+	require.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestCache_UseClick(t *testing.T) {
+	ctx := context.Background()
+
+	storageMock := mocks.NewURLStorage(t)
+	storageMock.On("GetURLInfo", mock.Anything, "", "alias").
+		Return(storage.URL{URL: "https://google.com", MaxClicks: 1}, nil).Once()
+	storageMock.On("UseClick", mock.Anything, "", "alias").Return(storage.ErrURLExhausted).Once()
+
+	c := cache.New(storageMock, 10, time.Minute, time.Minute)
+
+	_, err := c.GetURL(ctx, "", "alias")
+	require.NoError(t, err)
+
+	require.ErrorIs(t, c.UseClick(ctx, "", "alias"), storage.ErrURLExhausted)
+
+	_, err = c.GetURL(ctx, "", "alias")
+	require.ErrorIs(t, err, storage.ErrURLExhausted)
+}
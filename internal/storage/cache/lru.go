@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/storage"
+)
+
+type entry struct {
+	key       string
+	url       storage.URL
+	err       error
+	expiresAt time.Time
+}
+
+// lru is a fixed size least recently used cache with per-entry expiration.
+type lru struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List
+	items map[string]*list.Element
+}
+
+func newLRU(size int) *lru {
+	return &lru{
+		size:  size,
+		order: list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+func (l *lru) get(key string, now time.Time) (entry, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	el, ok := l.items[key]
+	if !ok {
+		return entry{}, false
+	}
+
+	e := el.Value.(entry)
+	if !now.Before(e.expiresAt) {
+		l.order.Remove(el)
+		delete(l.items, key)
+
+		return entry{}, false
+	}
+
+	l.order.MoveToFront(el)
+
+	return e, true
+}
+
+func (l *lru) set(e entry) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if el, ok := l.items[e.key]; ok {
+		el.Value = e
+		l.order.MoveToFront(el)
+
+		return
+	}
+
+	l.items[e.key] = l.order.PushFront(e)
+
+	if l.order.Len() > l.size {
+		oldest := l.order.Back()
+		l.order.Remove(oldest)
+		delete(l.items, oldest.Value.(entry).key)
+	}
+}
+
+func (l *lru) remove(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if el, ok := l.items[key]; ok {
+		l.order.Remove(el)
+		delete(l.items, key)
+	}
+}
+
+func (l *lru) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.order.Len()
+}
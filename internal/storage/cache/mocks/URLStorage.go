@@ -0,0 +1,207 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	storage "github.com/Braendie/url-shortener/internal/storage"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// URLStorage is an autogenerated mock type for the URLStorage type
+type URLStorage struct {
+	mock.Mock
+}
+
+// DeleteURL provides a mock function with given fields: ctx, domain, alias
+func (_m *URLStorage) DeleteURL(ctx context.Context, domain string, alias string) error {
+	ret := _m.Called(ctx, domain, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteURL")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, domain, alias)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// FindGeneratedURL provides a mock function with given fields: ctx, domain, url, ownerID
+func (_m *URLStorage) FindGeneratedURL(ctx context.Context, domain string, url string, ownerID int64) (storage.URL, error) {
+	ret := _m.Called(ctx, domain, url, ownerID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindGeneratedURL")
+	}
+
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64) (storage.URL, error)); ok {
+		return rf(ctx, domain, url, ownerID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int64) storage.URL); ok {
+		r0 = rf(ctx, domain, url, ownerID)
+	} else {
+		r0 = ret.Get(0).(storage.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int64) error); ok {
+		r1 = rf(ctx, domain, url, ownerID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetURLInfo provides a mock function with given fields: ctx, domain, alias
+func (_m *URLStorage) GetURLInfo(ctx context.Context, domain string, alias string) (storage.URL, error) {
+	ret := _m.Called(ctx, domain, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetURLInfo")
+	}
+
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) (storage.URL, error)); ok {
+		return rf(ctx, domain, alias)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) storage.URL); ok {
+		r0 = rf(ctx, domain, alias)
+	} else {
+		r0 = ret.Get(0).(storage.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, domain, alias)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveURL provides a mock function with given fields: ctx, url
+func (_m *URLStorage) SaveURL(ctx context.Context, url storage.URL) (int64, error) {
+	ret := _m.Called(ctx, url)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveURL")
+	}
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, storage.URL) (int64, error)); ok {
+		return rf(ctx, url)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, storage.URL) int64); ok {
+		r0 = rf(ctx, url)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, storage.URL) error); ok {
+		r1 = rf(ctx, url)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SaveURLs provides a mock function with given fields: ctx, urls
+func (_m *URLStorage) SaveURLs(ctx context.Context, urls []storage.URL) ([]storage.SaveResult, error) {
+	ret := _m.Called(ctx, urls)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveURLs")
+	}
+
+	var r0 []storage.SaveResult
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []storage.URL) ([]storage.SaveResult, error)); ok {
+		return rf(ctx, urls)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []storage.URL) []storage.SaveResult); ok {
+		r0 = rf(ctx, urls)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.SaveResult)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []storage.URL) error); ok {
+		r1 = rf(ctx, urls)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateURL provides a mock function with given fields: ctx, domain, alias, upd
+func (_m *URLStorage) UpdateURL(ctx context.Context, domain string, alias string, upd storage.URLUpdate) (storage.URL, error) {
+	ret := _m.Called(ctx, domain, alias, upd)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateURL")
+	}
+
+	var r0 storage.URL
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, storage.URLUpdate) (storage.URL, error)); ok {
+		return rf(ctx, domain, alias, upd)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, storage.URLUpdate) storage.URL); ok {
+		r0 = rf(ctx, domain, alias, upd)
+	} else {
+		r0 = ret.Get(0).(storage.URL)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, storage.URLUpdate) error); ok {
+		r1 = rf(ctx, domain, alias, upd)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UseClick provides a mock function with given fields: ctx, domain, alias
+func (_m *URLStorage) UseClick(ctx context.Context, domain string, alias string) error {
+	ret := _m.Called(ctx, domain, alias)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UseClick")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = rf(ctx, domain, alias)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewURLStorage creates a new instance of URLStorage. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewURLStorage(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *URLStorage {
+	mock := &URLStorage{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
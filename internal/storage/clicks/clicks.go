@@ -0,0 +1,137 @@
+package clicks
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+	"github.com/Braendie/url-shortener/internal/storage"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=ClicksSaver
+type ClicksSaver interface {
+	SaveClicks(ctx context.Context, clicks []storage.Click) error
+}
+
+// Writer buffers clicks in memory and saves them in batches from a background
+// goroutine, so that recording a click never blocks the redirect.
+type Writer struct {
+	log           *slog.Logger
+	saver         ClicksSaver
+	ipKey         []byte
+	batchSize     int
+	flushInterval time.Duration
+
+	queue    chan storage.Click
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// New creates a writer. ipKey is the secret used to hash client addresses.
+func New(
+	log *slog.Logger,
+	saver ClicksSaver,
+	ipKey string,
+	bufferSize int,
+	batchSize int,
+	flushInterval time.Duration,
+) *Writer {
+	return &Writer{
+		log:           log.With(slog.String("component", "clicks")),
+		saver:         saver,
+		ipKey:         []byte(ipKey),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		queue:         make(chan storage.Click, bufferSize),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+}
+
+// RecordClick enqueues a click. If the buffer is full the click is dropped.
+func (w *Writer) RecordClick(click storage.Click) {
+	click.IPHash = w.hashIP(click.ClientIP)
+	click.ClientIP = ""
+
+	select {
+	case w.queue <- click:
+	default:
+		w.log.Warn("clicks buffer is full, click dropped", slog.String("alias", click.Alias))
+	}
+}
+
+// Start runs the flush loop in a separate goroutine until Stop is called.
+func (w *Writer) Start() {
+	go w.run()
+}
+
+// Stop flushes buffered clicks and waits for the flush loop to exit.
+func (w *Writer) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stop)
+	})
+	<-w.done
+}
+
+func (w *Writer) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]storage.Click, 0, w.batchSize)
+
+	for {
+		select {
+		case click := <-w.queue:
+			batch = append(batch, click)
+			if len(batch) >= w.batchSize {
+				batch = w.flush(batch)
+			}
+		case <-ticker.C:
+			batch = w.flush(batch)
+		case <-w.stop:
+			for {
+				select {
+				case click := <-w.queue:
+					batch = append(batch, click)
+				default:
+					w.flush(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+func (w *Writer) flush(batch []storage.Click) []storage.Click {
+	const op = "storage.clicks.flush"
+
+	if len(batch) == 0 {
+		return batch
+	}
+
+	if err := w.saver.SaveClicks(context.Background(), batch); err != nil {
+		w.log.Error("failed to save clicks", slog.String("op", op), slog.Int("count", len(batch)), sl.Err(err))
+	}
+
+	// The saver may keep the slice, so the next batch gets a fresh one.
+	return make([]storage.Click, 0, w.batchSize)
+}
+
+func (w *Writer) hashIP(ip string) string {
+	if ip == "" {
+		return ""
+	}
+
+	mac := hmac.New(sha256.New, w.ipKey)
+	mac.Write([]byte(ip))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
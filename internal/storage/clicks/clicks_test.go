@@ -0,0 +1,65 @@
+package clicks_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/Braendie/url-shortener/internal/storage/clicks"
+	"github.com/Braendie/url-shortener/internal/storage/clicks/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWriter_BatchesAndFlushesOnStop(t *testing.T) {
+	saverMock := mocks.NewClicksSaver(t)
+
+	var (
+		mu    sync.Mutex
+		saved []storage.Click
+	)
+	saverMock.On("SaveClicks", mock.Anything, mock.AnythingOfType("[]storage.Click")).
+		Return(nil).
+		Run(func(args mock.Arguments) {
+			mu.Lock()
+			defer mu.Unlock()
+			saved = append(saved, args.Get(1).([]storage.Click)...)
+		})
+
+	w := clicks.New(slogdiscard.NewDiscardLogger(), saverMock, "secret", 100, 2, time.Hour)
+	w.Start()
+
+	for _, ip := range []string{"10.0.0.1", "10.0.0.1", "10.0.0.2"} {
+		w.RecordClick(storage.Click{Alias: "alias", ClickedAt: time.Now(), ClientIP: ip})
+	}
+
+	w.Stop()
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Len(t, saved, 3)
+	for _, c := range saved {
+		assert.Empty(t, c.ClientIP)
+		assert.Len(t, c.IPHash, 64)
+	}
+	assert.Equal(t, saved[0].IPHash, saved[1].IPHash)
+	assert.NotEqual(t, saved[0].IPHash, saved[2].IPHash)
+}
+
+func TestWriter_DropsWhenBufferIsFull(t *testing.T) {
+	saverMock := mocks.NewClicksSaver(t)
+	saverMock.On("SaveClicks", mock.Anything, mock.MatchedBy(func(c []storage.Click) bool { return len(c) == 1 })).
+		Return(nil).
+		Once()
+
+	// The writer is not started, so nothing drains the buffer until Stop.
+	w := clicks.New(slogdiscard.NewDiscardLogger(), saverMock, "secret", 1, 10, time.Hour)
+	w.RecordClick(storage.Click{Alias: "alias"})
+	w.RecordClick(storage.Click{Alias: "alias"})
+
+	w.Start()
+	w.Stop()
+}
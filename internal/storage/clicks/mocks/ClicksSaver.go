@@ -0,0 +1,47 @@
+// Code generated by mockery v2.53.4. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	storage "github.com/Braendie/url-shortener/internal/storage"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// ClicksSaver is an autogenerated mock type for the ClicksSaver type
+type ClicksSaver struct {
+	mock.Mock
+}
+
+// SaveClicks provides a mock function with given fields: ctx, _a1
+func (_m *ClicksSaver) SaveClicks(ctx context.Context, _a1 []storage.Click) error {
+	ret := _m.Called(ctx, _a1)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SaveClicks")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []storage.Click) error); ok {
+		r0 = rf(ctx, _a1)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// NewClicksSaver creates a new instance of ClicksSaver. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewClicksSaver(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *ClicksSaver {
+	mock := &ClicksSaver{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
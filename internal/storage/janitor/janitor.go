@@ -0,0 +1,82 @@
+package janitor
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/lib/logger/sl"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.53.4 --name=ExpiredURLDeleter
+type ExpiredURLDeleter interface {
+	DeleteExpiredURLs(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Janitor periodically purges expired links in the background.
+type Janitor struct {
+	log      *slog.Logger
+	deleter  ExpiredURLDeleter
+	interval time.Duration
+
+	stopOnce sync.Once
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func New(log *slog.Logger, deleter ExpiredURLDeleter, interval time.Duration) *Janitor {
+	return &Janitor{
+		log:      log.With(slog.String("component", "janitor")),
+		deleter:  deleter,
+		interval: interval,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start runs the purge loop in a separate goroutine until Stop is called.
+func (j *Janitor) Start() {
+	go j.run()
+}
+
+// Stop signals the purge loop to exit and waits until the current purge, if any, finishes.
+func (j *Janitor) Stop() {
+	j.stopOnce.Do(func() {
+		close(j.stop)
+	})
+	<-j.done
+}
+
+func (j *Janitor) run() {
+	defer close(j.done)
+
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	j.log.Info("janitor started", slog.Duration("interval", j.interval))
+
+	for {
+		select {
+		case <-j.stop:
+			j.log.Info("janitor stopped")
+			return
+		case <-ticker.C:
+			j.purge()
+		}
+	}
+}
+
+func (j *Janitor) purge() {
+	const op = "storage.janitor.purge"
+
+	n, err := j.deleter.DeleteExpiredURLs(context.Background(), time.Now())
+	if err != nil {
+		j.log.Error("failed to delete expired urls", slog.String("op", op), sl.Err(err))
+		return
+	}
+
+	if n > 0 {
+		j.log.Info("expired urls deleted", slog.Int64("count", n))
+	}
+}
@@ -0,0 +1,60 @@
+package janitor_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/lib/logger/handlers/slogdiscard"
+	"github.com/Braendie/url-shortener/internal/storage/janitor"
+	"github.com/Braendie/url-shortener/internal/storage/janitor/mocks"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestJanitor(t *testing.T) {
+	testCases := []struct {
+		name      string
+		mockError error
+	}{
+		{
+			name: "success",
+		},
+		{
+			name:      "storage error",
+			mockError: errors.New("some error"),
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			deleterMock := mocks.NewExpiredURLDeleter(t)
+
+			purged := make(chan struct{}, 1)
+			deleterMock.On("DeleteExpiredURLs", mock.Anything, mock.AnythingOfType("time.Time")).
+				Return(int64(1), tc.mockError).
+				Run(func(mock.Arguments) {
+					select {
+					case purged <- struct{}{}:
+					default:
+					}
+				})
+
+			j := janitor.New(slogdiscard.NewDiscardLogger(), deleterMock, 10*time.Millisecond)
+			j.Start()
+
+			select {
+			case <-purged:
+			case <-time.After(time.Second):
+				t.Fatal("janitor did not purge expired urls")
+			}
+
+			j.Stop()
+			// Stop must be safe to call more than once.
+			j.Stop()
+		})
+	}
+}
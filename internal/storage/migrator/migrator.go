@@ -0,0 +1,388 @@
+package migrator
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrNoChange       = errors.New("no change")
+	ErrUnknownVersion = errors.New("unknown migration version")
+	ErrInvalidName    = errors.New("invalid migration file name")
+)
+
+type Dialect int
+
+const (
+	SQLite Dialect = iota
+	Postgres
+)
+
+// lockID is the postgres advisory lock key that serializes migrations
+// started concurrently by several replicas.
+const lockID = 7345012
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	dialect    Dialect
+	migrations []Migration
+}
+
+// New reads migrations from fsys. Every migration is a pair of files named
+// <version>_<name>.up.sql and <version>_<name>.down.sql, e.g. 0001_create_url.up.sql.
+func New(db *sql.DB, fsys fs.FS, dialect Dialect) (*Migrator, error) {
+	const op = "storage.migrator.New"
+
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Migrator{
+		db:         db,
+		dialect:    dialect,
+		migrations: migrations,
+	}, nil
+}
+
+// Up applies all pending migrations.
+func (m *Migrator) Up() error {
+	const op = "storage.migrator.Up"
+
+	if len(m.migrations) == 0 {
+		return fmt.Errorf("%s: %w", op, ErrNoChange)
+	}
+
+	if err := m.To(m.migrations[len(m.migrations)-1].Version); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Down rolls back the last applied migration.
+func (m *Migrator) Down() error {
+	const op = "storage.migrator.Down"
+
+	current, err := m.Version()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if current == 0 {
+		return fmt.Errorf("%s: %w", op, ErrNoChange)
+	}
+
+	var target int64
+	for _, mg := range m.migrations {
+		if mg.Version < current {
+			target = mg.Version
+		}
+	}
+
+	if err := m.To(target); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// To migrates the schema up or down to the given version. Version 0 rolls back every migration.
+func (m *Migrator) To(version int64) error {
+	const op = "storage.migrator.To"
+
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("%s: %w: %d", op, ErrUnknownVersion, version)
+	}
+
+	if err := m.ensureTable(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	changed := false
+
+	for _, mg := range m.migrations {
+		if mg.Version > version {
+			break
+		}
+		if _, ok := applied[mg.Version]; ok {
+			continue
+		}
+
+		ok, err := m.apply(mg, true)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		changed = changed || ok
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mg := m.migrations[i]
+		if mg.Version <= version {
+			break
+		}
+		if _, ok := applied[mg.Version]; !ok {
+			continue
+		}
+
+		ok, err := m.apply(mg, false)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		changed = changed || ok
+	}
+
+	if !changed {
+		return fmt.Errorf("%s: %w", op, ErrNoChange)
+	}
+
+	return nil
+}
+
+// Version returns the latest applied migration version or 0 if none were applied.
+func (m *Migrator) Version() (int64, error) {
+	const op = "storage.migrator.Version"
+
+	if err := m.ensureTable(); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var version sql.NullInt64
+	err := m.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return version.Int64, nil
+}
+
+// Status reports every known migration and whether it has been applied.
+func (m *Migrator) Status() ([]Status, error) {
+	const op = "storage.migrator.Status"
+
+	if err := m.ensureTable(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, mg := range m.migrations {
+		appliedAt, ok := applied[mg.Version]
+		statuses = append(statuses, Status{
+			Version:   mg.Version,
+			Name:      mg.Name,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+
+	return statuses, nil
+}
+
+func (m *Migrator) ensureTable() error {
+	_, err := m.db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMP NOT NULL)
+	`)
+
+	return err
+}
+
+func (m *Migrator) applied() (map[int64]time.Time, error) {
+	rows, err := m.db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var (
+			version   int64
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// apply runs a single migration in a transaction. It reports false if another
+// process has already done the same work in the meantime.
+func (m *Migrator) apply(mg Migration, up bool) (bool, error) {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if m.dialect == Postgres {
+		if _, err := tx.Exec(`SELECT pg_advisory_xact_lock(` + strconv.Itoa(lockID) + `)`); err != nil {
+			return false, err
+		}
+	}
+
+	var count int
+	err = tx.QueryRow(m.rebind(`SELECT COUNT(*) FROM schema_migrations WHERE version = ?`), mg.Version).Scan(&count)
+	if err != nil {
+		return false, err
+	}
+
+	if up == (count > 0) {
+		return false, nil
+	}
+
+	script := mg.Down
+	if up {
+		script = mg.Up
+	}
+
+	if strings.TrimSpace(script) != "" {
+		if _, err := tx.Exec(script); err != nil {
+			return false, fmt.Errorf("migration %d_%s: %w", mg.Version, mg.Name, err)
+		}
+	}
+
+	if up {
+		_, err = tx.Exec(
+			m.rebind(`INSERT INTO schema_migrations(version, name, applied_at) VALUES(?, ?, ?)`),
+			mg.Version, mg.Name, time.Now().UTC(),
+		)
+	} else {
+		_, err = tx.Exec(m.rebind(`DELETE FROM schema_migrations WHERE version = ?`), mg.Version)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// rebind replaces ? placeholders with the numbered form postgres expects.
+func (m *Migrator) rebind(query string) string {
+	if m.dialect != Postgres {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+
+	return nil
+}
+
+func load(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+
+	for _, file := range files {
+		base := path.Base(file)
+
+		var up bool
+		switch {
+		case strings.HasSuffix(base, ".up.sql"):
+			up = true
+		case strings.HasSuffix(base, ".down.sql"):
+		default:
+			return nil, fmt.Errorf("%w: %s", ErrInvalidName, base)
+		}
+
+		stem := strings.TrimSuffix(strings.TrimSuffix(base, ".up.sql"), ".down.sql")
+		rawVersion, name, ok := strings.Cut(stem, "_")
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidName, base)
+		}
+
+		version, err := strconv.ParseInt(rawVersion, 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidName, base)
+		}
+
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		mg, ok := byVersion[version]
+		if !ok {
+			mg = &Migration{Version: version, Name: name}
+			byVersion[version] = mg
+		}
+		if mg.Name != name {
+			return nil, fmt.Errorf("%w: %s: version %d is already used by %s", ErrInvalidName, base, version, mg.Name)
+		}
+
+		if up {
+			mg.Up = string(body)
+		} else {
+			mg.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, mg := range byVersion {
+		migrations = append(migrations, *mg)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
@@ -0,0 +1,104 @@
+package migrator_test
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Braendie/url-shortener/internal/storage/migrator"
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testMigrations = fstest.MapFS{
+	"0001_create_a.up.sql":   {Data: []byte(`CREATE TABLE a (id INTEGER PRIMARY KEY);`)},
+	"0001_create_a.down.sql": {Data: []byte(`DROP TABLE a;`)},
+	"0002_create_b.up.sql":   {Data: []byte(`CREATE TABLE b (id INTEGER PRIMARY KEY);`)},
+	"0002_create_b.down.sql": {Data: []byte(`DROP TABLE b;`)},
+	"0003_add_c.up.sql":      {Data: []byte(`ALTER TABLE b ADD COLUMN c TEXT;`)},
+	"0003_add_c.down.sql":    {Data: []byte(`ALTER TABLE b DROP COLUMN c;`)},
+}
+
+func newMigrator(t *testing.T) (*migrator.Migrator, *sql.DB) {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	m, err := migrator.New(db, testMigrations, migrator.SQLite)
+	require.NoError(t, err)
+
+	return m, db
+}
+
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+
+	var count int
+	err := db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, name).Scan(&count)
+	require.NoError(t, err)
+
+	return count > 0
+}
+
+func TestMigrator_UpDown(t *testing.T) {
+	m, db := newMigrator(t)
+
+	require.NoError(t, m.Up())
+	assert.True(t, tableExists(t, db, "a"))
+	assert.True(t, tableExists(t, db, "b"))
+
+	version, err := m.Version()
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), version)
+
+	assert.ErrorIs(t, m.Up(), migrator.ErrNoChange)
+
+	require.NoError(t, m.Down())
+	version, err = m.Version()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), version)
+
+	require.NoError(t, m.Down())
+	require.NoError(t, m.Down())
+	assert.False(t, tableExists(t, db, "a"))
+	assert.False(t, tableExists(t, db, "b"))
+
+	assert.ErrorIs(t, m.Down(), migrator.ErrNoChange)
+}
+
+func TestMigrator_To(t *testing.T) {
+	m, db := newMigrator(t)
+
+	require.NoError(t, m.To(1))
+	assert.True(t, tableExists(t, db, "a"))
+	assert.False(t, tableExists(t, db, "b"))
+
+	require.NoError(t, m.To(3))
+
+	statuses, err := m.Status()
+	require.NoError(t, err)
+	require.Len(t, statuses, 3)
+	for _, st := range statuses {
+		assert.True(t, st.Applied, st.Name)
+	}
+
+	require.NoError(t, m.To(0))
+	assert.False(t, tableExists(t, db, "a"))
+
+	assert.ErrorIs(t, m.To(42), migrator.ErrUnknownVersion)
+}
+
+func TestNew_InvalidName(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = migrator.New(db, fstest.MapFS{
+		"create_a.up.sql": {Data: []byte(`CREATE TABLE a (id INTEGER);`)},
+	}, migrator.SQLite)
+	assert.ErrorIs(t, err, migrator.ErrInvalidName)
+}
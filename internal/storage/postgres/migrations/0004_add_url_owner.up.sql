@@ -0,0 +1,3 @@
+-- Links created before ownership was tracked keep owner_id 0 and can be managed by admins only.
+ALTER TABLE url ADD COLUMN IF NOT EXISTS owner_id BIGINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_url_owner_id ON url(owner_id);
@@ -0,0 +1,9 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+CREATE TABLE IF NOT EXISTS url_history (
+	id BIGSERIAL PRIMARY KEY,
+	url_id BIGINT NOT NULL REFERENCES url(id) ON DELETE CASCADE,
+	url TEXT NOT NULL,
+	expires_at TIMESTAMPTZ,
+	changed_at TIMESTAMPTZ NOT NULL,
+	changed_by BIGINT NOT NULL DEFAULT 0);
+CREATE INDEX IF NOT EXISTS idx_url_history_url_id ON url_history(url_id, changed_at);
@@ -0,0 +1,2 @@
+ALTER TABLE url DROP COLUMN IF EXISTS forward_query;
+ALTER TABLE url DROP COLUMN IF EXISTS redirect_code;
@@ -0,0 +1,2 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS redirect_code INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE url ADD COLUMN IF NOT EXISTS forward_query BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,2 @@
+ALTER TABLE url DROP COLUMN IF EXISTS used_clicks;
+ALTER TABLE url DROP COLUMN IF EXISTS max_clicks;
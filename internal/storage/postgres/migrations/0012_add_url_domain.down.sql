@@ -0,0 +1,6 @@
+-- Links of custom domains are dropped: their aliases may clash with those of the default domain.
+DELETE FROM url WHERE domain <> '';
+DROP INDEX IF EXISTS idx_url_domain_alias;
+ALTER TABLE url ADD CONSTRAINT url_alias_key UNIQUE (alias);
+CREATE INDEX IF NOT EXISTS idx_alias ON url(alias);
+ALTER TABLE url DROP COLUMN IF EXISTS domain;
@@ -0,0 +1,4 @@
+ALTER TABLE url ADD COLUMN IF NOT EXISTS domain TEXT NOT NULL DEFAULT '';
+ALTER TABLE url DROP CONSTRAINT IF EXISTS url_alias_key;
+DROP INDEX IF EXISTS idx_alias;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_url_domain_alias ON url(domain, alias);
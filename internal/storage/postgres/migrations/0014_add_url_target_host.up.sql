@@ -0,0 +1,6 @@
+-- The host of the target URL, lower-cased and without userinfo or port, see storage.TargetHost.
+ALTER TABLE url ADD COLUMN IF NOT EXISTS target_host TEXT NOT NULL DEFAULT '';
+UPDATE url SET target_host = lower(btrim(
+	coalesce(substring(url FROM '^[A-Za-z][A-Za-z0-9+.-]*://(?:[^@/?#]*@)?(\[[^]/?#]*\]|[^:/?#]*)'), ''),
+	'[]'));
+CREATE INDEX IF NOT EXISTS idx_url_target_host ON url(target_host);
@@ -0,0 +1,913 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/Braendie/url-shortener/internal/storage/migrator"
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// uniqueViolation is the SQLSTATE code postgres reports for unique constraint violations.
+const uniqueViolation = "23505"
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+type Storage struct {
+	db *sql.DB
+}
+
+func New(dsn string) (*Storage, error) {
+	const op = "storage.postgres.New"
+
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := db.Ping(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Storage{db: db}, nil
+}
+
+// Ping checks that the database is reachable.
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close closes the underlying database.
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+// Migrator returns the schema migrator for this database.
+func (s *Storage) Migrator() (*migrator.Migrator, error) {
+	const op = "storage.postgres.Migrator"
+
+	fsys, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	m, err := migrator.New(s.db, fsys, migrator.Postgres)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return m, nil
+}
+
+func (s *Storage) SaveURL(ctx context.Context, u storage.URL) (int64, error) {
+	const op = "storage.postgres.SaveURL"
+
+	stmt, err := s.db.PrepareContext(ctx, `INSERT INTO url(domain, url, alias, owner_id, created_at, expires_at, generated, redirect_code, forward_query, password_hash, max_clicks, target_host) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	var id int64
+	err = stmt.QueryRowContext(ctx, u.Domain, u.URL, u.Alias, u.OwnerID, createdAt(u), utc(u.ExpiresAt), u.Generated, u.RedirectCode, u.ForwardQuery, u.PasswordHash, u.MaxClicks, storage.TargetHost(u.URL)).Scan(&id)
+	if err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == uniqueViolation {
+			return 0, fmt.Errorf("%s: %w", op, storage.ErrURLExists)
+		}
+
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// SaveURLs saves links in a single transaction. Taken aliases are reported
+// per item and do not fail the batch.
+func (s *Storage) SaveURLs(ctx context.Context, urls []storage.URL) ([]storage.SaveResult, error) {
+	const op = "storage.postgres.SaveURLs"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO url(domain, url, alias, owner_id, created_at, expires_at, generated, redirect_code, forward_query, password_hash, max_clicks, target_host) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	results := make([]storage.SaveResult, len(urls))
+
+	for i, u := range urls {
+		// A failed statement aborts the whole transaction in postgres, so
+		// every item runs in its own savepoint.
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT batch_item`); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		err := stmt.QueryRowContext(ctx, u.Domain, u.URL, u.Alias, u.OwnerID, createdAt(u), utc(u.ExpiresAt), u.Generated, u.RedirectCode, u.ForwardQuery, u.PasswordHash, u.MaxClicks, storage.TargetHost(u.URL)).Scan(&results[i].ID)
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if !errors.As(err, &pgErr) || pgErr.Code != uniqueViolation {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+
+			if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT batch_item`); err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+			results[i].Err = fmt.Errorf("%s: %w", op, storage.ErrURLExists)
+
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT batch_item`); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return results, nil
+}
+
+// ExportURLs calls fn for every link of the domain in id order. A zero
+// ownerID exports the links of all owners.
+func (s *Storage) ExportURLs(ctx context.Context, domain string, ownerID int64, fn func(storage.URL) error) error {
+	const op = "storage.postgres.ExportURLs"
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+urlColumns+` FROM url WHERE domain = $1 AND ($2 = 0 OR owner_id = $2) ORDER BY id`, domain, ownerID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		u, err := scanURL(rows)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := fn(u); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ImportURLs saves the links returned by next until it returns io.EOF, in a
+// single transaction. Existing aliases are handled according to policy; with
+// ConflictFail, or if next fails, nothing is imported.
+func (s *Storage) ImportURLs(
+	ctx context.Context,
+	next func() (storage.URL, error),
+	policy storage.ConflictPolicy,
+) (storage.ImportResult, error) {
+	const op = "storage.postgres.ImportURLs"
+
+	var res storage.ImportResult
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return res, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	insert, err := tx.PrepareContext(ctx, `INSERT INTO url(domain, url, alias, owner_id, created_at, expires_at, redirect_code, forward_query, password_hash, max_clicks, used_clicks, generated, target_host) VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)`)
+	if err != nil {
+		return res, fmt.Errorf("%s: %w", op, err)
+	}
+	defer insert.Close()
+
+	update, err := tx.PrepareContext(ctx, `
+		UPDATE url SET url = $1, owner_id = $2, created_at = $3, expires_at = $4, updated_at = $5,
+			redirect_code = $6, forward_query = $7, password_hash = $8, max_clicks = $9, used_clicks = $10, generated = $11,
+			target_host = $14
+		WHERE domain = $12 AND alias = $13`)
+	if err != nil {
+		return res, fmt.Errorf("%s: %w", op, err)
+	}
+	defer update.Close()
+
+	for {
+		u, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return storage.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		// A failed statement aborts the whole transaction in postgres, so
+		// every row runs in its own savepoint.
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT import_row`); err != nil {
+			return storage.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err = insert.ExecContext(
+			ctx, u.Domain, u.URL, u.Alias, u.OwnerID, createdAt(u), utc(u.ExpiresAt), u.RedirectCode, u.ForwardQuery,
+			u.PasswordHash, u.MaxClicks, u.UsedClicks, u.Generated, storage.TargetHost(u.URL),
+		)
+		if err == nil {
+			if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT import_row`); err != nil {
+				return storage.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+			}
+			res.Created++
+			continue
+		}
+		if !isUniqueViolation(err) {
+			return storage.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT import_row`); err != nil {
+			return storage.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+		switch policy {
+		case storage.ConflictSkip:
+			res.Skipped++
+		case storage.ConflictOverwrite:
+			_, err := update.ExecContext(
+				ctx, u.URL, u.OwnerID, createdAt(u), utc(u.ExpiresAt), time.Now().UTC(),
+				u.RedirectCode, u.ForwardQuery, u.PasswordHash, u.MaxClicks, u.UsedClicks, u.Generated, u.Domain, u.Alias,
+				storage.TargetHost(u.URL),
+			)
+			if err != nil {
+				return storage.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+			}
+			res.Overwritten++
+		default:
+			return storage.ImportResult{}, fmt.Errorf("%s: alias %q: %w", op, u.Alias, storage.ErrURLExists)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return storage.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// GetURL returns the link of alias on the domain to redirect to. Expired
+// links are reported as storage.ErrURLExpired and exhausted ones as
+// storage.ErrURLExhausted.
+func (s *Storage) GetURL(ctx context.Context, domain, alias string) (storage.URL, error) {
+	const op = "storage.postgres.GetURL"
+
+	stmt, err := s.db.PrepareContext(ctx, `SELECT `+urlColumns+` FROM url WHERE domain = $1 AND alias = $2`)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	u, err := scanURL(stmt.QueryRowContext(ctx, domain, alias))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.URL{}, fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+		}
+
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if u.Expired(time.Now()) {
+		return storage.URL{}, fmt.Errorf("%s: %w", op, storage.ErrURLExpired)
+	}
+	if u.Exhausted() {
+		return storage.URL{}, fmt.Errorf("%s: %w", op, storage.ErrURLExhausted)
+	}
+
+	return u, nil
+}
+
+// FindGeneratedURL returns the oldest link to url on the domain that has a
+// generated alias, does not expire, is not protected or limited and redirects
+// with the default options.
+// A zero ownerID searches the links of all owners.
+func (s *Storage) FindGeneratedURL(ctx context.Context, domain, url string, ownerID int64) (storage.URL, error) {
+	const op = "storage.postgres.FindGeneratedURL"
+
+	u, err := scanURL(s.db.QueryRowContext(ctx, `SELECT `+urlColumns+` FROM url
+		WHERE domain = $1 AND url = $2 AND generated AND ($3::bigint = 0 OR owner_id = $3) AND expires_at IS NULL
+			AND redirect_code = 0 AND NOT forward_query AND password_hash = '' AND max_clicks = 0
+		ORDER BY id LIMIT 1`, domain, url, ownerID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.URL{}, fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+		}
+
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return u, nil
+}
+
+// UseClick counts a redirect through the link against its click limit and
+// fails with storage.ErrURLExhausted once the limit is reached. The check and
+// the increment are a single statement, so concurrent redirects cannot
+// overshoot the limit.
+func (s *Storage) UseClick(ctx context.Context, domain, alias string) error {
+	const op = "storage.postgres.UseClick"
+
+	res, err := s.db.ExecContext(ctx, `UPDATE url SET used_clicks = used_clicks + 1
+		WHERE domain = $1 AND alias = $2 AND (max_clicks = 0 OR used_clicks < max_clicks)`, domain, alias)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	if n > 0 {
+		return nil
+	}
+
+	var id int64
+	err = s.db.QueryRowContext(ctx, `SELECT id FROM url WHERE domain = $1 AND alias = $2`, domain, alias).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return fmt.Errorf("%s: %w", op, storage.ErrURLExhausted)
+}
+
+// GetURLInfo returns the full link record, including expired links.
+func (s *Storage) GetURLInfo(ctx context.Context, domain, alias string) (storage.URL, error) {
+	const op = "storage.postgres.GetURLInfo"
+
+	stmt, err := s.db.PrepareContext(ctx, `SELECT `+urlColumns+` FROM url WHERE domain = $1 AND alias = $2`)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	u, err := scanURL(stmt.QueryRowContext(ctx, domain, alias))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.URL{}, fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+		}
+
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return u, nil
+}
+
+// UpdateURL atomically changes the mutable attributes of a link
+// and keeps its previous state in the history table.
+func (s *Storage) UpdateURL(ctx context.Context, domain, alias string, upd storage.URLUpdate) (storage.URL, error) {
+	const op = "storage.postgres.UpdateURL"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	u, err := scanURL(tx.QueryRowContext(ctx, `SELECT `+urlColumns+` FROM url WHERE domain = $1 AND alias = $2 FOR UPDATE`, domain, alias))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.URL{}, fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+		}
+
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now().UTC()
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO url_history(url_id, url, expires_at, changed_at, changed_by) VALUES($1, $2, $3, $4, $5)`,
+		u.ID, u.URL, utc(u.ExpiresAt), now, upd.ChangedBy,
+	)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if upd.URL != nil {
+		u.URL = *upd.URL
+	}
+	if upd.ClearExpiration {
+		u.ExpiresAt = nil
+	} else if upd.ExpiresAt != nil {
+		u.ExpiresAt = upd.ExpiresAt
+	}
+	if upd.RedirectCode != nil {
+		u.RedirectCode = *upd.RedirectCode
+	}
+	if upd.ForwardQuery != nil {
+		u.ForwardQuery = *upd.ForwardQuery
+	}
+	if upd.PasswordHash != nil {
+		u.PasswordHash = *upd.PasswordHash
+	}
+	if upd.MaxClicks != nil {
+		u.MaxClicks = *upd.MaxClicks
+	}
+	u.UpdatedAt = &now
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE url SET url = $1, expires_at = $2, redirect_code = $3, forward_query = $4, password_hash = $5,
+			max_clicks = $6, updated_at = $7, target_host = $9 WHERE id = $8`,
+		u.URL, utc(u.ExpiresAt), u.RedirectCode, u.ForwardQuery, u.PasswordHash, u.MaxClicks, now, u.ID,
+		storage.TargetHost(u.URL),
+	)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return u, nil
+}
+
+// URLHistory returns previous states of a link, newest first.
+func (s *Storage) URLHistory(ctx context.Context, domain, alias string) ([]storage.Revision, error) {
+	const op = "storage.postgres.URLHistory"
+
+	var urlID int64
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM url WHERE domain = $1 AND alias = $2`, domain, alias).Scan(&urlID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT url, expires_at, changed_at, changed_by
+		FROM url_history
+		WHERE url_id = $1
+		ORDER BY changed_at DESC, id DESC
+	`, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var revisions []storage.Revision
+	for rows.Next() {
+		var (
+			rev       storage.Revision
+			expiresAt sql.NullTime
+		)
+		if err := rows.Scan(&rev.URL, &expiresAt, &rev.ChangedAt, &rev.ChangedBy); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		rev.ExpiresAt = nullTime(expiresAt)
+
+		revisions = append(revisions, rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revisions, nil
+}
+
+// ListURLs returns links matching the query ordered by q.SortBy and id,
+// together with the number of recorded clicks of every link.
+func (s *Storage) ListURLs(ctx context.Context, q storage.ListQuery) ([]storage.URL, error) {
+	const op = "storage.postgres.ListURLs"
+
+	where := []string{"u.domain = ?"}
+	args := []any{q.Domain}
+
+	if q.OwnerID != 0 {
+		where = append(where, "u.owner_id = ?")
+		args = append(args, q.OwnerID)
+	}
+	if q.AliasPrefix != "" {
+		where = append(where, "substr(u.alias, 1, ?) = ?")
+		args = append(args, utf8.RuneCountInString(q.AliasPrefix), q.AliasPrefix)
+	}
+	if q.TargetDomain != "" {
+		host, suffix := strings.ToLower(q.TargetDomain), "."+strings.ToLower(q.TargetDomain)
+		where = append(where, "(u.target_host = ? OR right(u.target_host, ?) = ?)")
+		args = append(args, host, utf8.RuneCountInString(suffix), suffix)
+	}
+
+	column, after := sortKey(q)
+
+	order, cmp := "ASC", ">"
+	if q.Desc {
+		order, cmp = "DESC", "<"
+	}
+
+	if q.After != nil {
+		where = append(where, fmt.Sprintf("(%s %s ? OR (%s = ? AND u.id %s ?))", column, cmp, column, cmp))
+		args = append(args, after, after, q.After.ID)
+	}
+
+	query := `
+		SELECT u.id, u.domain, u.alias, u.url, u.owner_id, u.created_at, u.expires_at, u.max_clicks, u.used_clicks,
+			(SELECT COUNT(*) FROM clicks c WHERE c.url_id = u.id)
+		FROM url u
+		WHERE ` + strings.Join(where, " AND ")
+	query += fmt.Sprintf(" ORDER BY %s %s, u.id %s", column, order, order)
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var urls []storage.URL
+	for rows.Next() {
+		var (
+			u         storage.URL
+			expiresAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&u.ID, &u.Domain, &u.Alias, &u.URL, &u.OwnerID, &u.CreatedAt, &expiresAt, &u.MaxClicks, &u.UsedClicks, &u.Clicks,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		u.ExpiresAt = nullTime(expiresAt)
+
+		urls = append(urls, u)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return urls, nil
+}
+
+// sortKey returns the column to order by and its value in the q.After cursor.
+func sortKey(q storage.ListQuery) (string, any) {
+	var after storage.URL
+	if q.After != nil {
+		after = *q.After
+	}
+
+	switch q.SortBy {
+	case storage.SortByAlias:
+		return "u.alias", after.Alias
+	case storage.SortByURL:
+		return "u.url", after.URL
+	default:
+		return "u.created_at", after.CreatedAt.UTC()
+	}
+}
+
+// NextAliasSeq returns the next value of the counter behind sequential aliases.
+func (s *Storage) NextAliasSeq(ctx context.Context) (int64, error) {
+	const op = "storage.postgres.NextAliasSeq"
+
+	var n int64
+	if err := s.db.QueryRowContext(ctx, `SELECT nextval('alias_seq')`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+func (s *Storage) DeleteURL(ctx context.Context, domain, alias string) error {
+	const op = "storage.postgres.DeleteURL"
+
+	stmt, err := s.db.PrepareContext(ctx, `DELETE FROM url WHERE domain = $1 AND alias = $2`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, domain, alias)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteExpiredURLs removes links that expired at or before the given time
+// and returns the number of removed rows.
+func (s *Storage) DeleteExpiredURLs(ctx context.Context, before time.Time) (int64, error) {
+	const op = "storage.postgres.DeleteExpiredURLs"
+
+	stmt, err := s.db.PrepareContext(ctx, `DELETE FROM url WHERE expires_at IS NOT NULL AND expires_at <= $1`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// SaveClicks stores a batch of clicks in a single transaction.
+// Clicks on aliases that no longer exist are silently skipped.
+func (s *Storage) SaveClicks(ctx context.Context, clicks []storage.Click) error {
+	const op = "storage.postgres.SaveClicks"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO clicks(url_id, clicked_at, referrer, user_agent, ip_hash, request_id)
+		SELECT id, $1, $2, $3, $4, $5 FROM url WHERE domain = $6 AND alias = $7
+	`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for _, c := range clicks {
+		_, err := stmt.ExecContext(ctx, c.ClickedAt.UTC(), c.Referrer, c.UserAgent, c.IPHash, c.RequestID, c.Domain, c.Alias)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// CountClicks returns the number of recorded clicks of the alias on the domain.
+func (s *Storage) CountClicks(ctx context.Context, domain, alias string) (int64, error) {
+	const op = "storage.postgres.CountClicks"
+
+	var n int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM clicks c JOIN url u ON u.id = c.url_id WHERE u.domain = $1 AND u.alias = $2
+	`, domain, alias).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// ClickStats returns all-time totals for the alias and a time series
+// bucketed by the query granularity within [q.From, q.To).
+func (s *Storage) ClickStats(
+	ctx context.Context,
+	domain, alias string,
+	q storage.StatsQuery,
+) (storage.ClickStats, error) {
+	const op = "storage.postgres.ClickStats"
+
+	var stats storage.ClickStats
+
+	var urlID int64
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM url WHERE domain = $1 AND alias = $2`, domain, alias).Scan(&urlID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return stats, fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+		}
+
+		return stats, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.db.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*), COUNT(DISTINCT ip_hash) FROM clicks WHERE url_id = $1`, urlID,
+	).Scan(&stats.TotalClicks, &stats.UniqueVisitors)
+	if err != nil {
+		return stats, fmt.Errorf("%s: %w", op, err)
+	}
+
+	bucket := "day"
+	if q.Granularity == storage.GranularityHour {
+		bucket = "hour"
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT date_trunc($1, clicked_at AT TIME ZONE 'UTC') AS bucket, COUNT(*), COUNT(DISTINCT ip_hash)
+		FROM clicks
+		WHERE url_id = $2 AND clicked_at >= $3 AND clicked_at < $4
+		GROUP BY bucket
+		ORDER BY bucket
+	`, bucket, urlID, q.From.UTC(), q.To.UTC())
+	if err != nil {
+		return stats, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var point storage.StatsPoint
+		if err := rows.Scan(&point.Time, &point.Clicks, &point.UniqueVisitors); err != nil {
+			return stats, fmt.Errorf("%s: %w", op, err)
+		}
+
+		stats.Series = append(stats.Series, point)
+	}
+
+	if err := rows.Err(); err != nil {
+		return stats, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return stats, nil
+}
+
+// SaveDomain registers the domain or replaces the defaults of a registered one.
+func (s *Storage) SaveDomain(ctx context.Context, d storage.Domain) error {
+	const op = "storage.postgres.SaveDomain"
+
+	createdAt := d.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO domains(name, alias_length, redirect_code, fallback_url, created_at) VALUES($1, $2, $3, $4, $5)
+		ON CONFLICT(name) DO UPDATE SET
+			alias_length = excluded.alias_length,
+			redirect_code = excluded.redirect_code,
+			fallback_url = excluded.fallback_url
+	`, d.Name, d.AliasLength, d.RedirectCode, d.FallbackURL, createdAt.UTC())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ListDomains returns the registered domains ordered by name.
+func (s *Storage) ListDomains(ctx context.Context) ([]storage.Domain, error) {
+	const op = "storage.postgres.ListDomains"
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT name, alias_length, redirect_code, fallback_url, created_at FROM domains ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var domains []storage.Domain
+	for rows.Next() {
+		var d storage.Domain
+		if err := rows.Scan(&d.Name, &d.AliasLength, &d.RedirectCode, &d.FallbackURL, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		domains = append(domains, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return domains, nil
+}
+
+// DeleteDomain unregisters the domain. It fails with storage.ErrDomainInUse
+// while the domain has links.
+func (s *Storage) DeleteDomain(ctx context.Context, name string) error {
+	const op = "storage.postgres.DeleteDomain"
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM domains
+		WHERE name = $1 AND NOT EXISTS (SELECT 1 FROM url WHERE domain = $1)`, name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	if n > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM domains WHERE name = $1)`, name).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		return fmt.Errorf("%s: %w", op, storage.ErrDomainNotFound)
+	}
+
+	return fmt.Errorf("%s: %w", op, storage.ErrDomainInUse)
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
+
+// urlColumns are the url table columns read by scanURL, in order.
+const urlColumns = `id, domain, alias, url, owner_id, created_at, updated_at, expires_at, redirect_code, forward_query, password_hash,
+	max_clicks, used_clicks, generated`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanURL(row scanner) (storage.URL, error) {
+	var (
+		u         storage.URL
+		updatedAt sql.NullTime
+		expiresAt sql.NullTime
+	)
+
+	err := row.Scan(
+		&u.ID, &u.Domain, &u.Alias, &u.URL, &u.OwnerID, &u.CreatedAt, &updatedAt, &expiresAt,
+		&u.RedirectCode, &u.ForwardQuery, &u.PasswordHash, &u.MaxClicks, &u.UsedClicks, &u.Generated,
+	)
+	if err != nil {
+		return storage.URL{}, err
+	}
+
+	u.UpdatedAt = nullTime(updatedAt)
+	u.ExpiresAt = nullTime(expiresAt)
+
+	return u, nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
+
+// createdAt returns the creation time to store for a new link.
+func createdAt(u storage.URL) time.Time {
+	if u.CreatedAt.IsZero() {
+		return time.Now().UTC()
+	}
+
+	return u.CreatedAt.UTC()
+}
+
+// utc normalizes optional timestamps so that they are stored and compared in UTC.
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	u := t.UTC()
+
+	return &u
+}
+
+// rebind replaces ? placeholders of dynamically built queries with the numbered form postgres expects.
+func rebind(query string) string {
+	var b strings.Builder
+
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
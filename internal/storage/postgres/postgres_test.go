@@ -0,0 +1,459 @@
+package postgres_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Braendie/url-shortener/internal/lib/random"
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/Braendie/url-shortener/internal/storage/migrator"
+	"github.com/Braendie/url-shortener/internal/storage/postgres"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Tests in this file run against a real postgres instance, e.g. the one
+// started by `make postgres-up`. They are skipped when POSTGRES_TEST_DSN is not set.
+const dsnEnv = "POSTGRES_TEST_DSN"
+
+func newStorage(t *testing.T) *postgres.Storage {
+	t.Helper()
+
+	dsn := os.Getenv(dsnEnv)
+	if dsn == "" {
+		t.Skipf("%s is not set", dsnEnv)
+	}
+
+	s, err := postgres.New(dsn)
+	require.NoError(t, err)
+
+	m, err := s.Migrator()
+	require.NoError(t, err)
+
+	err = m.Up()
+	if !errors.Is(err, migrator.ErrNoChange) {
+		require.NoError(t, err)
+	}
+
+	return s
+}
+
+func TestStorage_SaveGetDelete(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	alias, err := random.NewRandomString(10)
+	require.NoError(t, err)
+
+	id, err := s.SaveURL(ctx, storage.URL{URL: "https://google.com", Alias: alias, OwnerID: 7})
+	require.NoError(t, err)
+	assert.NotZero(t, id)
+
+	info, err := s.GetURLInfo(ctx, "", alias)
+	require.NoError(t, err)
+	assert.Equal(t, id, info.ID)
+	assert.Equal(t, int64(7), info.OwnerID)
+
+	u, err := s.GetURL(ctx, "", alias)
+	require.NoError(t, err)
+	assert.Equal(t, "https://google.com", u.URL)
+
+	_, err = s.SaveURL(ctx, storage.URL{URL: "https://ya.ru", Alias: alias})
+	assert.ErrorIs(t, err, storage.ErrURLExists)
+
+	require.NoError(t, s.DeleteURL(ctx, "", alias))
+
+	_, err = s.GetURL(ctx, "", alias)
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestStorage_Expiration(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	alias, err := random.NewRandomString(10)
+	require.NoError(t, err)
+
+	expiresAt := time.Now().Add(-time.Minute)
+	_, err = s.SaveURL(ctx, storage.URL{URL: "https://google.com", Alias: alias, ExpiresAt: &expiresAt})
+	require.NoError(t, err)
+
+	_, err = s.GetURL(ctx, "", alias)
+	assert.ErrorIs(t, err, storage.ErrURLExpired)
+
+	n, err := s.DeleteExpiredURLs(ctx, time.Now())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, n, int64(1))
+
+	_, err = s.GetURL(ctx, "", alias)
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestStorage_ListURLs(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	prefix, err := random.NewRandomString(8)
+	require.NoError(t, err)
+
+	for _, suffix := range []string{"a", "b", "c"} {
+		_, err := s.SaveURL(ctx, storage.URL{URL: "https://example.com/" + suffix, Alias: prefix + suffix, OwnerID: 7})
+		require.NoError(t, err)
+	}
+
+	q := storage.ListQuery{AliasPrefix: prefix, SortBy: storage.SortByAlias, Limit: 2}
+
+	page, err := s.ListURLs(ctx, q)
+	require.NoError(t, err)
+	require.Len(t, page, 2)
+	assert.Equal(t, prefix+"a", page[0].Alias)
+
+	q.After = &page[1]
+	page, err = s.ListURLs(ctx, q)
+	require.NoError(t, err)
+	require.Len(t, page, 1)
+	assert.Equal(t, prefix+"c", page[0].Alias)
+}
+
+func TestStorage_ListURLs_TargetDomain(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	prefix, err := random.NewRandomString(8)
+	require.NoError(t, err)
+	host := strings.ToLower(prefix) + ".test"
+
+	urls := map[string]string{
+		"exact":     "https://" + strings.ToUpper(host) + "/search",
+		"subdomain": "https://mail." + host,
+		"port":      "http://user@" + host + ":8080/x",
+		"query":     "https://evil.com/?q=" + host,
+		"lookalike": "https://not" + host,
+		"path":      "https://evil.com/" + host,
+	}
+	for alias, u := range urls {
+		_, err := s.SaveURL(ctx, storage.URL{URL: u, Alias: prefix + alias})
+		require.NoError(t, err)
+	}
+
+	page, err := s.ListURLs(ctx, storage.ListQuery{TargetDomain: host, SortBy: storage.SortByAlias, Limit: 10})
+	require.NoError(t, err)
+
+	var aliases []string
+	for _, u := range page {
+		aliases = append(aliases, strings.TrimPrefix(u.Alias, prefix))
+	}
+	assert.Equal(t, []string{"exact", "port", "subdomain"}, aliases)
+}
+
+func TestStorage_UpdateURL(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	alias, err := random.NewRandomString(10)
+	require.NoError(t, err)
+
+	_, err = s.SaveURL(ctx, storage.URL{URL: "https://old.example.com", Alias: alias})
+	require.NoError(t, err)
+
+	newURL := "https://new.example.com"
+	updated, err := s.UpdateURL(ctx, "", alias, storage.URLUpdate{URL: &newURL, ChangedBy: 7})
+	require.NoError(t, err)
+	assert.Equal(t, newURL, updated.URL)
+	assert.NotNil(t, updated.UpdatedAt)
+
+	u, err := s.GetURL(ctx, "", alias)
+	require.NoError(t, err)
+	assert.Equal(t, newURL, u.URL)
+
+	revisions, err := s.URLHistory(ctx, "", alias)
+	require.NoError(t, err)
+	require.Len(t, revisions, 1)
+	assert.Equal(t, "https://old.example.com", revisions[0].URL)
+	assert.Equal(t, int64(7), revisions[0].ChangedBy)
+
+	_, err = s.UpdateURL(ctx, "", "missing-"+alias, storage.URLUpdate{URL: &newURL})
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestStorage_SaveURLs(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	prefix, err := random.NewRandomString(8)
+	require.NoError(t, err)
+
+	_, err = s.SaveURL(ctx, storage.URL{URL: "https://google.com", Alias: prefix + "_taken"})
+	require.NoError(t, err)
+
+	results, err := s.SaveURLs(ctx, []storage.URL{
+		{URL: "https://a.com", Alias: prefix + "_a"},
+		{URL: "https://b.com", Alias: prefix + "_taken"},
+		{URL: "https://c.com", Alias: prefix + "_a"},
+		{URL: "https://d.com", Alias: prefix + "_d"},
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+
+	assert.NoError(t, results[0].Err)
+	assert.NotZero(t, results[0].ID)
+	assert.ErrorIs(t, results[1].Err, storage.ErrURLExists)
+	assert.ErrorIs(t, results[2].Err, storage.ErrURLExists)
+	assert.NoError(t, results[3].Err)
+
+	u, err := s.GetURL(ctx, "", prefix+"_d")
+	require.NoError(t, err)
+	assert.Equal(t, "https://d.com", u.URL)
+}
+
+func TestStorage_ImportExportURLs(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	prefix, err := random.NewRandomString(8)
+	require.NoError(t, err)
+
+	// A random owner keeps the export independent of other tests.
+	ownerID := time.Now().UnixNano()
+
+	_, err = s.SaveURL(ctx, storage.URL{URL: "https://google.com", Alias: prefix + "_taken"})
+	require.NoError(t, err)
+
+	urls := func(urls ...storage.URL) func() (storage.URL, error) {
+		return func() (storage.URL, error) {
+			if len(urls) == 0 {
+				return storage.URL{}, io.EOF
+			}
+			u := urls[0]
+			urls = urls[1:]
+
+			return u, nil
+		}
+	}
+
+	_, err = s.ImportURLs(ctx, urls(
+		storage.URL{URL: "https://a.com", Alias: prefix + "_a", OwnerID: ownerID},
+		storage.URL{URL: "https://b.com", Alias: prefix + "_taken", OwnerID: ownerID},
+	), storage.ConflictFail)
+	assert.ErrorIs(t, err, storage.ErrURLExists)
+
+	_, err = s.GetURL(ctx, "", prefix+"_a")
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	res, err := s.ImportURLs(ctx, urls(
+		storage.URL{URL: "https://a.com", Alias: prefix + "_a", OwnerID: ownerID},
+		storage.URL{URL: "https://b.com", Alias: prefix + "_taken", OwnerID: ownerID},
+	), storage.ConflictSkip)
+	require.NoError(t, err)
+	assert.Equal(t, storage.ImportResult{Created: 1, Skipped: 1}, res)
+
+	res, err = s.ImportURLs(ctx, urls(
+		storage.URL{URL: "https://b.com", Alias: prefix + "_taken", OwnerID: ownerID},
+	), storage.ConflictOverwrite)
+	require.NoError(t, err)
+	assert.Equal(t, storage.ImportResult{Overwritten: 1}, res)
+
+	var exported []string
+	err = s.ExportURLs(ctx, "", ownerID, func(u storage.URL) error {
+		exported = append(exported, u.Alias+" "+u.URL)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{prefix + "_taken https://b.com", prefix + "_a https://a.com"}, exported)
+}
+
+func TestStorage_NextAliasSeq(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	first, err := s.NextAliasSeq(ctx)
+	require.NoError(t, err)
+
+	second, err := s.NextAliasSeq(ctx)
+	require.NoError(t, err)
+
+	assert.Greater(t, second, first)
+}
+
+func TestStorage_FindGeneratedURL(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	suffix, err := random.NewRandomString(10)
+	require.NoError(t, err)
+	url := "https://google.com/" + suffix
+
+	_, err = s.SaveURL(ctx, storage.URL{URL: url, Alias: suffix + "-custom", OwnerID: 7})
+	require.NoError(t, err)
+
+	_, err = s.FindGeneratedURL(ctx, "", url, 0)
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+
+	_, err = s.SaveURL(ctx, storage.URL{URL: url, Alias: suffix + "-gen", OwnerID: 7, Generated: true})
+	require.NoError(t, err)
+
+	u, err := s.FindGeneratedURL(ctx, "", url, 7)
+	require.NoError(t, err)
+	assert.Equal(t, suffix+"-gen", u.Alias)
+
+	u, err = s.FindGeneratedURL(ctx, "", url, 0)
+	require.NoError(t, err)
+	assert.Equal(t, suffix+"-gen", u.Alias)
+
+	_, err = s.FindGeneratedURL(ctx, "", url, 8)
+	assert.ErrorIs(t, err, storage.ErrURLNotFound)
+}
+
+func TestStorage_RedirectOptions(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	alias, err := random.NewRandomString(10)
+	require.NoError(t, err)
+
+	_, err = s.SaveURL(ctx, storage.URL{URL: "https://go.dev", Alias: alias, RedirectCode: 308, ForwardQuery: true})
+	require.NoError(t, err)
+
+	u, err := s.GetURL(ctx, "", alias)
+	require.NoError(t, err)
+	assert.Equal(t, 308, u.RedirectCode)
+	assert.True(t, u.ForwardQuery)
+
+	code, forward := 0, false
+	updated, err := s.UpdateURL(ctx, "", alias, storage.URLUpdate{RedirectCode: &code, ForwardQuery: &forward})
+	require.NoError(t, err)
+	assert.Zero(t, updated.RedirectCode)
+	assert.False(t, updated.ForwardQuery)
+
+	u, err = s.GetURL(ctx, "", alias)
+	require.NoError(t, err)
+	assert.Zero(t, u.RedirectCode)
+	assert.False(t, u.ForwardQuery)
+}
+
+func TestStorage_PasswordHash(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	alias, err := random.NewRandomString(10)
+	require.NoError(t, err)
+
+	_, err = s.SaveURL(ctx, storage.URL{URL: "https://go.dev", Alias: alias, PasswordHash: "hash"})
+	require.NoError(t, err)
+
+	u, err := s.GetURL(ctx, "", alias)
+	require.NoError(t, err)
+	assert.Equal(t, "hash", u.PasswordHash)
+
+	empty := ""
+	updated, err := s.UpdateURL(ctx, "", alias, storage.URLUpdate{PasswordHash: &empty})
+	require.NoError(t, err)
+	assert.Empty(t, updated.PasswordHash)
+}
+
+func TestStorage_UseClick(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	alias, err := random.NewRandomString(10)
+	require.NoError(t, err)
+
+	_, err = s.SaveURL(ctx, storage.URL{URL: "https://go.dev", Alias: alias, MaxClicks: 3})
+	require.NoError(t, err)
+
+	var (
+		wg   sync.WaitGroup
+		used atomic.Int64
+	)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := s.UseClick(ctx, "", alias); err == nil {
+				used.Add(1)
+			} else {
+				assert.ErrorIs(t, err, storage.ErrURLExhausted)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 3, used.Load())
+
+	_, err = s.GetURL(ctx, "", alias)
+	assert.ErrorIs(t, err, storage.ErrURLExhausted)
+
+	assert.ErrorIs(t, s.UseClick(ctx, "", "missing_"+alias), storage.ErrURLNotFound)
+}
+
+func TestStorage_Domains(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	suffix, err := random.NewRandomString(8)
+	require.NoError(t, err)
+	domain := "go-" + suffix + ".example.com"
+
+	require.NoError(t, s.SaveDomain(ctx, storage.Domain{Name: domain, AliasLength: 4}))
+	require.NoError(t, s.SaveDomain(ctx, storage.Domain{Name: domain, RedirectCode: 301, FallbackURL: "https://example.com"}))
+
+	domains, err := s.ListDomains(ctx)
+	require.NoError(t, err)
+
+	var saved *storage.Domain
+	for i := range domains {
+		if domains[i].Name == domain {
+			saved = &domains[i]
+		}
+	}
+	require.NotNil(t, saved)
+	assert.Zero(t, saved.AliasLength)
+	assert.Equal(t, 301, saved.RedirectCode)
+	assert.Equal(t, "https://example.com", saved.FallbackURL)
+
+	// The same alias may exist once per domain.
+	alias := "a" + suffix
+	_, err = s.SaveURL(ctx, storage.URL{URL: "https://default.example.com", Alias: alias})
+	require.NoError(t, err)
+	_, err = s.SaveURL(ctx, storage.URL{Domain: domain, URL: "https://custom.example.com", Alias: alias})
+	require.NoError(t, err)
+	_, err = s.SaveURL(ctx, storage.URL{Domain: domain, URL: "https://other.example.com", Alias: alias})
+	assert.ErrorIs(t, err, storage.ErrURLExists)
+
+	u, err := s.GetURL(ctx, domain, alias)
+	require.NoError(t, err)
+	assert.Equal(t, "https://custom.example.com", u.URL)
+	assert.Equal(t, domain, u.Domain)
+
+	u, err = s.GetURL(ctx, "", alias)
+	require.NoError(t, err)
+	assert.Equal(t, "https://default.example.com", u.URL)
+
+	assert.ErrorIs(t, s.DeleteDomain(ctx, domain), storage.ErrDomainInUse)
+
+	require.NoError(t, s.DeleteURL(ctx, domain, alias))
+	require.NoError(t, s.DeleteURL(ctx, "", alias))
+	require.NoError(t, s.DeleteDomain(ctx, domain))
+	assert.ErrorIs(t, s.DeleteDomain(ctx, domain), storage.ErrDomainNotFound)
+}
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_url_owner_id;
+ALTER TABLE url DROP COLUMN owner_id;
@@ -0,0 +1,5 @@
+-- SQLite cannot add a column with a non-constant default, so existing rows are backfilled
+-- in the same format the driver uses for time values to keep them comparable.
+ALTER TABLE url ADD COLUMN created_at TIMESTAMP;
+UPDATE url SET created_at = strftime('%Y-%m-%d %H:%M:%S+00:00', 'now') WHERE created_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_url_created_at ON url(created_at);
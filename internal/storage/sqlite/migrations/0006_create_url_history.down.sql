@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_url_history_url_id;
+DROP TABLE IF EXISTS url_history;
+ALTER TABLE url DROP COLUMN updated_at;
@@ -0,0 +1,9 @@
+ALTER TABLE url ADD COLUMN updated_at TIMESTAMP;
+CREATE TABLE IF NOT EXISTS url_history (
+	id INTEGER PRIMARY KEY,
+	url_id INTEGER NOT NULL REFERENCES url(id) ON DELETE CASCADE,
+	url TEXT NOT NULL,
+	expires_at TIMESTAMP,
+	changed_at TIMESTAMP NOT NULL,
+	changed_by INTEGER NOT NULL DEFAULT 0);
+CREATE INDEX IF NOT EXISTS idx_url_history_url_id ON url_history(url_id, changed_at);
@@ -0,0 +1,2 @@
+CREATE TABLE IF NOT EXISTS alias_seq (value INTEGER NOT NULL);
+INSERT INTO alias_seq(value) VALUES (0);
@@ -0,0 +1,2 @@
+ALTER TABLE url ADD COLUMN generated BOOLEAN NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_url_url ON url(url);
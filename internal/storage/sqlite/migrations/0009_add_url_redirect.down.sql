@@ -0,0 +1,2 @@
+ALTER TABLE url DROP COLUMN forward_query;
+ALTER TABLE url DROP COLUMN redirect_code;
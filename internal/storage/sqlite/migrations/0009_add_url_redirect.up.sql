@@ -0,0 +1,2 @@
+ALTER TABLE url ADD COLUMN redirect_code INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE url ADD COLUMN forward_query BOOLEAN NOT NULL DEFAULT 0;
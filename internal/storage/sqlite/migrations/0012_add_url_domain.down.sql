@@ -0,0 +1,58 @@
+-- Links of custom domains are dropped: their aliases may clash with those of the default domain.
+CREATE TABLE url_new (
+	id INTEGER PRIMARY KEY,
+	alias TEXT NOT NULL UNIQUE,
+	url TEXT NOT NULL,
+	expires_at TIMESTAMP,
+	owner_id INTEGER NOT NULL DEFAULT 0,
+	created_at TIMESTAMP,
+	updated_at TIMESTAMP,
+	generated BOOLEAN NOT NULL DEFAULT 0,
+	redirect_code INTEGER NOT NULL DEFAULT 0,
+	forward_query BOOLEAN NOT NULL DEFAULT 0,
+	password_hash TEXT NOT NULL DEFAULT '',
+	max_clicks INTEGER NOT NULL DEFAULT 0,
+	used_clicks INTEGER NOT NULL DEFAULT 0);
+INSERT INTO url_new(id, alias, url, expires_at, owner_id, created_at, updated_at, generated, redirect_code,
+		forward_query, password_hash, max_clicks, used_clicks)
+	SELECT id, alias, url, expires_at, owner_id, created_at, updated_at, generated, redirect_code,
+		forward_query, password_hash, max_clicks, used_clicks
+	FROM url
+	WHERE domain = '';
+
+CREATE TABLE clicks_new (
+	id INTEGER PRIMARY KEY,
+	url_id INTEGER NOT NULL REFERENCES url_new(id) ON DELETE CASCADE,
+	clicked_at TIMESTAMP NOT NULL,
+	referrer TEXT NOT NULL DEFAULT '',
+	user_agent TEXT NOT NULL DEFAULT '',
+	ip_hash TEXT NOT NULL DEFAULT '',
+	request_id TEXT NOT NULL DEFAULT '');
+INSERT INTO clicks_new SELECT id, url_id, clicked_at, referrer, user_agent, ip_hash, request_id FROM clicks
+	WHERE url_id IN (SELECT id FROM url_new);
+
+CREATE TABLE url_history_new (
+	id INTEGER PRIMARY KEY,
+	url_id INTEGER NOT NULL REFERENCES url_new(id) ON DELETE CASCADE,
+	url TEXT NOT NULL,
+	expires_at TIMESTAMP,
+	changed_at TIMESTAMP NOT NULL,
+	changed_by INTEGER NOT NULL DEFAULT 0);
+INSERT INTO url_history_new SELECT id, url_id, url, expires_at, changed_at, changed_by FROM url_history
+	WHERE url_id IN (SELECT id FROM url_new);
+
+DROP TABLE clicks;
+DROP TABLE url_history;
+DROP TABLE url;
+
+ALTER TABLE url_new RENAME TO url;
+ALTER TABLE clicks_new RENAME TO clicks;
+ALTER TABLE url_history_new RENAME TO url_history;
+
+CREATE INDEX idx_alias ON url(alias);
+CREATE INDEX idx_url_expires_at ON url(expires_at);
+CREATE INDEX idx_url_owner_id ON url(owner_id);
+CREATE INDEX idx_url_created_at ON url(created_at);
+CREATE INDEX idx_url_url ON url(url);
+CREATE INDEX idx_clicks_url_id_clicked_at ON clicks(url_id, clicked_at);
+CREATE INDEX idx_url_history_url_id ON url_history(url_id, changed_at);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS domains (
+	name TEXT PRIMARY KEY,
+	alias_length INTEGER NOT NULL DEFAULT 0,
+	redirect_code INTEGER NOT NULL DEFAULT 0,
+	fallback_url TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_url_target_host;
+ALTER TABLE url DROP COLUMN target_host;
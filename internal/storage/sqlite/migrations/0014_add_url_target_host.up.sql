@@ -0,0 +1,21 @@
+-- The host of the target URL, lower-cased and without userinfo or port, see storage.TargetHost.
+-- Existing rows are filled in from the URL: the part after the scheme up to the path, query or
+-- fragment, then without userinfo, and without port or the brackets of an IPv6 address.
+ALTER TABLE url ADD COLUMN target_host TEXT NOT NULL DEFAULT '';
+UPDATE url SET target_host = (
+	SELECT lower(CASE
+		WHEN host LIKE '[%' THEN substr(host, 2, instr(host, ']') - 2)
+		ELSE substr(host || ':', 1, instr(host || ':', ':') - 1)
+	END)
+	FROM (
+		SELECT substr(authority, instr(authority, '@') + 1) AS host
+		FROM (
+			SELECT substr(rest, 1, instr(rest, '/') - 1) AS authority
+			FROM (
+				SELECT replace(replace(substr(url.url, instr(url.url, '://') + 3), '?', '/'), '#', '/') || '/' AS rest
+			)
+		)
+	)
+)
+WHERE instr(url, '://') > 0;
+CREATE INDEX IF NOT EXISTS idx_url_target_host ON url(target_host);
@@ -1,52 +1,77 @@
 package sqlite
 
 import (
+	"context"
 	"database/sql"
+	"embed"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/Braendie/url-shortener/internal/storage/migrator"
 	"github.com/mattn/go-sqlite3"
 )
 
+//go:embed migrations/*.sql
+var migrations embed.FS
+
 type Storage struct {
 	db *sql.DB
 }
 
 func New(storagePath string) (*Storage, error) {
 	const op = "storage.sqlite.New"
-	db, err := sql.Open("sqlite3", fmt.Sprintf("%s/url-shortener.db", storagePath))
+	// Foreign keys are required for clicks to be removed together with their url.
+	db, err := sql.Open("sqlite3", fmt.Sprintf("%s/url-shortener.db?_foreign_keys=on&_busy_timeout=5000", storagePath))
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	stmt, err := db.Prepare(`
-		CREATE TABLE IF NOT EXISTS url (
-			id INTEGER PRIMARY KEY,
-			alias TEXT NOT NULL UNIQUE,
-			url TEXT NOT NULL);
-		CREATE INDEX IF NOT EXISTS idx_alias ON url(alias);
-	`)
+
+	return &Storage{db: db}, nil
+}
+
+// Ping checks that the database is reachable.
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close closes the underlying database.
+func (s *Storage) Close() error {
+	return s.db.Close()
+}
+
+// Migrator returns the schema migrator for this database.
+func (s *Storage) Migrator() (*migrator.Migrator, error) {
+	const op = "storage.sqlite.Migrator"
+
+	fsys, err := fs.Sub(migrations, "migrations")
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = stmt.Exec()
+	m, err := migrator.New(s.db, fsys, migrator.SQLite)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return &Storage{db: db}, nil
+	return m, nil
 }
 
-func (s *Storage) SaveURL(urlToSave string, alias string) (int64, error) {
+func (s *Storage) SaveURL(ctx context.Context, u storage.URL) (int64, error) {
 	const op = "storage.sqlite.SaveURL"
 
-	stmt, err := s.db.Prepare(`INSERT INTO url(url, alias) VALUES(?, ?)`)
+	stmt, err := s.db.PrepareContext(ctx, `INSERT INTO url(domain, url, alias, owner_id, created_at, expires_at, generated, redirect_code, forward_query, password_hash, max_clicks, target_host) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
 	if err != nil {
 		return 0, fmt.Errorf("%s: %w", op, err)
 	}
+	defer stmt.Close()
 
-	res, err := stmt.Exec(urlToSave, alias)
+	res, err := stmt.ExecContext(ctx, u.Domain, u.URL, u.Alias, u.OwnerID, createdAt(u), utc(u.ExpiresAt), u.Generated, u.RedirectCode, u.ForwardQuery, u.PasswordHash, u.MaxClicks, storage.TargetHost(u.URL))
 	if err != nil {
 		if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
 			return 0, fmt.Errorf("%s: %w", op, storage.ErrURLExists)
@@ -63,39 +88,787 @@ func (s *Storage) SaveURL(urlToSave string, alias string) (int64, error) {
 	return id, nil
 }
 
-func (s *Storage) GetURL(alias string) (string, error) {
+// SaveURLs saves links in a single transaction. Taken aliases are reported
+// per item and do not fail the batch.
+func (s *Storage) SaveURLs(ctx context.Context, urls []storage.URL) ([]storage.SaveResult, error) {
+	const op = "storage.sqlite.SaveURLs"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO url(domain, url, alias, owner_id, created_at, expires_at, generated, redirect_code, forward_query, password_hash, max_clicks, target_host) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	results := make([]storage.SaveResult, len(urls))
+
+	for i, u := range urls {
+		// A failed insert only rolls back its own statement, the transaction goes on.
+		res, err := stmt.ExecContext(ctx, u.Domain, u.URL, u.Alias, u.OwnerID, createdAt(u), utc(u.ExpiresAt), u.Generated, u.RedirectCode, u.ForwardQuery, u.PasswordHash, u.MaxClicks, storage.TargetHost(u.URL))
+		if err != nil {
+			if sqliteErr, ok := err.(sqlite3.Error); ok && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+				results[i].Err = fmt.Errorf("%s: %w", op, storage.ErrURLExists)
+				continue
+			}
+
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to get last insert id: %w", op, err)
+		}
+		results[i].ID = id
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return results, nil
+}
+
+// ExportURLs calls fn for every link of the domain in id order. A zero
+// ownerID exports the links of all owners.
+func (s *Storage) ExportURLs(ctx context.Context, domain string, ownerID int64, fn func(storage.URL) error) error {
+	const op = "storage.sqlite.ExportURLs"
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+urlColumns+` FROM url WHERE domain = ? AND (? = 0 OR owner_id = ?) ORDER BY id`, domain, ownerID, ownerID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		u, err := scanURL(rows)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+
+		if err := fn(u); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ImportURLs saves the links returned by next until it returns io.EOF, in a
+// single transaction. Existing aliases are handled according to policy; with
+// ConflictFail, or if next fails, nothing is imported.
+func (s *Storage) ImportURLs(
+	ctx context.Context,
+	next func() (storage.URL, error),
+	policy storage.ConflictPolicy,
+) (storage.ImportResult, error) {
+	const op = "storage.sqlite.ImportURLs"
+
+	var res storage.ImportResult
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return res, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	insert, err := tx.PrepareContext(ctx, `INSERT INTO url(domain, url, alias, owner_id, created_at, expires_at, redirect_code, forward_query, password_hash, max_clicks, used_clicks, generated, target_host) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return res, fmt.Errorf("%s: %w", op, err)
+	}
+	defer insert.Close()
+
+	update, err := tx.PrepareContext(ctx, `
+		UPDATE url SET url = ?, target_host = ?, owner_id = ?, created_at = ?, expires_at = ?, updated_at = ?,
+			redirect_code = ?, forward_query = ?, password_hash = ?, max_clicks = ?, used_clicks = ?, generated = ?
+		WHERE domain = ? AND alias = ?`)
+	if err != nil {
+		return res, fmt.Errorf("%s: %w", op, err)
+	}
+	defer update.Close()
+
+	for {
+		u, err := next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return storage.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		_, err = insert.ExecContext(
+			ctx, u.Domain, u.URL, u.Alias, u.OwnerID, createdAt(u), utc(u.ExpiresAt), u.RedirectCode, u.ForwardQuery,
+			u.PasswordHash, u.MaxClicks, u.UsedClicks, u.Generated, storage.TargetHost(u.URL),
+		)
+		if err == nil {
+			res.Created++
+			continue
+		}
+		if !isUniqueViolation(err) {
+			return storage.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+		switch policy {
+		case storage.ConflictSkip:
+			res.Skipped++
+		case storage.ConflictOverwrite:
+			_, err := update.ExecContext(
+				ctx, u.URL, storage.TargetHost(u.URL), u.OwnerID, createdAt(u), utc(u.ExpiresAt), time.Now().UTC(),
+				u.RedirectCode, u.ForwardQuery, u.PasswordHash, u.MaxClicks, u.UsedClicks, u.Generated, u.Domain, u.Alias,
+			)
+			if err != nil {
+				return storage.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+			}
+			res.Overwritten++
+		default:
+			return storage.ImportResult{}, fmt.Errorf("%s: alias %q: %w", op, u.Alias, storage.ErrURLExists)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return storage.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return res, nil
+}
+
+// GetURL returns the link of alias on the domain to redirect to. Expired
+// links are reported as storage.ErrURLExpired and exhausted ones as
+// storage.ErrURLExhausted.
+func (s *Storage) GetURL(ctx context.Context, domain, alias string) (storage.URL, error) {
 	const op = "storage.sqlite.GetURL"
 
-	stmt, err := s.db.Prepare(`SELECT url FROM url WHERE alias = ?`)
+	stmt, err := s.db.PrepareContext(ctx, `SELECT `+urlColumns+` FROM url WHERE domain = ? AND alias = ?`)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	u, err := scanURL(stmt.QueryRowContext(ctx, domain, alias))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.URL{}, fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+		}
+
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if u.Expired(time.Now()) {
+		return storage.URL{}, fmt.Errorf("%s: %w", op, storage.ErrURLExpired)
+	}
+	if u.Exhausted() {
+		return storage.URL{}, fmt.Errorf("%s: %w", op, storage.ErrURLExhausted)
+	}
+
+	return u, nil
+}
+
+// FindGeneratedURL returns the oldest link to url on the domain that has a
+// generated alias, does not expire, is not protected or limited and redirects
+// with the default options.
+// A zero ownerID searches the links of all owners.
+func (s *Storage) FindGeneratedURL(ctx context.Context, domain, url string, ownerID int64) (storage.URL, error) {
+	const op = "storage.sqlite.FindGeneratedURL"
+
+	u, err := scanURL(s.db.QueryRowContext(ctx, `SELECT `+urlColumns+` FROM url
+		WHERE domain = ? AND url = ? AND generated AND (? = 0 OR owner_id = ?) AND expires_at IS NULL
+			AND redirect_code = 0 AND NOT forward_query AND password_hash = '' AND max_clicks = 0
+		ORDER BY id LIMIT 1`, domain, url, ownerID, ownerID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.URL{}, fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+		}
+
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return u, nil
+}
+
+// UseClick counts a redirect through the link against its click limit and
+// fails with storage.ErrURLExhausted once the limit is reached. The check and
+// the increment are a single statement, so concurrent redirects cannot
+// overshoot the limit.
+func (s *Storage) UseClick(ctx context.Context, domain, alias string) error {
+	const op = "storage.sqlite.UseClick"
+
+	res, err := s.db.ExecContext(ctx, `UPDATE url SET used_clicks = used_clicks + 1
+		WHERE domain = ? AND alias = ? AND (max_clicks = 0 OR used_clicks < max_clicks)`, domain, alias)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	if n > 0 {
+		return nil
+	}
+
+	var id int64
+	err = s.db.QueryRowContext(ctx, `SELECT id FROM url WHERE domain = ? AND alias = ?`, domain, alias).Scan(&id)
 	if err != nil {
-		return "", fmt.Errorf("%s: %w", op, err)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+		}
+
+		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	var url string
-	err = stmt.QueryRow(alias).Scan(&url)
+	return fmt.Errorf("%s: %w", op, storage.ErrURLExhausted)
+}
+
+// GetURLInfo returns the full link record, including expired links.
+func (s *Storage) GetURLInfo(ctx context.Context, domain, alias string) (storage.URL, error) {
+	const op = "storage.sqlite.GetURLInfo"
+
+	stmt, err := s.db.PrepareContext(ctx, `SELECT `+urlColumns+` FROM url WHERE domain = ? AND alias = ?`)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	u, err := scanURL(stmt.QueryRowContext(ctx, domain, alias))
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return "", fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+			return storage.URL{}, fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+		}
+
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return u, nil
+}
+
+// UpdateURL atomically changes the mutable attributes of a link
+// and keeps its previous state in the history table.
+func (s *Storage) UpdateURL(ctx context.Context, domain, alias string, upd storage.URLUpdate) (storage.URL, error) {
+	const op = "storage.sqlite.UpdateURL"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	u, err := scanURL(tx.QueryRowContext(ctx, `SELECT `+urlColumns+` FROM url WHERE domain = ? AND alias = ?`, domain, alias))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return storage.URL{}, fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+		}
+
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now().UTC()
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO url_history(url_id, url, expires_at, changed_at, changed_by) VALUES(?, ?, ?, ?, ?)`,
+		u.ID, u.URL, utc(u.ExpiresAt), now, upd.ChangedBy,
+	)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if upd.URL != nil {
+		u.URL = *upd.URL
+	}
+	if upd.ClearExpiration {
+		u.ExpiresAt = nil
+	} else if upd.ExpiresAt != nil {
+		u.ExpiresAt = upd.ExpiresAt
+	}
+	if upd.RedirectCode != nil {
+		u.RedirectCode = *upd.RedirectCode
+	}
+	if upd.ForwardQuery != nil {
+		u.ForwardQuery = *upd.ForwardQuery
+	}
+	if upd.PasswordHash != nil {
+		u.PasswordHash = *upd.PasswordHash
+	}
+	if upd.MaxClicks != nil {
+		u.MaxClicks = *upd.MaxClicks
+	}
+	u.UpdatedAt = &now
+
+	_, err = tx.ExecContext(
+		ctx,
+		`UPDATE url SET url = ?, target_host = ?, expires_at = ?, redirect_code = ?, forward_query = ?,
+			password_hash = ?, max_clicks = ?, updated_at = ? WHERE id = ?`,
+		u.URL, storage.TargetHost(u.URL), utc(u.ExpiresAt), u.RedirectCode, u.ForwardQuery, u.PasswordHash, u.MaxClicks, now, u.ID,
+	)
+	if err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return storage.URL{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return u, nil
+}
+
+// URLHistory returns previous states of a link, newest first.
+func (s *Storage) URLHistory(ctx context.Context, domain, alias string) ([]storage.Revision, error) {
+	const op = "storage.sqlite.URLHistory"
+
+	var urlID int64
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM url WHERE domain = ? AND alias = ?`, domain, alias).Scan(&urlID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT url, expires_at, changed_at, changed_by
+		FROM url_history
+		WHERE url_id = ?
+		ORDER BY changed_at DESC, id DESC
+	`, urlID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var revisions []storage.Revision
+	for rows.Next() {
+		var (
+			rev       storage.Revision
+			expiresAt sql.NullTime
+		)
+		if err := rows.Scan(&rev.URL, &expiresAt, &rev.ChangedAt, &rev.ChangedBy); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		rev.ExpiresAt = nullTime(expiresAt)
+
+		revisions = append(revisions, rev)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return revisions, nil
+}
+
+// ListURLs returns links matching the query ordered by q.SortBy and id,
+// together with the number of recorded clicks of every link.
+func (s *Storage) ListURLs(ctx context.Context, q storage.ListQuery) ([]storage.URL, error) {
+	const op = "storage.sqlite.ListURLs"
+
+	where := []string{"u.domain = ?"}
+	args := []any{q.Domain}
+
+	if q.OwnerID != 0 {
+		where = append(where, "u.owner_id = ?")
+		args = append(args, q.OwnerID)
+	}
+	if q.AliasPrefix != "" {
+		where = append(where, "substr(u.alias, 1, ?) = ?")
+		args = append(args, utf8.RuneCountInString(q.AliasPrefix), q.AliasPrefix)
+	}
+	if q.TargetDomain != "" {
+		host, suffix := strings.ToLower(q.TargetDomain), "."+strings.ToLower(q.TargetDomain)
+		where = append(where, "(u.target_host = ? OR substr(u.target_host, -?) = ?)")
+		args = append(args, host, utf8.RuneCountInString(suffix), suffix)
+	}
+
+	column, after := sortKey(q)
+
+	order, cmp := "ASC", ">"
+	if q.Desc {
+		order, cmp = "DESC", "<"
+	}
+
+	if q.After != nil {
+		where = append(where, fmt.Sprintf("(%s %s ? OR (%s = ? AND u.id %s ?))", column, cmp, column, cmp))
+		args = append(args, after, after, q.After.ID)
+	}
+
+	query := `
+		SELECT u.id, u.domain, u.alias, u.url, u.owner_id, u.created_at, u.expires_at, u.max_clicks, u.used_clicks,
+			(SELECT COUNT(*) FROM clicks c WHERE c.url_id = u.id)
+		FROM url u
+		WHERE ` + strings.Join(where, " AND ")
+	query += fmt.Sprintf(" ORDER BY %s %s, u.id %s", column, order, order)
+	if q.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, q.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var urls []storage.URL
+	for rows.Next() {
+		var (
+			u         storage.URL
+			expiresAt sql.NullTime
+		)
+		if err := rows.Scan(
+			&u.ID, &u.Domain, &u.Alias, &u.URL, &u.OwnerID, &u.CreatedAt, &expiresAt, &u.MaxClicks, &u.UsedClicks, &u.Clicks,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
 		}
+		u.ExpiresAt = nullTime(expiresAt)
+
+		urls = append(urls, u)
+	}
 
-		return "", fmt.Errorf("%s: %w", op, err)
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return url, nil
+	return urls, nil
 }
 
-func (s *Storage) DeleteURL(alias string) error {
+// sortKey returns the column to order by and its value in the q.After cursor.
+func sortKey(q storage.ListQuery) (string, any) {
+	var after storage.URL
+	if q.After != nil {
+		after = *q.After
+	}
+
+	switch q.SortBy {
+	case storage.SortByAlias:
+		return "u.alias", after.Alias
+	case storage.SortByURL:
+		return "u.url", after.URL
+	default:
+		return "u.created_at", after.CreatedAt.UTC()
+	}
+}
+
+// NextAliasSeq returns the next value of the counter behind sequential aliases.
+func (s *Storage) NextAliasSeq(ctx context.Context) (int64, error) {
+	const op = "storage.sqlite.NextAliasSeq"
+
+	var n int64
+	if err := s.db.QueryRowContext(ctx, `UPDATE alias_seq SET value = value + 1 RETURNING value`).Scan(&n); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+func (s *Storage) DeleteURL(ctx context.Context, domain, alias string) error {
 	const op = "storage.sqlite.DeleteURL"
 
-	stmt, err := s.db.Prepare(`DELETE FROM url WHERE alias = ?`)
+	stmt, err := s.db.PrepareContext(ctx, `DELETE FROM url WHERE domain = ? AND alias = ?`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	_, err = stmt.ExecContext(ctx, domain, alias)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = stmt.Exec(alias)
+	return nil
+}
+
+// DeleteExpiredURLs removes links that expired at or before the given time
+// and returns the number of removed rows.
+func (s *Storage) DeleteExpiredURLs(ctx context.Context, before time.Time) (int64, error) {
+	const op = "storage.sqlite.DeleteExpiredURLs"
+
+	stmt, err := s.db.PrepareContext(ctx, `DELETE FROM url WHERE expires_at IS NOT NULL AND expires_at <= ?`)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// SaveClicks stores a batch of clicks in a single transaction.
+// Clicks on aliases that no longer exist are silently skipped.
+func (s *Storage) SaveClicks(ctx context.Context, clicks []storage.Click) error {
+	const op = "storage.sqlite.SaveClicks"
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO clicks(url_id, clicked_at, referrer, user_agent, ip_hash, request_id)
+		SELECT id, ?, ?, ?, ?, ? FROM url WHERE domain = ? AND alias = ?
+	`)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer stmt.Close()
+
+	for _, c := range clicks {
+		_, err := stmt.ExecContext(ctx, c.ClickedAt.UTC(), c.Referrer, c.UserAgent, c.IPHash, c.RequestID, c.Domain, c.Alias)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// CountClicks returns the number of recorded clicks of the alias on the domain.
+func (s *Storage) CountClicks(ctx context.Context, domain, alias string) (int64, error) {
+	const op = "storage.sqlite.CountClicks"
+
+	var n int64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM clicks c JOIN url u ON u.id = c.url_id WHERE u.domain = ? AND u.alias = ?
+	`, domain, alias).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return n, nil
+}
+
+// ClickStats returns all-time totals for the alias and a time series
+// bucketed by the query granularity within [q.From, q.To).
+func (s *Storage) ClickStats(
+	ctx context.Context,
+	domain, alias string,
+	q storage.StatsQuery,
+) (storage.ClickStats, error) {
+	const op = "storage.sqlite.ClickStats"
+
+	var stats storage.ClickStats
+
+	var urlID int64
+	err := s.db.QueryRowContext(ctx, `SELECT id FROM url WHERE domain = ? AND alias = ?`, domain, alias).Scan(&urlID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return stats, fmt.Errorf("%s: %w", op, storage.ErrURLNotFound)
+		}
+
+		return stats, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = s.db.QueryRowContext(
+		ctx,
+		`SELECT COUNT(*), COUNT(DISTINCT ip_hash) FROM clicks WHERE url_id = ?`, urlID,
+	).Scan(&stats.TotalClicks, &stats.UniqueVisitors)
+	if err != nil {
+		return stats, fmt.Errorf("%s: %w", op, err)
+	}
+
+	bucket := "%Y-%m-%d 00:00:00"
+	if q.Granularity == storage.GranularityHour {
+		bucket = "%Y-%m-%d %H:00:00"
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT strftime(?, clicked_at) AS bucket, COUNT(*), COUNT(DISTINCT ip_hash)
+		FROM clicks
+		WHERE url_id = ? AND clicked_at >= ? AND clicked_at < ?
+		GROUP BY bucket
+		ORDER BY bucket
+	`, bucket, urlID, q.From.UTC(), q.To.UTC())
+	if err != nil {
+		return stats, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			rawTime string
+			point   storage.StatsPoint
+		)
+		if err := rows.Scan(&rawTime, &point.Clicks, &point.UniqueVisitors); err != nil {
+			return stats, fmt.Errorf("%s: %w", op, err)
+		}
+
+		point.Time, err = time.Parse(time.DateTime, rawTime)
+		if err != nil {
+			return stats, fmt.Errorf("%s: %w", op, err)
+		}
+
+		stats.Series = append(stats.Series, point)
+	}
+
+	if err := rows.Err(); err != nil {
+		return stats, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return stats, nil
+}
+
+// SaveDomain registers the domain or replaces the defaults of a registered one.
+func (s *Storage) SaveDomain(ctx context.Context, d storage.Domain) error {
+	const op = "storage.sqlite.SaveDomain"
+
+	createdAt := d.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO domains(name, alias_length, redirect_code, fallback_url, created_at) VALUES(?, ?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			alias_length = excluded.alias_length,
+			redirect_code = excluded.redirect_code,
+			fallback_url = excluded.fallback_url
+	`, d.Name, d.AliasLength, d.RedirectCode, d.FallbackURL, createdAt.UTC())
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
 	return nil
 }
+
+// ListDomains returns the registered domains ordered by name.
+func (s *Storage) ListDomains(ctx context.Context) ([]storage.Domain, error) {
+	const op = "storage.sqlite.ListDomains"
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT name, alias_length, redirect_code, fallback_url, created_at FROM domains ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var domains []storage.Domain
+	for rows.Next() {
+		var d storage.Domain
+		if err := rows.Scan(&d.Name, &d.AliasLength, &d.RedirectCode, &d.FallbackURL, &d.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		domains = append(domains, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return domains, nil
+}
+
+// DeleteDomain unregisters the domain. It fails with storage.ErrDomainInUse
+// while the domain has links.
+func (s *Storage) DeleteDomain(ctx context.Context, name string) error {
+	const op = "storage.sqlite.DeleteDomain"
+
+	res, err := s.db.ExecContext(ctx, `DELETE FROM domains
+		WHERE name = ? AND NOT EXISTS (SELECT 1 FROM url WHERE domain = ?)`, name, name)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%s: failed to get rows affected: %w", op, err)
+	}
+	if n > 0 {
+		return nil
+	}
+
+	var exists bool
+	err = s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM domains WHERE name = ?)`, name).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		return fmt.Errorf("%s: %w", op, storage.ErrDomainNotFound)
+	}
+
+	return fmt.Errorf("%s: %w", op, storage.ErrDomainInUse)
+}
+
+func isUniqueViolation(err error) bool {
+	var sqliteErr sqlite3.Error
+
+	return errors.As(err, &sqliteErr) && sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique
+}
+
+// urlColumns are the url table columns read by scanURL, in order.
+const urlColumns = `id, domain, alias, url, owner_id, created_at, updated_at, expires_at, redirect_code, forward_query, password_hash,
+	max_clicks, used_clicks, generated`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanURL(row scanner) (storage.URL, error) {
+	var (
+		u         storage.URL
+		updatedAt sql.NullTime
+		expiresAt sql.NullTime
+	)
+
+	err := row.Scan(
+		&u.ID, &u.Domain, &u.Alias, &u.URL, &u.OwnerID, &u.CreatedAt, &updatedAt, &expiresAt,
+		&u.RedirectCode, &u.ForwardQuery, &u.PasswordHash, &u.MaxClicks, &u.UsedClicks, &u.Generated,
+	)
+	if err != nil {
+		return storage.URL{}, err
+	}
+
+	u.UpdatedAt = nullTime(updatedAt)
+	u.ExpiresAt = nullTime(expiresAt)
+
+	return u, nil
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+
+	return &t.Time
+}
+
+// createdAt returns the creation time to store for a new link.
+func createdAt(u storage.URL) time.Time {
+	if u.CreatedAt.IsZero() {
+		return time.Now().UTC()
+	}
+
+	return u.CreatedAt.UTC()
+}
+
+// utc normalizes optional timestamps so that they are stored and compared in UTC.
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+
+	u := t.UTC()
+
+	return &u
+}
@@ -0,0 +1,254 @@
+package sqlite_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/Braendie/url-shortener/internal/storage"
+	"github.com/Braendie/url-shortener/internal/storage/migrator"
+	"github.com/Braendie/url-shortener/internal/storage/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStorage(t *testing.T) *sqlite.Storage {
+	t.Helper()
+
+	s, err := sqlite.New(t.TempDir())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+
+	m, err := s.Migrator()
+	require.NoError(t, err)
+
+	err = m.Up()
+	if !errors.Is(err, migrator.ErrNoChange) {
+		require.NoError(t, err)
+	}
+
+	return s
+}
+
+func TestStorage_Migrations(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	m, err := s.Migrator()
+	require.NoError(t, err)
+
+	// Every migration can be rolled back one by one, and applied again.
+	for {
+		err := m.Down()
+		if errors.Is(err, migrator.ErrNoChange) {
+			break
+		}
+		require.NoError(t, err)
+	}
+
+	version, err := m.Version()
+	require.NoError(t, err)
+	assert.Zero(t, version)
+
+	_, err = s.SaveURL(ctx, storage.URL{URL: "https://google.com", Alias: "google"})
+	assert.Error(t, err)
+
+	require.NoError(t, m.Up())
+	assert.ErrorIs(t, m.Up(), migrator.ErrNoChange)
+
+	_, err = s.SaveURL(ctx, storage.URL{URL: "https://google.com", Alias: "google"})
+	require.NoError(t, err)
+
+	u, err := s.GetURL(ctx, "", "google")
+	require.NoError(t, err)
+	assert.Equal(t, "https://google.com", u.URL)
+}
+
+func TestStorage_UseClick(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	_, err := s.SaveURL(ctx, storage.URL{URL: "https://go.dev", Alias: "go", MaxClicks: 3})
+	require.NoError(t, err)
+
+	for range 3 {
+		require.NoError(t, s.UseClick(ctx, "", "go"))
+	}
+
+	// The link stays exhausted once the last click is used.
+	for range 2 {
+		assert.ErrorIs(t, s.UseClick(ctx, "", "go"), storage.ErrURLExhausted)
+	}
+
+	_, err = s.GetURL(ctx, "", "go")
+	assert.ErrorIs(t, err, storage.ErrURLExhausted)
+
+	assert.ErrorIs(t, s.UseClick(ctx, "", "missing"), storage.ErrURLNotFound)
+}
+
+func TestStorage_ListURLs(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	for _, alias := range []string{"a", "b", "c", "d", "e"} {
+		_, err := s.SaveURL(ctx, storage.URL{URL: "https://example.com/" + alias, Alias: alias, OwnerID: 7})
+		require.NoError(t, err)
+	}
+	_, err := s.SaveURL(ctx, storage.URL{URL: "https://example.com/x", Alias: "x", OwnerID: 8})
+	require.NoError(t, err)
+
+	q := storage.ListQuery{OwnerID: 7, SortBy: storage.SortByAlias, Limit: 2}
+
+	var pages [][]string
+	for {
+		page, err := s.ListURLs(ctx, q)
+		require.NoError(t, err)
+		if len(page) == 0 {
+			break
+		}
+
+		var aliases []string
+		for _, u := range page {
+			aliases = append(aliases, u.Alias)
+		}
+		pages = append(pages, aliases)
+
+		q.After = &page[len(page)-1]
+	}
+
+	assert.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, pages)
+}
+
+func TestStorage_ImportURLs(t *testing.T) {
+	ctx := context.Background()
+
+	urls := func(urls ...storage.URL) func() (storage.URL, error) {
+		return func() (storage.URL, error) {
+			if len(urls) == 0 {
+				return storage.URL{}, io.EOF
+			}
+			u := urls[0]
+			urls = urls[1:]
+
+			return u, nil
+		}
+	}
+
+	batch := []storage.URL{
+		{URL: "https://a.com", Alias: "a"},
+		{URL: "https://b.com", Alias: "taken"},
+	}
+
+	cases := []struct {
+		name    string
+		policy  storage.ConflictPolicy
+		wantRes storage.ImportResult
+		wantErr error
+		// want is the target of each alias after the import, empty if missing.
+		want map[string]string
+	}{
+		{
+			name:    "skip",
+			policy:  storage.ConflictSkip,
+			wantRes: storage.ImportResult{Created: 1, Skipped: 1},
+			want:    map[string]string{"a": "https://a.com", "taken": "https://google.com"},
+		},
+		{
+			name:    "overwrite",
+			policy:  storage.ConflictOverwrite,
+			wantRes: storage.ImportResult{Created: 1, Overwritten: 1},
+			want:    map[string]string{"a": "https://a.com", "taken": "https://b.com"},
+		},
+		{
+			name:    "fail",
+			policy:  storage.ConflictFail,
+			wantErr: storage.ErrURLExists,
+			want:    map[string]string{"a": "", "taken": "https://google.com"},
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := newStorage(t)
+
+			_, err := s.SaveURL(ctx, storage.URL{URL: "https://google.com", Alias: "taken"})
+			require.NoError(t, err)
+
+			res, err := s.ImportURLs(ctx, urls(batch...), tc.policy)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tc.wantRes, res)
+			}
+
+			for alias, want := range tc.want {
+				u, err := s.GetURL(ctx, "", alias)
+				if want == "" {
+					assert.ErrorIs(t, err, storage.ErrURLNotFound)
+					continue
+				}
+				require.NoError(t, err)
+				assert.Equal(t, want, u.URL)
+			}
+		})
+	}
+}
+
+func TestStorage_ListURLs_TargetDomain(t *testing.T) {
+	ctx := context.Background()
+
+	s := newStorage(t)
+
+	urls := map[string]string{
+		"exact":     "https://Google.com/search",
+		"subdomain": "https://mail.google.com",
+		"port":      "http://user@google.com:8080/x",
+		"query":     "https://evil.com/?q=google.com",
+		"lookalike": "https://notgoogle.com",
+		"path":      "https://evil.com/google.com",
+	}
+	for alias, u := range urls {
+		_, err := s.SaveURL(ctx, storage.URL{URL: u, Alias: alias})
+		require.NoError(t, err)
+	}
+
+	list := func() []string {
+		page, err := s.ListURLs(ctx, storage.ListQuery{TargetDomain: "Google.com", SortBy: storage.SortByAlias, Limit: 10})
+		require.NoError(t, err)
+
+		var aliases []string
+		for _, u := range page {
+			aliases = append(aliases, u.Alias)
+		}
+
+		return aliases
+	}
+
+	want := []string{"exact", "port", "subdomain"}
+	assert.Equal(t, want, list())
+
+	// Rows written before the host was stored are filled in by the migration.
+	m, err := s.Migrator()
+	require.NoError(t, err)
+	require.NoError(t, m.To(13))
+	version, err := m.Version()
+	require.NoError(t, err)
+	require.Equal(t, int64(13), version)
+	require.NoError(t, m.Up())
+	assert.Equal(t, want, list())
+
+	// Updates keep the stored host in sync.
+	target := "https://go.dev"
+	_, err = s.UpdateURL(ctx, "", "exact", storage.URLUpdate{URL: &target})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"port", "subdomain"}, list())
+}
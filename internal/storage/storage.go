@@ -1,8 +1,211 @@
 package storage
 
-import "errors"
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
 
 var (
 	ErrURLNotFound = errors.New("url not found")
 	ErrURLExists   = errors.New("url exists")
+	ErrURLExpired  = errors.New("url expired")
+	// ErrURLExhausted means the link has been followed MaxClicks times.
+	ErrURLExhausted = errors.New("url exhausted")
+
+	ErrDomainNotFound = errors.New("domain not found")
+	// ErrDomainInUse means the domain still has links.
+	ErrDomainInUse = errors.New("domain in use")
 )
+
+// URL is a short link record.
+type URL struct {
+	ID int64
+	// Domain is the host the link is served on, empty for the default one.
+	// Aliases are unique per domain.
+	Domain    string
+	Alias     string
+	URL       string
+	OwnerID   int64
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+	ExpiresAt *time.Time
+	// Generated marks links whose alias was generated rather than chosen.
+	// It is only written when the link is saved.
+	Generated bool
+	// RedirectCode is the status of redirects through the link, zero means
+	// the server default.
+	RedirectCode int
+	// ForwardQuery appends the query string of a redirect request to URL.
+	ForwardQuery bool
+	// PasswordHash protects the link with a password, see password.Hash.
+	// Empty means the link is public.
+	PasswordHash string
+	// MaxClicks limits how many times the link can be followed, zero means
+	// no limit. UsedClicks counts the redirects taken towards the limit.
+	MaxClicks  int64
+	UsedClicks int64
+	// Clicks is the number of recorded redirects. It is only filled in by ListURLs.
+	Clicks int64
+}
+
+// TargetHost returns the lower-cased host of the target URL without port,
+// which the storages keep to filter links by ListQuery.TargetDomain.
+func TargetHost(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+
+	return strings.ToLower(u.Hostname())
+}
+
+// Expired reports whether the link has an expiration time that is not after now.
+func (u URL) Expired(now time.Time) bool {
+	return u.ExpiresAt != nil && !u.ExpiresAt.After(now)
+}
+
+// Exhausted reports whether the link has been followed MaxClicks times.
+func (u URL) Exhausted() bool {
+	return u.MaxClicks > 0 && u.UsedClicks >= u.MaxClicks
+}
+
+// RemainingClicks returns how many more times the link can be followed,
+// or nil if it is not limited.
+func (u URL) RemainingClicks() *int64 {
+	if u.MaxClicks == 0 {
+		return nil
+	}
+
+	n := max(u.MaxClicks-u.UsedClicks, 0)
+
+	return &n
+}
+
+// SaveResult is the outcome of saving one link of a batch. Err is
+// ErrURLExists if the alias is taken.
+type SaveResult struct {
+	ID  int64
+	Err error
+}
+
+// ConflictPolicy decides what an import does with an alias that already exists.
+type ConflictPolicy string
+
+const (
+	ConflictSkip      ConflictPolicy = "skip"
+	ConflictOverwrite ConflictPolicy = "overwrite"
+	ConflictFail      ConflictPolicy = "fail"
+)
+
+// ImportResult counts the outcome of an import.
+type ImportResult struct {
+	Created     int64 `json:"created"`
+	Overwritten int64 `json:"overwritten"`
+	Skipped     int64 `json:"skipped"`
+}
+
+// URLUpdate describes a change of the mutable attributes of a link.
+// Nil fields are left unchanged.
+type URLUpdate struct {
+	URL *string
+	// ExpiresAt sets a new expiration time unless ClearExpiration is set.
+	ExpiresAt       *time.Time
+	ClearExpiration bool
+	RedirectCode    *int
+	ForwardQuery    *bool
+	// PasswordHash sets a new password, an empty one removes the protection.
+	PasswordHash *string
+	// MaxClicks sets a new click limit, zero removes it. Clicks already
+	// taken keep counting towards the new limit.
+	MaxClicks *int64
+	ChangedBy int64
+}
+
+// Revision is a previous state of a link kept when the link is updated.
+type Revision struct {
+	URL       string
+	ExpiresAt *time.Time
+	ChangedAt time.Time
+	ChangedBy int64
+}
+
+type SortField string
+
+const (
+	SortByCreatedAt SortField = "created_at"
+	SortByAlias     SortField = "alias"
+	SortByURL       SortField = "url"
+)
+
+// ListQuery filters and paginates links of Domain. Other zero values
+// disable the corresponding filter. TargetDomain matches links whose
+// TargetHost is the lower-cased domain or one of its subdomains.
+type ListQuery struct {
+	Domain       string
+	OwnerID      int64
+	AliasPrefix  string
+	TargetDomain string
+	SortBy       SortField
+	Desc         bool
+	Limit        int
+	// After is the last link of the previous page. Only its ID and
+	// the field selected by SortBy are used.
+	After *URL
+}
+
+// Click is a single redirect through a short link.
+type Click struct {
+	Domain    string
+	Alias     string
+	ClickedAt time.Time
+	Referrer  string
+	UserAgent string
+	// ClientIP is the raw client address. It is never persisted:
+	// the clicks writer replaces it with IPHash before saving.
+	ClientIP  string
+	IPHash    string
+	RequestID string
+}
+
+type Granularity string
+
+const (
+	GranularityHour Granularity = "hour"
+	GranularityDay  Granularity = "day"
+)
+
+// StatsQuery selects the time series returned with click statistics.
+type StatsQuery struct {
+	Granularity Granularity
+	From        time.Time
+	To          time.Time
+}
+
+// StatsPoint is a single bucket of a click time series.
+type StatsPoint struct {
+	Time           time.Time
+	Clicks         int64
+	UniqueVisitors int64
+}
+
+// ClickStats is the aggregated click statistics of a short link.
+type ClickStats struct {
+	TotalClicks    int64
+	UniqueVisitors int64
+	Series         []StatsPoint
+}
+
+// Domain is a custom host that serves its own links. Its zero values fall
+// back to the server defaults.
+type Domain struct {
+	Name string
+	// AliasLength is the base length of aliases generated for the domain.
+	AliasLength  int
+	RedirectCode int
+	// FallbackURL is where requests for unknown aliases are redirected to
+	// instead of getting 404.
+	FallbackURL string
+	CreatedAt   time.Time
+}